@@ -0,0 +1,331 @@
+package api
+
+import (
+	"crypto/subtle"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scottmcleodjr/rekl/bus"
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/handler"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+const (
+	DefaultAddr = "localhost:7373"
+	Source      = "http" // The source of events from the API
+	eventBuffer = 64     // Events held for a slow client before they are dropped
+)
+
+//go:embed web
+var webFiles embed.FS
+
+// Server is the HTTP control API and web UI.  Actions go through the
+// same handler functions as the TUI, and their events go through the
+// bus, so the TUI and every client see the same events.
+type Server struct {
+	keyer  handler.Keyer
+	events *bus.Bus
+	cfg    *config.Config
+	host   string // The host of the listen address
+	token  string
+}
+
+// New returns a new Server for the listen address addr.  An empty token
+// allows all requests, otherwise requests must have the token as a
+// bearer token or as the token query parameter.
+func New(keyer handler.Keyer, events *bus.Bus, cfg *config.Config, addr string, token string) *Server {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	return &Server{keyer: keyer, events: events, cfg: cfg, host: host, token: token}
+}
+
+// Handler returns the HTTP handler for the API and web UI.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/send", s.handleSend)
+	mux.HandleFunc("/api/memory/", s.handleMemory)
+	mux.HandleFunc("/api/stop", s.handleStop)
+	mux.HandleFunc("/api/speed", s.handleSpeed)
+	mux.HandleFunc("/api/config", s.handleConfig)
+	mux.HandleFunc("/api/events", s.handleEvents)
+	web, _ := fs.Sub(webFiles, "web") // The embedded directory always exists
+	mux.Handle("/", http.FileServer(http.FS(web)))
+	return s.authorize(mux)
+}
+
+// authorize checks that requests are for the listen address, and that
+// API requests come from the web UI or other software rather than from
+// another web page, and have the token.  The web UI files are not
+// secret, and the page passes its token on to the API.
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.allowHost(r.Host) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("host %q is not the listen address", r.Host))
+			return
+		}
+		if !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		err := checkOrigin(r)
+		if err != nil {
+			writeError(w, http.StatusForbidden, err)
+			return
+		}
+		if s.token != "" {
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if token == "" || token == r.Header.Get("Authorization") {
+				token = r.URL.Query().Get("token")
+			}
+			if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+				writeError(w, http.StatusUnauthorized, errors.New("missing or wrong token"))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowHost returns if a Host header names the listen address.  IP
+// addresses and localhost are always allowed, as only other names can
+// be pointed at the Server by another web site.
+func (s *Server) allowHost(hostPort string) bool {
+	host, _, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		host = hostPort
+	}
+	host = strings.Trim(host, "[]")
+	return net.ParseIP(host) != nil || strings.EqualFold(host, "localhost") || strings.EqualFold(host, s.host)
+}
+
+// checkOrigin returns an error if an API request was made by a page
+// from another origin, or changes something without a JSON body type.
+// Browsers can send a few body types to any site without asking it
+// first, but not JSON.
+func checkOrigin(r *http.Request) error {
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || u.Host != r.Host {
+			return fmt.Errorf("requests from %s are not allowed", origin)
+		}
+	}
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		return errors.New("requests must have the application/json content type")
+	}
+	return nil
+}
+
+type sendRequest struct {
+	Text string `json:"text"`
+}
+
+type sentResponse struct {
+	Sent string `json:"sent"`
+}
+
+func (s *Server) handleSend(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	var req sendRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unable to parse request: %w", err))
+		return
+	}
+	if strings.TrimSpace(req.Text) == "" {
+		writeError(w, http.StatusBadRequest, errors.New("nothing to send"))
+		return
+	}
+	message, err := handler.SendText(s.keyer, s.events.From(Source), req.Text)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, sentResponse{Sent: message})
+}
+
+func (s *Server) handleMemory(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	position, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/memory/"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, errors.New("unable to parse memory position"))
+		return
+	}
+	message, err := handler.SendMemory(s.keyer, s.events.From(Source), s.cfg, position)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, sentResponse{Sent: message})
+}
+
+func (s *Server) handleStop(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	handler.Stop(s.keyer, s.events.From(Source))
+	writeJSON(w, struct{}{})
+}
+
+type speedBody struct {
+	Speed int `json:"speed"`
+}
+
+func (s *Server) handleSpeed(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet, http.MethodPut, http.MethodPost) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		var req speedBody
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("unable to parse request: %w", err))
+			return
+		}
+		err = handler.SetSpeed(s.events.From(Source), s.cfg, req.Speed)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	writeJSON(w, speedBody{Speed: s.cfg.Speed()})
+}
+
+type configResponse struct {
+	Speed      int      `json:"speed"`
+	Farnsworth int      `json:"farnsworth"`
+	Weight     int      `json:"weight"`
+	Call       string   `json:"call"`
+	Bank       string   `json:"bank"`     // The active memory bank
+	Messages   []string `json:"messages"` // By memory position, in the active bank
+	Labels     []string `json:"labels"`   // By memory position
+}
+
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	resp := configResponse{
+		Speed:      s.cfg.Speed(),
+		Farnsworth: s.cfg.Farnsworth(),
+		Weight:     s.cfg.Weight(),
+		Call:       s.cfg.Call(),
+		Bank:       s.cfg.Bank(),
+	}
+	for position := 0; position <= config.MaxPosition; position++ {
+		m, _ := s.cfg.Memory(position) // Error is not reachable here
+		resp.Messages = append(resp.Messages, m.Message)
+		resp.Labels = append(resp.Labels, m.Label)
+	}
+	writeJSON(w, resp)
+}
+
+// handleEvents streams events to the client as Server-Sent Events.  A
+// kind query parameter, like "sent,aborted", streams only those kinds.
+// Changes to the config are streamed as events of bus.KindConfig, so
+// clients know when to read it again.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+
+	var kinds []bus.Kind
+	for _, kind := range strings.Split(r.URL.Query().Get("kind"), ",") {
+		if kind != "" {
+			kinds = append(kinds, bus.Kind(kind))
+		}
+	}
+	events, cancel := s.events.Subscribe(eventBuffer, kinds...)
+	defer cancel()
+	var changes <-chan config.Change // Nil if config events are not wanted
+	if wantsKind(kinds, bus.KindConfig) {
+		var cancelChanges func()
+		changes, cancelChanges = s.cfg.Subscribe(eventBuffer)
+		defer cancelChanges()
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		var event bus.Event
+		select {
+		case <-r.Context().Done():
+			return
+		case <-changes:
+			event = bus.Event{Time: time.Now().UTC(), Kind: bus.KindConfig, Source: "config", Level: tui.LevelDebug}
+		case event = <-events:
+		}
+		data, _ := json.Marshal(event)
+		_, err := fmt.Fprintf(w, "data: %s\n\n", data)
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// wantsKind returns if kind is in kinds, or if there are no kinds, as
+// every kind is wanted then.
+func wantsKind(kinds []bus.Kind, kind bus.Kind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return len(kinds) == 0
+}
+
+// allowMethods writes an error and returns false if the request
+// method is not one of methods.
+func allowMethods(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, method := range methods {
+		if r.Method == method {
+			return true
+		}
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+	return false
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
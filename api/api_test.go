@@ -0,0 +1,288 @@
+package api_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/scottmcleodjr/rekl/api"
+	"github.com/scottmcleodjr/rekl/bus"
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+type testKeyer struct {
+	mu      sync.Mutex
+	queued  []string
+	drained int
+}
+
+func (k *testKeyer) QueueMessage(message string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.queued = append(k.queued, message)
+	return nil
+}
+
+func (k *testKeyer) DrainSendQueue() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.drained++
+}
+
+type testEvents struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (e *testEvents) WriteEvent(level tui.Level, message string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.messages = append(e.messages, message)
+}
+
+func newTestServer(t *testing.T, token string) (*httptest.Server, *testKeyer, *config.Config) {
+	keyer := &testKeyer{}
+	cfg := config.New()
+	cfg.SetMessage(3, "CQ TEST")
+	server := httptest.NewServer(api.New(keyer, bus.New(&testEvents{}), cfg, api.DefaultAddr, token).Handler())
+	t.Cleanup(server.Close)
+	return server, keyer, cfg
+}
+
+func do(t *testing.T, method, url, body string, header map[string]string) (int, string) {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	if host, ok := header["Host"]; ok {
+		req.Host = host
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var sb strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		sb.WriteString(scanner.Text())
+	}
+	return resp.StatusCode, sb.String()
+}
+
+// jsonHeader is the header of API requests with a JSON body.
+var jsonHeader = map[string]string{"Content-Type": "application/json"}
+
+func TestServer(t *testing.T) {
+	server, keyer, cfg := newTestServer(t, "")
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		want   string
+	}{
+		{method: "POST", path: "/api/send", body: `{"text":"cq test"}`, status: 200, want: `{"sent":"CQ TEST"}`},
+		{method: "POST", path: "/api/send", body: `{"text":"cq ~"}`, status: 400},
+		{method: "POST", path: "/api/send", body: `{"text":`, status: 400},
+		{method: "POST", path: "/api/send", body: `{"text":" "}`, status: 400},
+		{method: "GET", path: "/api/send", status: 405},
+		{method: "POST", path: "/api/memory/3", status: 200, want: `{"sent":"CQ TEST"}`},
+		{method: "POST", path: "/api/memory/99", status: 400},
+		{method: "POST", path: "/api/memory/x", status: 404},
+		{method: "POST", path: "/api/stop", status: 200, want: `{}`},
+		{method: "GET", path: "/api/speed", status: 200, want: `{"speed":18}`},
+		{method: "PUT", path: "/api/speed", body: `{"speed":25}`, status: 200, want: `{"speed":25}`},
+		{method: "PUT", path: "/api/speed", body: `{"speed":99}`, status: 400},
+		{method: "DELETE", path: "/api/speed", status: 405},
+	}
+
+	for _, test := range tests {
+		status, got := do(t, test.method, server.URL+test.path, test.body, jsonHeader)
+		if status != test.status {
+			t.Errorf("got status %d, want %d for %s %s", status, test.status, test.method, test.path)
+		}
+		if test.want != "" && got != test.want {
+			t.Errorf("got %s, want %s for %s %s", got, test.want, test.method, test.path)
+		}
+	}
+
+	if len(keyer.queued) != 2 {
+		t.Errorf("got %v queued, want 2 messages", keyer.queued)
+	}
+	if keyer.drained != 1 {
+		t.Errorf("got %d drains, want 1", keyer.drained)
+	}
+	if cfg.Speed() != 25 {
+		t.Errorf("got speed %d, want 25", cfg.Speed())
+	}
+}
+
+func TestServerConfig(t *testing.T) {
+	server, _, cfg := newTestServer(t, "")
+	cfg.SetLabel(3, "CQ")
+
+	_, body := do(t, "GET", server.URL+"/api/config", "", nil)
+	var got struct {
+		Speed    int      `json:"speed"`
+		Messages []string `json:"messages"`
+		Labels   []string `json:"labels"`
+		Bank     string   `json:"bank"`
+	}
+	err := json.Unmarshal([]byte(body), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Speed != config.InitSpeed || got.Bank != config.DefaultBanks[0] {
+		t.Errorf("got speed %d and bank %s, want %d and %s", got.Speed, got.Bank, config.InitSpeed, config.DefaultBanks[0])
+	}
+	if len(got.Messages) != config.MaxPosition+1 || got.Messages[3] != "CQ TEST" {
+		t.Errorf("got messages %q, want CQ TEST at 3", got.Messages)
+	}
+	if len(got.Labels) != config.MaxPosition+1 || got.Labels[3] != "CQ" {
+		t.Errorf("got labels %q, want CQ at 3", got.Labels)
+	}
+}
+
+func TestServerToken(t *testing.T) {
+	server, _, _ := newTestServer(t, "secret")
+
+	tests := []struct {
+		path   string
+		header map[string]string
+		status int
+	}{
+		{path: "/api/speed", status: 401},
+		{path: "/api/speed?token=wrong", status: 401},
+		{path: "/api/speed", header: map[string]string{"Authorization": "secret"}, status: 401},
+		{path: "/api/speed?token=secret", status: 200},
+		{path: "/api/speed", header: map[string]string{"Authorization": "Bearer secret"}, status: 200},
+		{path: "/", status: 200},
+		{path: "/app.js", status: 200},
+	}
+
+	for _, test := range tests {
+		status, _ := do(t, "GET", server.URL+test.path, "", test.header)
+		if status != test.status {
+			t.Errorf("got status %d, want %d for %s with %v", status, test.status, test.path, test.header)
+		}
+	}
+}
+
+func TestServerOrigin(t *testing.T) {
+	server, keyer, _ := newTestServer(t, "")
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		header map[string]string
+		status int
+	}{
+		{method: "POST", path: "/api/send", body: `{"text":"cq"}`, header: map[string]string{"Content-Type": "text/plain"}, status: 403},
+		{method: "POST", path: "/api/stop", status: 403}, // No content type
+		{method: "POST", path: "/api/stop", header: map[string]string{"Content-Type": "application/json; charset=utf-8"}, status: 200},
+		{method: "POST", path: "/api/stop", header: map[string]string{"Content-Type": "application/json", "Origin": "http://example.com"}, status: 403},
+		{method: "POST", path: "/api/stop", header: map[string]string{"Content-Type": "application/json", "Origin": server.URL}, status: 200},
+		{method: "GET", path: "/api/speed", header: map[string]string{"Host": "rebind.example.com:7373"}, status: 403},
+		{method: "GET", path: "/", header: map[string]string{"Host": "rebind.example.com"}, status: 403},
+		{method: "GET", path: "/api/speed", header: map[string]string{"Host": "LOCALHOST:7373"}, status: 200},
+		{method: "GET", path: "/api/speed", header: map[string]string{"Host": "[::1]:7373"}, status: 200},
+	}
+
+	for _, test := range tests {
+		status, _ := do(t, test.method, server.URL+test.path, test.body, test.header)
+		if status != test.status {
+			t.Errorf("got status %d, want %d for %s %s with %v", status, test.status, test.method, test.path, test.header)
+		}
+	}
+	if len(keyer.queued) != 0 {
+		t.Errorf("got %v queued, want nothing sent", keyer.queued)
+	}
+}
+
+func TestServerEvents(t *testing.T) {
+	keyer := &testKeyer{}
+	events := &testEvents{}
+	b := bus.New(events)
+	server := httptest.NewServer(api.New(keyer, b, config.New(), api.DefaultAddr, "").Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("got content type %q, want text/event-stream", got)
+	}
+
+	// The subscription is made before the headers are sent
+	b.WriteEvent(tui.LevelError, "Key failed")
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	var event bus.Event
+	err = json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event)
+	if err != nil {
+		t.Fatalf("unable to parse %q: %s", line, err)
+	}
+	if event.Level != tui.LevelError || event.Kind != bus.KindError || event.Message != "Key failed" {
+		t.Errorf("got %+v, want error event Key failed", event)
+	}
+	if len(events.messages) != 1 {
+		t.Errorf("got %v written to the TUI, want the event", events.messages)
+	}
+}
+
+func TestServerConfigEvents(t *testing.T) {
+	b := bus.New(&testEvents{})
+	cfg := config.New()
+	server := httptest.NewServer(api.New(&testKeyer{}, b, cfg, api.DefaultAddr, "").Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/events?kind=sent,config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+
+	// Key state is left out, and a config change is streamed
+	b.Publish(bus.Event{Kind: bus.KindKeyState, Down: true})
+	cfg.SetSpeed(30)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	var event bus.Event
+	err = json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event)
+	if err != nil {
+		t.Fatalf("unable to parse %q: %s", line, err)
+	}
+	if event.Kind != bus.KindConfig || event.Message != "" {
+		t.Errorf("got %+v, want a config event with no message", event)
+	}
+}
+
+func TestServerWebUI(t *testing.T) {
+	server, _, _ := newTestServer(t, "")
+
+	status, body := do(t, "GET", server.URL+"/", "", nil)
+	if status != 200 {
+		t.Errorf("got status %d, want 200", status)
+	}
+	if !strings.Contains(body, `id="stop"`) {
+		t.Errorf("got %q, want the web UI with a stop button", body)
+	}
+}
@@ -0,0 +1,110 @@
+"use strict";
+
+// The token is taken from the page URL, as in http://HOST/?token=TOKEN
+const token = new URLSearchParams(location.search).get("token");
+
+function apiURL(path, params) {
+  const query = new URLSearchParams(params);
+  if (token) {
+    query.set("token", token);
+  }
+  return query.toString() ? path + "?" + query : path;
+}
+
+async function request(method, path, body) {
+  // The API takes only JSON for requests that change anything
+  const options = {method: method, headers: {"Content-Type": "application/json"}};
+  if (body !== undefined) {
+    options.body = JSON.stringify(body);
+  }
+  const resp = await fetch(apiURL(path), options);
+  const data = await resp.json();
+  if (!resp.ok) {
+    throw new Error(data.error);
+  }
+  return data;
+}
+
+const eventsView = document.getElementById("events");
+
+function showEvent(level, message) {
+  const line = document.createElement("div");
+  line.className = level;
+  line.textContent = message;
+  eventsView.appendChild(line);
+  eventsView.scrollTop = eventsView.scrollHeight;
+}
+
+// Errors from requests are shown like the TUI shows them, but only here,
+// since the request never reached the keyer
+async function act(method, path, body) {
+  try {
+    return await request(method, path, body);
+  } catch (err) {
+    showEvent("error", err.message);
+  }
+}
+
+const speed = document.getElementById("speed");
+const speedValue = document.getElementById("speed-value");
+const memories = document.getElementById("memories");
+
+async function refresh() {
+  const cfg = await act("GET", "/api/config");
+  if (!cfg) {
+    return;
+  }
+  document.getElementById("bank").textContent = cfg.bank;
+  document.getElementById("call").textContent = cfg.call;
+  if (document.activeElement !== speed) {
+    speed.value = cfg.speed;
+  }
+  speedValue.textContent = cfg.speed;
+
+  // Put 0 last like on a keyboard, and only show the memories past 9 if
+  // they are set
+  memories.replaceChildren();
+  for (let i = 1; i <= cfg.messages.length; i++) {
+    const position = i % cfg.messages.length;
+    if (position > 9 && !cfg.messages[position] && !cfg.labels[position]) {
+      continue;
+    }
+    const button = document.createElement("button");
+    button.type = "button";
+    button.textContent = position + ": " + (cfg.labels[position] || cfg.messages[position] || "");
+    button.title = cfg.messages[position] || "";
+    button.disabled = !cfg.messages[position];
+    button.addEventListener("click", () => act("POST", "/api/memory/" + position));
+    memories.appendChild(button);
+  }
+}
+
+document.getElementById("send").addEventListener("submit", async (e) => {
+  e.preventDefault();
+  const text = document.getElementById("text");
+  if (await act("POST", "/api/send", {text: text.value})) {
+    text.value = "";
+  }
+});
+
+document.getElementById("stop").addEventListener("click", () => act("POST", "/api/stop"));
+
+speed.addEventListener("input", () => {
+  speedValue.textContent = speed.value;
+});
+speed.addEventListener("change", () => act("PUT", "/api/speed", {speed: Number(speed.value)}));
+
+// Events are shown like the TUI shows them, without debug events, and the
+// config is read again when it changes.  Key state is left out, as it
+// comes with every element sent.
+const kinds = "message,sent,aborted,speed,error,qso,config";
+const source = new EventSource(apiURL("/api/events", {kind: kinds}));
+source.addEventListener("message", (e) => {
+  const event = JSON.parse(e.data);
+  if (event.kind === "config") {
+    refresh();
+  } else if (event.message && event.level !== "debug") {
+    showEvent(event.level, event.message);
+  }
+});
+source.addEventListener("open", refresh);
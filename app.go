@@ -2,48 +2,293 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/gdamore/tcell/v2"
 	"github.com/scottmcleodjr/cwkeyer"
+	"github.com/scottmcleodjr/rekl/bus"
 	"github.com/scottmcleodjr/rekl/config"
 	"github.com/scottmcleodjr/rekl/handler"
+	"github.com/scottmcleodjr/rekl/history"
+	"github.com/scottmcleodjr/rekl/paddle"
+	"github.com/scottmcleodjr/rekl/status"
 	"github.com/scottmcleodjr/rekl/tui"
 )
 
+const usage = `Usage: rekl [command] [flags]
+
+Commands:
+    (none)    Run the interactive REKL
+    send      Send text from the arguments or stdin as CW and exit
+    fox       Run as an ARDF fox transmitter
+    wav       Render text from the arguments or stdin to a WAV file
+    decode    Decode CW from a WAV file or raw PCM on stdin
+    train     Practice copying CW ("rekl train koch")
+    sim       Run a simulated contest pileup
+    practice  Practice sending with a straight key or paddle
+    ctl       Send a command or text to a running REKL
+
+Run "rekl [command] -h" for the flags of a command.
+`
+
 func main() {
+	args := os.Args[1:]
+	command := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command = args[0]
+		args = args[1:]
+	}
 
-	beep := flag.Bool("beep", false, "If the REKL should use a Beep Key instead of a Serial DTR Key")
-	portName := flag.String("port", "/tty/USB0", "Serial port name for Serial DTR Key")
-	flag.Parse()
+	switch command {
+	case "":
+		runInteractive(args)
+	case "send":
+		runSend(args)
+	case "fox":
+		runFox(args)
+	case "wav":
+		runWav(args)
+	case "decode":
+		runDecode(args)
+	case "train":
+		runTrain(args)
+	case "sim":
+		runSim(args)
+	case "practice":
+		runPractice(args)
+	case "ctl":
+		runCtl(args)
+	case "help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		log.Fatalf("unknown command %q", command)
+	}
+}
 
-	var key cwkeyer.Key
-	var err error
-	if *beep {
-		key, err = cwkeyer.NewBeepKey(700, 48000, 1200) // Using the suggested values
-	} else {
-		key, err = cwkeyer.NewSerialDTRKey(*portName, 115200) // Using the suggested value
+// runInteractive runs the REKL with the terminal UI.
+func runInteractive(args []string) {
+	flags := flag.NewFlagSet("rekl", flag.ExitOnError)
+	keyFlags := addKeyFlags(flags, false)
+	rxFlags := addReceiveFlags(flags)
+	inFlags := addPaddleFlags(flags)
+	httpFlags := addAPIFlags(flags)
+	socketFlags := addCtlFlags(flags)
+	txFlags := addTranscriptFlags(flags)
+	historyPath := flags.String("history", userConfigPath("history"), "Path of the saved input history, or empty to not save it")
+	configPath := flags.String("config", userConfigPath("config.json"), "Path of the config file, with settings and key bindings")
+	uiName := flags.String("ui", "tui", `The user interface, "tui" or "plain" for plain lines for screen readers`)
+	flags.Parse(args)
+
+	switch *uiName {
+	case "tui":
+	case "plain":
+		if *rxFlags.path != "" {
+			log.Fatal("the plain user interface does not show received CW, use -ui tui with -rx")
+		}
+		runPlain(flags, *configPath, keyFlags, inFlags, httpFlags, socketFlags, txFlags)
+		return
+	default:
+		log.Fatalf("unknown user interface %q, want tui or plain", *uiName)
 	}
+
+	hist, err := history.Load(*historyPath, history.DefaultMax)
 	if err != nil {
-		log.Fatalf("unable to create key: %s", err)
+		log.Fatalf("unable to load history: %s", err)
 	}
-
-	cfg := config.New()
-	keyer := cwkeyer.New(cfg, key)
 	ui := tui.New()
-	ui.SetInputCapture(handler.InputHandler(keyer, ui, cfg))
+	// Events go through the bus so web clients see them too
+	events := bus.New(ui)
+	eventUI := busUI{TUI: ui, events: events}
+	cfg := keyFlags.newConfig()
+	loadConfigFile(flags, *configPath, keyFlags, cfg)
+	cfg.SetPath(*configPath)
+	applyTheme(ui, cfg)
+	k, closeKeying := startKeying(keyFlags, txFlags, inFlags, cfg, events)
+	defer closeKeying()
+	sendKeyer := k.send
 	ui.WriteEvent(tui.LevelInfo, config.WelcomeText)
+	handler.ShowKeyLabels(ui, cfg)
+	ui.ShowStatusBar()
+	go showStatus(ui, cfg, k.key, k.keyer)
+
+	inputHandler := handler.InputHandler(sendKeyer, eventUI, cfg)
+	editor := handler.NewEditor(eventUI, hist, cfg)
+	ui.SetInputCapture(editor.Handler(inputHandler))
+	httpFlags.start(sendKeyer, events, cfg)
+	closeSocket := socketFlags.start(sendKeyer, queuedUI{busUI: eventUI}, cfg)
+	defer closeSocket()
+
+	if *rxFlags.path != "" {
+		receiver := rxFlags.start(ui, events.From("receive"))
+		ui.SetInputCapture(editor.Handler(func(capture *tcell.EventKey) *tcell.EventKey {
+			if capture.Key() == tcell.KeyCtrlG {
+				grabCallsign(receiver, eventUI, cfg)
+				return nil
+			}
+			return inputHandler(capture)
+		}))
+	}
+
+	err = ui.RunApp()
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// busUI is the TUI with its events written through the bus.
+type busUI struct {
+	*tui.TUI
+	events *bus.Bus
+}
+
+func (ui busUI) WriteEvent(level tui.Level, message string) {
+	ui.Publish(bus.Message(level, message))
+}
+
+func (ui busUI) Publish(e bus.Event) {
+	ui.events.From("tui").Publish(e)
+}
+
+// keying is the key and keyer of the interactive REKL.
+type keying struct {
+	key   *status.Key
+	keyer *cwkeyer.Keyer
+	send  handler.Keyer // The keyer, through the transcript if there is one
+}
+
+// startKeying opens the key, the transcript and the paddle, and sends
+// the messages queued to the keyer in the background.  The returned
+// function closes the transcript and the paddle.
+func startKeying(kf keyFlags, tf transcriptFlags, pf paddleFlags, cfg *config.Config, events *bus.Bus) (keying, func()) {
+	recorder, closeTranscript := tf.open(cfg, kf.name(), events.From("transcript"))
+	rawKey := kf.openKey()
+	if recorder != nil {
+		rawKey = recorder.Key(rawKey)
+	}
+	key := status.NewKey(rawKey, kf.name(), events)
+	src := pf.open()
+	// The paddle and the keyer take turns with the key
+	var keyerKey cwkeyer.Key = key
+	shared := paddle.NewShared(key)
+	if src != nil {
+		keyerKey = shared.Keyer()
+	}
+	keyer := cwkeyer.New(cfg, keyerKey)
+	// Messages are queued through the recorder so they are in the transcript
+	var sendKeyer handler.Keyer = keyer
+	if recorder != nil {
+		sendKeyer = recorder.Keyer(keyer)
+	}
+
+	// Using the paddle stops any queued messages
+	closePaddle := func() {}
+	if src != nil {
+		sendKeyer = resumeKeyer{Keyer: sendKeyer, shared: shared, queueEmpty: keyer.SendQueueIsEmpty, cfg: cfg}
+		drain := sendKeyer.DrainSendQueue
+		stop := make(chan struct{})
+		pf.start(src, shared.Paddle(), cfg, events.From("paddle"), stop, func() { shared.BreakIn(drain) })
+		closePaddle = func() {
+			close(stop)
+			src.Close()
+		}
+	}
 
 	go func() {
+		keyerEvents := events.From("keyer")
 		for {
 			err := keyer.ProcessSendQueue(false)
 			if err != nil {
-				ui.WriteEvent(tui.LevelError, err.Error())
+				keyerEvents.WriteEvent(tui.LevelError, err.Error())
+				// The key went wrong partway, so the transcript starts over
+				if recorder != nil {
+					recorder.Abort()
+				}
 			}
 		}
 	}()
 
-	err = ui.RunApp()
+	return keying{key: key, keyer: keyer, send: sendKeyer}, func() {
+		closePaddle()
+		closeTranscript()
+	}
+}
+
+// loadConfigFile applies a config file or exits if that fails.  A
+// speed flag that is set overrides the speed in the file.
+func loadConfigFile(flags *flag.FlagSet, path string, kf keyFlags, cfg *config.Config) {
+	f, err := config.LoadFile(path)
 	if err != nil {
-		log.Fatal(err)
+		log.Fatalf("unable to load config: %s", err)
+	}
+	err = cfg.Apply(f)
+	if err != nil {
+		log.Fatalf("unable to load config %s: %s", path, err)
+	}
+	err = handler.CheckBindings(cfg.Bindings())
+	if err != nil {
+		log.Fatalf("unable to load config %s: %s", path, err)
+	}
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "speed" {
+			cfg.SetSpeed(*kf.speed) // Checked by newConfig
+		}
+	})
+}
+
+// userConfigPath returns the path of a file in the user's REKL
+// config directory, or in the working directory if there is none.
+func userConfigPath(name string) string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return name
+	}
+	return filepath.Join(dir, "rekl", name)
+}
+
+// keyFlags are the flags shared by every command that keys CW.
+type keyFlags struct {
+	beep     *bool
+	portName *string
+	speed    *int
+}
+
+// addKeyFlags adds the key flags to a flag set.  The beep argument
+// is the default for using a Beep Key.
+func addKeyFlags(flags *flag.FlagSet, beep bool) keyFlags {
+	return keyFlags{
+		beep:     flags.Bool("beep", beep, "If the REKL should use a Beep Key instead of a Serial DTR Key"),
+		portName: flags.String("port", "/tty/USB0", "Serial port name for Serial DTR Key"),
+		speed:    flags.Int("speed", config.InitSpeed, "Initial CW speed in WPM"),
+	}
+}
+
+// openKey opens the configured Key or exits if that fails.
+func (kf keyFlags) openKey() cwkeyer.Key {
+	var key cwkeyer.Key
+	var err error
+	if *kf.beep {
+		key, err = cwkeyer.NewBeepKey(700, 48000, 1200) // Using the suggested values
+	} else {
+		key, err = cwkeyer.NewSerialDTRKey(*kf.portName, 115200) // Using the suggested value
+	}
+	if err != nil {
+		log.Fatalf("unable to create key: %s", err)
+	}
+	return key
+}
+
+// newConfig returns a Config with the configured speed or exits if
+// the speed is out of range.
+func (kf keyFlags) newConfig() *config.Config {
+	cfg := config.New()
+	err := cfg.SetSpeed(*kf.speed)
+	if err != nil {
+		log.Fatalf("unable to set speed: %s", err)
 	}
+	return cfg
 }
@@ -0,0 +1,170 @@
+package bus
+
+import (
+	"sync"
+	"time"
+
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+// Kind is the type of an Event.
+type Kind string
+
+const (
+	KindMessage      Kind = "message" // KindMessage is any other event view line
+	KindSent         Kind = "sent"    // KindSent is text queued for sending
+	KindAborted      Kind = "aborted" // KindAborted is the queued text being stopped
+	KindSpeedChanged Kind = "speed"   // KindSpeedChanged is a new CW speed
+	KindError        Kind = "error"   // KindError is something that failed
+	KindQSOLogged    Kind = "qso"     // KindQSOLogged is a contact added to the log
+	KindKeyState     Kind = "key"     // KindKeyState is the key going down or up, only for subscribers
+	KindConfig       Kind = "config"  // KindConfig is a change to the settings, only for API clients
+)
+
+// Kinds are all the kinds of Event.
+var Kinds = []Kind{KindMessage, KindSent, KindAborted, KindSpeedChanged, KindError, KindQSOLogged, KindKeyState, KindConfig}
+
+// Event is something that happened, with the event view line for it if
+// there is one.  The fields after Message are set for some kinds.
+type Event struct {
+	Time    time.Time `json:"time"`
+	Kind    Kind      `json:"kind"`
+	Source  string    `json:"source,omitempty"` // Where it happened, like "tui", "http" or "keyer"
+	Level   tui.Level `json:"level"`
+	Message string    `json:"message,omitempty"`
+	Text    string    `json:"text,omitempty"`   // For KindSent
+	Speed   int       `json:"speed,omitempty"`  // For KindSpeedChanged
+	Call    string    `json:"call,omitempty"`   // For KindQSOLogged
+	Serial  int       `json:"serial,omitempty"` // For KindQSOLogged
+	Down    bool      `json:"down,omitempty"`   // For KindKeyState
+}
+
+// Message returns an event for a plain event view line, an error if
+// the level is tui.LevelError.
+func Message(level tui.Level, message string) Event {
+	kind := KindMessage
+	if level == tui.LevelError {
+		kind = KindError
+	}
+	return Event{Kind: kind, Level: level, Message: message}
+}
+
+// Writer is the part of the TUI that shows events.
+type Writer interface {
+	WriteEvent(level tui.Level, message string)
+}
+
+// KindWriter is a Writer that also shows the kind of events, like the
+// TUI does for filtering them.
+type KindWriter interface {
+	WriteEventKind(level tui.Level, kind string, message string)
+}
+
+// Publisher publishes typed events.
+type Publisher interface {
+	Publish(e Event)
+}
+
+// Publish publishes an event if w is a Publisher, and otherwise writes
+// its message to w, for the writers that only show events.
+func Publish(w Writer, e Event) {
+	if p, ok := w.(Publisher); ok {
+		p.Publish(e)
+		return
+	}
+	if e.Message != "" {
+		w.WriteEvent(e.Level, e.Message)
+	}
+}
+
+// Bus writes events to the TUI and publishes them to subscribers, so
+// the TUI and remote clients show the same events.  Bus is safe for
+// use from any goroutine.
+type Bus struct {
+	writer      Writer
+	mu          sync.Mutex
+	subscribers map[chan Event]map[Kind]bool
+}
+
+// New returns a new Bus writing events to writer.  A nil writer only
+// publishes events.
+func New(writer Writer) *Bus {
+	return &Bus{writer: writer, subscribers: map[chan Event]map[Kind]bool{}}
+}
+
+// WriteEvent publishes a plain event view line.
+func (b *Bus) WriteEvent(level tui.Level, message string) {
+	b.Publish(Message(level, message))
+}
+
+// Publish writes the message of an event to the TUI, if it has one,
+// and publishes the event to subscribers.  Subscribers that are not
+// keeping up miss the event rather than block.
+func (b *Bus) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	if b.writer != nil && e.Message != "" {
+		if w, ok := b.writer.(KindWriter); ok {
+			w.WriteEventKind(e.Level, string(e.Kind), e.Message)
+		} else {
+			b.writer.WriteEvent(e.Level, e.Message)
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for subscriber, kinds := range b.subscribers {
+		if len(kinds) != 0 && !kinds[e.Kind] {
+			continue
+		}
+		select {
+		case subscriber <- e:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel of events of the kinds given, or of every
+// kind if none are, holding up to buffer events for a slow subscriber,
+// and a function to end the subscription.
+func (b *Bus) Subscribe(buffer int, kinds ...Kind) (<-chan Event, func()) {
+	events := make(chan Event, buffer)
+	filter := map[Kind]bool{}
+	for _, kind := range kinds {
+		filter[kind] = true
+	}
+	b.mu.Lock()
+	b.subscribers[events] = filter
+	b.mu.Unlock()
+	return events, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscribers, events)
+	}
+}
+
+// From returns a Source publishing to the Bus from a source.
+func (b *Bus) From(source string) Source {
+	return Source{bus: b, source: source}
+}
+
+// Source is a Writer and Publisher for the events from one source, like
+// "http" or "keyer".  Events that already have a source keep it.
+type Source struct {
+	bus    *Bus
+	source string
+}
+
+// WriteEvent publishes a plain event view line from the source.
+func (s Source) WriteEvent(level tui.Level, message string) {
+	s.Publish(Message(level, message))
+}
+
+// Publish publishes an event from the source.
+func (s Source) Publish(e Event) {
+	if e.Source == "" {
+		e.Source = s.source
+	}
+	s.bus.Publish(e)
+}
@@ -0,0 +1,144 @@
+package bus_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/scottmcleodjr/rekl/bus"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+type testWriter struct {
+	messages []string
+}
+
+func (w *testWriter) WriteEvent(level tui.Level, message string) {
+	w.messages = append(w.messages, message)
+}
+
+func TestBus(t *testing.T) {
+	writer := &testWriter{}
+	b := bus.New(writer)
+	first, cancelFirst := b.Subscribe(1)
+	second, cancelSecond := b.Subscribe(1)
+	defer cancelSecond()
+
+	b.WriteEvent(tui.LevelError, "Key failed")
+	for _, events := range []<-chan bus.Event{first, second} {
+		event := <-events
+		if event.Level != tui.LevelError || event.Kind != bus.KindError || event.Message != "Key failed" {
+			t.Errorf("got %+v, want error event Key failed", event)
+		}
+	}
+
+	// A full subscriber misses events and an ended one gets none
+	cancelFirst()
+	b.WriteEvent(tui.LevelInfo, "One")
+	b.WriteEvent(tui.LevelInfo, "Two")
+	if len(first) != 0 {
+		t.Errorf("got %d events after the subscription ended, want 0", len(first))
+	}
+	if event := <-second; event.Message != "One" {
+		t.Errorf("got %q, want One", event.Message)
+	}
+	if len(writer.messages) != 3 {
+		t.Errorf("got %v written, want all 3 events", writer.messages)
+	}
+}
+
+func TestSubscribeKinds(t *testing.T) {
+	b := bus.New(nil)
+	events, cancel := b.Subscribe(10, bus.KindSent, bus.KindAborted)
+	defer cancel()
+
+	b.Publish(bus.Event{Kind: bus.KindSent, Level: tui.LevelInfo, Text: "CQ"})
+	b.WriteEvent(tui.LevelInfo, "Not sent")
+	b.Publish(bus.Event{Kind: bus.KindSpeedChanged, Level: tui.LevelInfo, Speed: 30})
+	b.Publish(bus.Event{Kind: bus.KindAborted, Level: tui.LevelInfo})
+
+	for _, want := range []bus.Kind{bus.KindSent, bus.KindAborted} {
+		event := <-events
+		if event.Kind != want {
+			t.Errorf("got %s event, want %s", event.Kind, want)
+		}
+		if event.Time.IsZero() {
+			t.Errorf("got no time for %s event, want the time it was published", event.Kind)
+		}
+	}
+	if len(events) != 0 {
+		t.Errorf("got %d more events, want only the kinds subscribed to", len(events))
+	}
+}
+
+func TestFrom(t *testing.T) {
+	writer := &testWriter{}
+	b := bus.New(writer)
+	events, cancel := b.Subscribe(10)
+	defer cancel()
+
+	source := b.From("keyer")
+	source.WriteEvent(tui.LevelError, "Key failed")
+	source.Publish(bus.Event{Kind: bus.KindKeyState, Level: tui.LevelDebug, Down: true})
+	source.Publish(bus.Event{Kind: bus.KindSent, Source: "http", Level: tui.LevelInfo, Message: "Sending: CQ"})
+
+	tests := []struct {
+		kind       bus.Kind
+		wantSource string
+	}{
+		{kind: bus.KindError, wantSource: "keyer"},
+		{kind: bus.KindKeyState, wantSource: "keyer"},
+		{kind: bus.KindSent, wantSource: "http"}, // Keeps its source
+	}
+	for _, test := range tests {
+		event := <-events
+		if event.Kind != test.kind || event.Source != test.wantSource {
+			t.Errorf("got %s event from %q, want %s event from %q", event.Kind, event.Source, test.kind, test.wantSource)
+		}
+	}
+	// Only the events with messages are written
+	if len(writer.messages) != 2 {
+		t.Errorf("got %v written, want the 2 events with messages", writer.messages)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	writer := &testWriter{}
+	bus.Publish(writer, bus.Event{Kind: bus.KindSent, Level: tui.LevelInfo, Message: "Sending: CQ"})
+	bus.Publish(writer, bus.Event{Kind: bus.KindKeyState, Level: tui.LevelDebug, Down: true})
+	if len(writer.messages) != 1 || writer.messages[0] != "Sending: CQ" {
+		t.Errorf("got %v written, want only the message", writer.messages)
+	}
+
+	b := bus.New(nil)
+	events, cancel := b.Subscribe(1)
+	defer cancel()
+	bus.Publish(b, bus.Event{Kind: bus.KindSent, Level: tui.LevelInfo, Text: "CQ"})
+	if event := <-events; event.Text != "CQ" {
+		t.Errorf("got %+v, want the sent event published", event)
+	}
+}
+
+type testKindWriter struct {
+	kinds []string
+}
+
+func (w *testKindWriter) WriteEvent(level tui.Level, message string) {
+	w.kinds = append(w.kinds, "")
+}
+
+func (w *testKindWriter) WriteEventKind(level tui.Level, kind string, message string) {
+	w.kinds = append(w.kinds, kind)
+}
+
+func TestKindWriter(t *testing.T) {
+	writer := &testKindWriter{}
+	b := bus.New(writer)
+	b.WriteEvent(tui.LevelInfo, "Ready")
+	b.Publish(bus.Event{Kind: bus.KindSent, Level: tui.LevelInfo, Message: "Sending: CQ"})
+	b.WriteEvent(tui.LevelError, "Key failed")
+
+	want := []string{"message", "sent", "error"}
+	if strings.Join(writer.kinds, " ") != strings.Join(want, " ") {
+		t.Errorf("got kinds %q written, want %q", writer.kinds, want)
+	}
+}
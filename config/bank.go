@@ -0,0 +1,96 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// DefaultBanks are the names of the memory banks in a new Config, for
+// running and for search and pounce.
+var DefaultBanks = []string{"Run", "S&P"}
+
+// bank is a named set of memories.
+type bank struct {
+	name     string
+	memories [MaxPosition + 1]Memory
+}
+
+// Bank returns the name of the active memory bank.
+func (cfg *Config) Bank() string {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	return cfg.banks[cfg.active].name
+}
+
+// Banks returns the names of the memory banks in order.
+func (cfg *Config) Banks() []string {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	names := make([]string, len(cfg.banks))
+	for i, b := range cfg.banks {
+		names[i] = b.name
+	}
+	return names
+}
+
+// SetBank makes the named memory bank active.  Bank names are
+// not case sensitive.
+func (cfg *Config) SetBank(name string) error {
+	cfg.mu.Lock()
+	i, ok := cfg.findBank(name)
+	if ok {
+		cfg.active = i
+	}
+	cfg.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("unknown memory bank %q", name)
+	}
+	cfg.publish(Change{Kind: KindBank})
+	return nil
+}
+
+// NextBank makes the next memory bank active, after the last
+// going back to the first, and returns its name.
+func (cfg *Config) NextBank() string {
+	cfg.mu.Lock()
+	cfg.active = (cfg.active + 1) % len(cfg.banks)
+	name := cfg.banks[cfg.active].name
+	cfg.mu.Unlock()
+	cfg.publish(Change{Kind: KindBank})
+	return name
+}
+
+// AddBank adds an empty memory bank after the others.
+func (cfg *Config) AddBank(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > MaxLabel {
+		return fmt.Errorf("memory bank name must be 1 to %d characters", MaxLabel)
+	}
+	for _, r := range name {
+		if !unicode.IsGraphic(r) || unicode.IsSpace(r) {
+			return fmt.Errorf("memory bank name contains unsupported rune %q", r)
+		}
+	}
+	cfg.mu.Lock()
+	_, exists := cfg.findBank(name)
+	if !exists {
+		cfg.banks = append(cfg.banks, bank{name: name})
+	}
+	cfg.mu.Unlock()
+	if exists {
+		return fmt.Errorf("memory bank %q already exists", name)
+	}
+	cfg.publish(Change{Kind: KindBank})
+	return nil
+}
+
+// findBank returns the index of the named bank.  The lock must be held.
+func (cfg *Config) findBank(name string) (int, bool) {
+	for i, b := range cfg.banks {
+		if strings.EqualFold(b.name, strings.TrimSpace(name)) {
+			return i, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,58 @@
+package config_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/scottmcleodjr/rekl/config"
+)
+
+func TestBanks(t *testing.T) {
+	cfg := config.New()
+	if !reflect.DeepEqual(cfg.Banks(), config.DefaultBanks) || cfg.Bank() != config.DefaultBanks[0] {
+		t.Fatalf("got banks %v with %s active, want the default banks", cfg.Banks(), cfg.Bank())
+	}
+
+	cfg.SetMessage(1, "CQ TEST K3GDS")
+	if got := cfg.NextBank(); got != "S&P" {
+		t.Errorf("got %s, want S&P after NextBank", got)
+	}
+	cfg.SetMessage(1, "K3GDS")
+	err := cfg.SetBank("run")
+	if err != nil || cfg.Bank() != "Run" {
+		t.Errorf("got %s and %v, want Run", cfg.Bank(), err)
+	}
+	if message, _ := cfg.Message(1); message != "CQ TEST K3GDS" {
+		t.Errorf("got %q in Run, want CQ TEST K3GDS", message)
+	}
+	cfg.NextBank()
+	if message, _ := cfg.Message(1); message != "K3GDS" {
+		t.Errorf("got %q in S&P, want K3GDS", message)
+	}
+	if got := cfg.NextBank(); got != "Run" {
+		t.Errorf("got %s, want NextBank to go back to Run", got)
+	}
+
+	tests := []struct {
+		name        string
+		errorWanted bool
+	}{
+		{name: "DX", errorWanted: false},
+		{name: "dx", errorWanted: true}, // Already exists
+		{name: "", errorWanted: true},
+		{name: "TWO WORDS", errorWanted: true},
+		{name: "TOOLONGNAME", errorWanted: true},
+	}
+	for _, test := range tests {
+		err := cfg.AddBank(test.name)
+		if test.errorWanted && (err == nil) {
+			t.Errorf("got nil, want error adding bank %q", test.name)
+		}
+		if !test.errorWanted && (err != nil) {
+			t.Errorf("got error %s, want nil adding bank %q", err, test.name)
+		}
+	}
+	if err := cfg.SetBank("nope"); err == nil {
+		t.Error("got nil, want error for an unknown bank")
+	}
+}
@@ -5,14 +5,24 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/scottmcleodjr/cwkeyer"
+	"github.com/scottmcleodjr/rekl/keys"
+	"github.com/scottmcleodjr/rekl/morse"
+	"github.com/scottmcleodjr/rekl/wav"
 )
 
 const (
 	InitSpeed   = 18 // Moderate, what I use normally
 	MinSpeed    = 5  // Very very slow
 	MaxSpeed    = 50 // Very very fast
+	InitWeight  = 50 // Standard dit to gap ratio
+	MinWeight   = 25 // Very light
+	MaxWeight   = 75 // Very heavy
+	MaxPosition = 24 // Memory positions are 0 to MaxPosition, for F1 to F24
+	MaxLabel    = 8  // Longest memory label, to fit the label bar
 	WelcomeText = `[::b]Welcome to the K3GDS REKL[::-]
 
 [::i]Written by Scott K3GDS
@@ -20,37 +30,36 @@ v0.2.2[::-]
 
 Enter "\help" for a list of supported commands.
 
-`
-	HelpText = `
-A command should be entered as input with no additional text on the line.
-A hotkey can be used at any time without submitting the input field.
-Any other inputs will be sent as CW if all characters are sendable.
-
-    "\help"       COMMAND    Display this help text
-    "\quit"       COMMAND    Exit the program
-    "\clear"      COMMAND    Clear the display
-	"\config"     COMMAND    Display the current REKL configurations
-    "\speed"      COMMAND    Display the current WPM speed
-    "\speed N"    COMMAND    Set the CW speed to N WPM
-    [Up Arrow]    HOTKEY     Increment the CW speed by 1 WPM
-    [Down Arrow]  HOTKEY     Decrement the CW speed by 1 WPM
-	"\N ..."      COMMAND    Save a message at memory position N
-	[Shift+N]     HOTKEY     Send the message at memory position N
-    [ESC[]         HOTKEY     Stop sending CW immediately
 `
 )
 
 // Config holds current configuration state for the REKL application.
 // Config is also the cwkeyer.SpeedProvider for the cwkeyer.Keyer.
 type Config struct {
-	mu       sync.Mutex
-	speed    int
-	messages [10]string
+	mu          sync.Mutex // Guards the settings
+	speed       int
+	farnsworth  int
+	weight      int
+	call        string
+	banks       []bank
+	active      int // Index of the active bank
+	path        string
+	transcript  Transcript
+	theme       Theme
+	layout      Layout
+	wav         wav.Settings
+	bindings    keys.Bindings
+	subMu       sync.Mutex // Guards the subscribers
+	subscribers map[chan Change]struct{}
 }
 
 // New returns a new Config.
 func New() *Config {
-	return &Config{speed: InitSpeed}
+	cfg := &Config{speed: InitSpeed, weight: InitWeight, wav: wav.DefaultSettings(), bindings: keys.Default(), subscribers: map[chan Change]struct{}{}}
+	for _, name := range DefaultBanks {
+		cfg.banks = append(cfg.banks, bank{name: name})
+	}
+	return cfg
 }
 
 // Speed returns the current CW WPM speed.  Speed is
@@ -70,8 +79,9 @@ func (cfg *Config) SetSpeed(speed int) error {
 		return fmt.Errorf("new speed is above maximum of %d", MaxSpeed)
 	}
 	cfg.mu.Lock()
-	defer cfg.mu.Unlock()
 	cfg.speed = speed
+	cfg.mu.Unlock()
+	cfg.publish(Change{Kind: KindSpeed})
 	return nil
 }
 
@@ -85,32 +95,337 @@ func (cfg *Config) DecrementSpeed() error {
 	return cfg.SetSpeed(cfg.Speed() - 1)
 }
 
-// Message returns the message at position N or an empty
-// string if that message is not set.
-func (cfg *Config) Message(position int) (string, error) {
-	if position < 0 || position > 9 {
-		return "", errors.New("message number out of range")
+// Farnsworth returns the Farnsworth speed in WPM, or zero
+// if Farnsworth spacing is off.
+func (cfg *Config) Farnsworth() int {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	return cfg.farnsworth
+}
+
+// SetFarnsworth sets the Farnsworth speed in WPM.  A speed
+// of zero turns Farnsworth spacing off.
+func (cfg *Config) SetFarnsworth(speed int) error {
+	if speed != 0 && speed < MinSpeed {
+		return fmt.Errorf("new Farnsworth speed is below minimum of %d", MinSpeed)
+	}
+	if speed > MaxSpeed {
+		return fmt.Errorf("new Farnsworth speed is above maximum of %d", MaxSpeed)
+	}
+	cfg.mu.Lock()
+	cfg.farnsworth = speed
+	cfg.mu.Unlock()
+	cfg.publish(Change{Kind: KindSpeed})
+	return nil
+}
+
+// Weight returns the key down weight as a percentage.
+func (cfg *Config) Weight() int {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	return cfg.weight
+}
+
+// SetWeight sets the key down weight as a percentage.
+func (cfg *Config) SetWeight(weight int) error {
+	if weight < MinWeight {
+		return fmt.Errorf("new weight is below minimum of %d", MinWeight)
 	}
+	if weight > MaxWeight {
+		return fmt.Errorf("new weight is above maximum of %d", MaxWeight)
+	}
+	cfg.mu.Lock()
+	cfg.weight = weight
+	cfg.mu.Unlock()
+	cfg.publish(Change{Kind: KindSpeed})
+	return nil
+}
+
+// Timing returns the current speed, Farnsworth, and weight settings.
+func (cfg *Config) Timing() morse.Timing {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	return morse.Timing{WPM: cfg.speed, Farnsworth: cfg.farnsworth, Weight: cfg.weight}
+}
+
+// Call returns the current call, the station being worked.
+func (cfg *Config) Call() string {
 	cfg.mu.Lock()
 	defer cfg.mu.Unlock()
-	return cfg.messages[position], nil
+	return cfg.call
+}
+
+// SetCall sets the current call.  An empty call clears it.
+func (cfg *Config) SetCall(call string) error {
+	call = strings.ToUpper(strings.TrimSpace(call))
+	for _, r := range call {
+		if r == ' ' || !cwkeyer.IsKeyable(r) {
+			return fmt.Errorf("call contains unsupported rune %c", r)
+		}
+	}
+	cfg.mu.Lock()
+	cfg.call = call
+	cfg.mu.Unlock()
+	cfg.publish(Change{Kind: KindCall})
+	return nil
+}
+
+// Memory is a labelled message saved at a memory position.
+type Memory struct {
+	Label   string
+	Message string
+}
+
+// IsEmpty returns if the Memory has no label or message.
+func (m Memory) IsEmpty() bool {
+	return m.Label == "" && m.Message == ""
+}
+
+// Name returns the label, or the start of the message if there is no
+// label, for showing the Memory in little space.
+func (m Memory) Name() string {
+	if m.Label != "" {
+		return m.Label
+	}
+	if len(m.Message) > MaxLabel {
+		return m.Message[:MaxLabel] // Messages are all ASCII
+	}
+	return m.Message
+}
+
+func checkPosition(position int) error {
+	if position < 0 || position > MaxPosition {
+		return errors.New("message number out of range")
+	}
+	return nil
+}
+
+// memories returns the memories of the active bank.  The lock must be held.
+func (cfg *Config) memories() *[MaxPosition + 1]Memory {
+	return &cfg.banks[cfg.active].memories
+}
+
+// Memory returns the Memory at position N in the active bank.  The
+// other memory methods also use the active bank.
+func (cfg *Config) Memory(position int) (Memory, error) {
+	err := checkPosition(position)
+	if err != nil {
+		return Memory{}, err
+	}
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	return cfg.memories()[position], nil
+}
+
+// Message returns the message at position N or an empty
+// string if that message is not set.
+func (cfg *Config) Message(position int) (string, error) {
+	m, err := cfg.Memory(position)
+	return m.Message, err
 }
 
 // SetMessage sets the message at position N to the string
-// message argument.
+// message argument.  The label is left as it is.
 func (cfg *Config) SetMessage(position int, message string) error {
-	if position < 0 || position > 9 {
-		return errors.New("message number out of range")
+	err := checkPosition(position)
+	if err != nil {
+		return err
+	}
+	message, err = cleanMessage(message)
+	if err != nil {
+		return err
 	}
+	cfg.mu.Lock()
+	cfg.memories()[position].Message = message
+	bank := cfg.banks[cfg.active].name
+	cfg.mu.Unlock()
+	cfg.publish(Change{Kind: KindMemory, Bank: bank, Position: position})
+	return nil
+}
+
+// SetLabel sets the label of the memory at position N.  An empty
+// label removes it.
+func (cfg *Config) SetLabel(position int, label string) error {
+	err := checkPosition(position)
+	if err != nil {
+		return err
+	}
+	label, err = cleanLabel(label)
+	if err != nil {
+		return err
+	}
+	cfg.mu.Lock()
+	cfg.memories()[position].Label = label
+	bank := cfg.banks[cfg.active].name
+	cfg.mu.Unlock()
+	cfg.publish(Change{Kind: KindMemory, Bank: bank, Position: position})
+	return nil
+}
+
+// AddMemory saves a labelled message at the first empty memory
+// position, in keyboard order with 0 last, and returns the position.
+func (cfg *Config) AddMemory(label, message string) (int, error) {
+	label, err := cleanLabel(label)
+	if err != nil {
+		return 0, err
+	}
+	message, err = cleanMessage(message)
+	if err != nil {
+		return 0, err
+	}
+	cfg.mu.Lock()
+	for _, position := range Positions() {
+		if cfg.memories()[position].IsEmpty() {
+			cfg.memories()[position] = Memory{Label: label, Message: message}
+			bank := cfg.banks[cfg.active].name
+			cfg.mu.Unlock()
+			cfg.publish(Change{Kind: KindMemory, Bank: bank, Position: position})
+			return position, nil
+		}
+	}
+	cfg.mu.Unlock()
+	return 0, errors.New("all memory positions are in use")
+}
+
+// ClearMemory removes the label and message at position N.
+func (cfg *Config) ClearMemory(position int) error {
+	err := checkPosition(position)
+	if err != nil {
+		return err
+	}
+	cfg.mu.Lock()
+	cfg.memories()[position] = Memory{}
+	bank := cfg.banks[cfg.active].name
+	cfg.mu.Unlock()
+	cfg.publish(Change{Kind: KindMemory, Bank: bank, Position: position})
+	return nil
+}
+
+// Positions returns the memory positions in keyboard order, with 0 last.
+func Positions() []int {
+	positions := make([]int, 0, MaxPosition+1)
+	for position := 1; position <= MaxPosition; position++ {
+		positions = append(positions, position)
+	}
+	return append(positions, 0)
+}
+
+func cleanMessage(message string) (string, error) {
 	message = strings.ToUpper(strings.TrimSpace(message))
 	for _, r := range message {
 		if !cwkeyer.IsKeyable(r) {
-			return fmt.Errorf("message contains unsupported rune %c", r)
+			return "", fmt.Errorf("message contains unsupported rune %c", r)
 		}
 	}
+	return message, nil
+}
+
+func cleanLabel(label string) (string, error) {
+	label = strings.TrimSpace(label)
+	if utf8.RuneCountInString(label) > MaxLabel {
+		return "", fmt.Errorf("label is longer than %d characters", MaxLabel)
+	}
+	for _, r := range label {
+		if !unicode.IsPrint(r) {
+			return "", fmt.Errorf("label contains unsupported rune %q", r)
+		}
+	}
+	return label, nil
+}
+
+// Bindings returns the key bindings.  The Bindings must not be changed.
+func (cfg *Config) Bindings() keys.Bindings {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	return cfg.bindings
+}
+
+// SetBindings replaces the key bindings.
+func (cfg *Config) SetBindings(bindings keys.Bindings) {
+	cfg.mu.Lock()
+	cfg.bindings = bindings
+	cfg.mu.Unlock()
+	cfg.publish(Change{Kind: KindBindings})
+}
+
+// Path returns the path of the config file, or an empty string if
+// there is none.
+func (cfg *Config) Path() string {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	return cfg.path
+}
+
+// SetPath sets the path of the config file, for saving the Config.
+func (cfg *Config) SetPath(path string) {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	cfg.path = path
+}
+
+// Transcript returns where the transcript is kept.  The Path is empty
+// if there is no transcript.
+func (cfg *Config) Transcript() Transcript {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	return cfg.transcript
+}
+
+// SetTranscript sets where the transcript is kept, for the next time
+// the REKL starts.
+func (cfg *Config) SetTranscript(t Transcript) {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	cfg.transcript = t
+}
+
+// Theme returns the colours of the TUI.
+func (cfg *Config) Theme() Theme {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	return cfg.theme.copy()
+}
+
+// SetTheme sets the colours of the TUI, for the next time the REKL
+// starts.
+func (cfg *Config) SetTheme(t Theme) {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	cfg.theme = t.copy()
+}
+
+// Layout returns the sizes of the panes of the TUI.
+func (cfg *Config) Layout() Layout {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	return cfg.layout
+}
+
+// SetLayout sets the sizes of the panes of the TUI, for the next time
+// the REKL starts.
+func (cfg *Config) SetLayout(l Layout) {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	cfg.layout = l
+}
+
+// WavSettings returns the audio settings for WAV files of the memories.
+func (cfg *Config) WavSettings() wav.Settings {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	return cfg.wav
+}
+
+// SetWavSettings sets the audio settings for WAV files of the memories.
+// SetWavSettings returns an error if the settings can not be rendered.
+func (cfg *Config) SetWavSettings(s wav.Settings) error {
+	err := s.Validate()
+	if err != nil {
+		return err
+	}
 	cfg.mu.Lock()
 	defer cfg.mu.Unlock()
-	cfg.messages[position] = message
+	cfg.wav = s
 	return nil
 }
 
@@ -118,11 +433,21 @@ func (cfg *Config) SetMessage(position int, message string) error {
 func (cfg *Config) String() string {
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("\nSpeed: %d WPM\n", cfg.Speed()))
+	sb.WriteString(fmt.Sprintf("Farnsworth: %d WPM\n", cfg.Farnsworth()))
+	sb.WriteString(fmt.Sprintf("Weight: %d%%\n", cfg.Weight()))
+	sb.WriteString(fmt.Sprintf("Call: %s\n", cfg.Call()))
+	sb.WriteString(fmt.Sprintf("Bank: %s\n", cfg.Bank()))
 	sb.WriteString("Messages:\n")
-	for i := 1; i <= 10; i++ {
-		position := i % 10                  // Put 0 last like on a keyboard
-		message, _ := cfg.Message(position) // Error is not reachable here
-		sb.WriteString(fmt.Sprintf("    %d: %s\n", position, message))
+	for _, position := range Positions() {
+		m, _ := cfg.Memory(position) // Error is not reachable here
+		if m.IsEmpty() && position > 9 {
+			continue // Only the memories on the digit row are always shown
+		}
+		if m.Label == "" {
+			sb.WriteString(fmt.Sprintf("    %d: %s\n", position, m.Message))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("    %d: [%s] %s\n", position, m.Label, m.Message))
 	}
 	return sb.String()
 }
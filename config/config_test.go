@@ -1,9 +1,15 @@
 package config_test
 
 import (
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/keys"
+	"github.com/scottmcleodjr/rekl/wav"
 )
 
 func TestSetSpeed(t *testing.T) {
@@ -94,8 +100,9 @@ func TestMessage(t *testing.T) {
 		{position: 0, errorWanted: false},
 		{position: 5, errorWanted: false},
 		{position: 9, errorWanted: false},
+		{position: config.MaxPosition, errorWanted: false},
 		{position: -1, errorWanted: true},
-		{position: 10, errorWanted: true},
+		{position: config.MaxPosition + 1, errorWanted: true},
 		{position: 42, errorWanted: true},
 	}
 
@@ -124,7 +131,7 @@ func TestSetMessage(t *testing.T) {
 		{position: 6, message: "invalid $  ", errorWanted: true},  // Invalid char
 		{position: 7, message: "invalid %  ", errorWanted: true},  // Invalid char
 		{position: -1, message: "badposition", errorWanted: true}, // Bad position
-		{position: 10, message: "badposition", errorWanted: true}, // Bad position
+		{position: 25, message: "badposition", errorWanted: true}, // Bad position
 	}
 
 	cfg := config.New()
@@ -146,7 +153,7 @@ func TestSetMessage(t *testing.T) {
 	expectedMessages[1] = "5NN TU"
 	expectedMessages[3] = "NEWMESSAGE"
 
-	for position := 0; position < 10; position++ {
+	for position := 0; position <= config.MaxPosition; position++ {
 		got, err := cfg.Message(position)
 		want := expectedMessages[position]
 		if got != want {
@@ -157,3 +164,346 @@ func TestSetMessage(t *testing.T) {
 		}
 	}
 }
+
+func TestSetLabel(t *testing.T) {
+	tests := []struct {
+		position    int
+		label       string
+		want        string
+		errorWanted bool
+	}{
+		{position: 1, label: " CQ ", want: "CQ", errorWanted: false},
+		{position: 4, label: "My Call", want: "My Call", errorWanted: false},
+		{position: 4, label: "", want: "", errorWanted: false}, // Removes the label
+		{position: 2, label: "Exchange1", want: "", errorWanted: true},
+		{position: 2, label: "T\nU", want: "", errorWanted: true},
+		{position: config.MaxPosition + 1, label: "CQ", want: "", errorWanted: true},
+	}
+
+	for _, test := range tests {
+		cfg := config.New()
+		err := cfg.SetLabel(test.position, test.label)
+		m, _ := cfg.Memory(test.position)
+		if m.Label != test.want {
+			t.Errorf("got %q, want %q for label %q", m.Label, test.want, test.label)
+		}
+		if test.errorWanted && (err == nil) {
+			t.Errorf("got nil, want error after setting label %q", test.label)
+		}
+		if !test.errorWanted && (err != nil) {
+			t.Errorf("got error, want nil after setting label %q", test.label)
+		}
+	}
+}
+
+func TestAddMemory(t *testing.T) {
+	cfg := config.New()
+	cfg.SetMessage(1, "CQ TEST")
+	cfg.SetLabel(3, "TU")
+
+	// The first empty positions in keyboard order, with 0 last
+	wants := []int{2}
+	for position := 4; position <= config.MaxPosition; position++ {
+		wants = append(wants, position)
+	}
+	wants = append(wants, 0)
+	for _, want := range wants {
+		got, err := cfg.AddMemory("EXCH", "5nn")
+		if err != nil || got != want {
+			t.Fatalf("got %d and %v, want %d", got, err, want)
+		}
+	}
+	if _, err := cfg.AddMemory("EXCH", "5NN"); err == nil {
+		t.Error("got nil, want error with no empty positions")
+	}
+	m, _ := cfg.Memory(2)
+	if m != (config.Memory{Label: "EXCH", Message: "5NN"}) {
+		t.Errorf("got %+v, want EXCH 5NN", m)
+	}
+
+	cfg.ClearMemory(2)
+	if m, _ := cfg.Memory(2); !m.IsEmpty() {
+		t.Errorf("got %+v, want an empty memory after ClearMemory", m)
+	}
+	if _, err := config.New().AddMemory("EXCH", "5NN ~"); err == nil {
+		t.Error("got nil, want error for an unsupported message")
+	}
+}
+
+func TestSetFarnsworth(t *testing.T) {
+	tests := []struct {
+		input       int
+		want        int
+		errorWanted bool
+	}{
+		{input: 10, want: 10, errorWanted: false},
+		{input: 0, want: 0, errorWanted: false}, // Off
+		{input: config.MinSpeed - 1, want: 0, errorWanted: true},
+		{input: config.MaxSpeed + 1, want: 0, errorWanted: true},
+	}
+
+	for _, test := range tests {
+		cfg := config.New()
+		err := cfg.SetFarnsworth(test.input)
+		if cfg.Farnsworth() != test.want {
+			t.Errorf("got %d, want %d for Farnsworth speed", cfg.Farnsworth(), test.want)
+		}
+		if test.errorWanted && (err == nil) {
+			t.Error("got nil, want error after setting Farnsworth speed")
+		}
+		if !test.errorWanted && (err != nil) {
+			t.Error("got error, want nil after setting Farnsworth speed")
+		}
+	}
+}
+
+func TestSetWeight(t *testing.T) {
+	tests := []struct {
+		input       int
+		want        int
+		errorWanted bool
+	}{
+		{input: 60, want: 60, errorWanted: false},
+		{input: config.MinWeight, want: config.MinWeight, errorWanted: false},
+		{input: config.MaxWeight + 1, want: config.InitWeight, errorWanted: true},
+		{input: 0, want: config.InitWeight, errorWanted: true},
+	}
+
+	for _, test := range tests {
+		cfg := config.New()
+		err := cfg.SetWeight(test.input)
+		if cfg.Weight() != test.want {
+			t.Errorf("got %d, want %d for weight", cfg.Weight(), test.want)
+		}
+		if test.errorWanted && (err == nil) {
+			t.Error("got nil, want error after setting weight")
+		}
+		if !test.errorWanted && (err != nil) {
+			t.Error("got error, want nil after setting weight")
+		}
+	}
+}
+
+func TestTiming(t *testing.T) {
+	cfg := config.New()
+	cfg.SetSpeed(25)
+	cfg.SetFarnsworth(15)
+	cfg.SetWeight(55)
+
+	timing := cfg.Timing()
+	if timing.WPM != 25 || timing.Farnsworth != 15 || timing.Weight != 55 {
+		t.Errorf("got %+v, want speed 25, Farnsworth 15, and weight 55", timing)
+	}
+}
+
+func TestSetCall(t *testing.T) {
+	tests := []struct {
+		input       string
+		want        string
+		errorWanted bool
+	}{
+		{input: "k3gds", want: "K3GDS", errorWanted: false},
+		{input: " VE3/K3GDS/P ", want: "VE3/K3GDS/P", errorWanted: false},
+		{input: "", want: "", errorWanted: false},
+		{input: "K3 GDS", want: "", errorWanted: true},
+		{input: "K3$GDS", want: "", errorWanted: true},
+	}
+
+	for _, test := range tests {
+		cfg := config.New()
+		err := cfg.SetCall(test.input)
+		if cfg.Call() != test.want {
+			t.Errorf("got %q, want %q for call %q", cfg.Call(), test.want, test.input)
+		}
+		if test.errorWanted && (err == nil) {
+			t.Errorf("got nil, want error after setting call %q", test.input)
+		}
+		if !test.errorWanted && (err != nil) {
+			t.Errorf("got error, want nil after setting call %q", test.input)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(path, []byte(`{
+		"speed": 25,
+		"call": "k3gds",
+		"messages": {"1": "cq test k3gds"},
+		"labels": {"1": "CQ"},
+		"keys": {"F1": "\\send 1", "Up": ""}
+	}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := config.LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.New()
+	err = cfg.Apply(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, _ := cfg.Memory(1)
+	if cfg.Speed() != 25 || cfg.Weight() != config.InitWeight || cfg.Call() != "K3GDS" || m != (config.Memory{Label: "CQ", Message: "CQ TEST K3GDS"}) {
+		t.Errorf("got %s, want the settings from the file", cfg)
+	}
+	f1, _ := keys.Parse("F1")
+	up, _ := keys.Parse("Up")
+	if _, ok := cfg.Bindings()[up]; ok || cfg.Bindings()[f1] != "\\send 1" {
+		t.Errorf("got bindings %v, want F1 bound and Up unbound", cfg.Bindings())
+	}
+}
+
+func TestApplyInvalid(t *testing.T) {
+	tests := []config.File{
+		{Speed: 99},
+		{Messages: map[string]string{"one": "CQ"}},
+		{Messages: map[string]string{"25": "CQ"}},
+		{Messages: map[string]string{"1": "CQ ~"}},
+		{Labels: map[string]string{"1": "TOO LONG LABEL"}},
+		{Keys: map[string]string{"Enter": "\\stop"}},
+		{Wav: &config.Wav{Tone: 5000}}, // Above half the sample rate
+		{Wav: &config.Wav{Rise: "5"}},
+		{Wav: &config.Wav{Rise: "-1ms"}},
+	}
+
+	for _, test := range tests {
+		test := test
+		err := config.New().Apply(&test)
+		if err == nil {
+			t.Errorf("got nil, want error for %+v", test)
+		}
+	}
+}
+
+func TestApplyBanks(t *testing.T) {
+	f := &config.File{
+		Messages: map[string]string{"1": "CQ TEST K3GDS"},
+		Bank:     "s&p",
+		Banks: []config.FileBank{
+			{Name: "S&P", Messages: map[string]string{"1": "k3gds"}, Labels: map[string]string{"1": "My Call"}},
+			{Name: "DX", Messages: map[string]string{"1": "k3gds k3gds"}},
+		},
+	}
+	cfg := config.New()
+	err := cfg.Apply(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cfg.Banks(), []string{"Run", "S&P", "DX"}) || cfg.Bank() != "S&P" {
+		t.Errorf("got banks %v with %s active, want DX added and S&P active", cfg.Banks(), cfg.Bank())
+	}
+	for _, want := range []string{"K3GDS K3GDS", "CQ TEST K3GDS", "K3GDS"} {
+		cfg.NextBank()
+		if message, _ := cfg.Message(1); message != want {
+			t.Errorf("got %q in %s, want %q", message, cfg.Bank(), want)
+		}
+	}
+
+	f.Banks[1].Messages["1"] = "CQ ~"
+	if err := config.New().Apply(f); err == nil {
+		t.Error("got nil, want error for an unsupported message in a bank")
+	}
+}
+
+func TestSaveFile(t *testing.T) {
+	cfg := config.New()
+	cfg.SetSpeed(30)
+	cfg.SetCall("W1AW")
+	cfg.AddMemory("CQ", "CQ TEST K3GDS")
+	cfg.AddBank("DX")
+	cfg.SetBank("DX")
+	cfg.SetMessage(12, "K3GDS")
+	bindings, _ := cfg.Bindings().Load(map[string]string{"Alt+Up": "\\speed 40", "Up": ""})
+	cfg.SetBindings(bindings)
+	cfg.SetTranscript(config.Transcript{Path: "/var/log/rekl.jsonl", Format: "jsonl"})
+	cfg.SetTheme(config.Theme{Preset: "monochrome", Colors: map[string]config.Color{"error": {Foreground: "red", Attributes: "b"}}})
+	cfg.SetLayout(config.Layout{Input: 7})
+	cfg.SetWavSettings(wav.Settings{Tone: 600, SampleRate: 44100, Rise: 3 * time.Millisecond})
+
+	path := filepath.Join(t.TempDir(), "rekl", "config.json")
+	err := config.SaveFile(path, cfg.File())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := config.LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := config.New()
+	err = loaded.Apply(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.String() != cfg.String() {
+		t.Errorf("got %s, want %s after saving and loading", loaded, cfg)
+	}
+	if !reflect.DeepEqual(loaded.Bindings(), cfg.Bindings()) {
+		t.Errorf("got bindings %v, want %v after saving and loading", loaded.Bindings(), cfg.Bindings())
+	}
+	if loaded.Transcript() != cfg.Transcript() {
+		t.Errorf("got transcript %+v, want %+v after saving and loading", loaded.Transcript(), cfg.Transcript())
+	}
+	if !reflect.DeepEqual(loaded.Theme(), cfg.Theme()) || loaded.Layout() != cfg.Layout() {
+		t.Errorf("got theme %+v and layout %+v, want %+v and %+v after saving and loading", loaded.Theme(), loaded.Layout(), cfg.Theme(), cfg.Layout())
+	}
+	if loaded.WavSettings() != cfg.WavSettings() {
+		t.Errorf("got wav settings %+v, want %+v after saving and loading", loaded.WavSettings(), cfg.WavSettings())
+	}
+	loaded.SetBank("Run")
+	if m, _ := loaded.Memory(1); m != (config.Memory{Label: "CQ", Message: "CQ TEST K3GDS"}) {
+		t.Errorf("got %+v in Run, want the CQ memory", m)
+	}
+}
+
+func TestLoadFileMissing(t *testing.T) {
+	f, err := config.LoadFile(filepath.Join(t.TempDir(), "none.json"))
+	if err != nil || f.Speed != 0 {
+		t.Errorf("got %+v and %v, want an empty File", f, err)
+	}
+}
+
+func TestWavSettings(t *testing.T) {
+	cfg := config.New()
+	if cfg.WavSettings() != wav.DefaultSettings() {
+		t.Errorf("got %+v, want the default wav settings", cfg.WavSettings())
+	}
+	if f := cfg.File(); f.Wav != nil {
+		t.Errorf("got wav %+v in the file, want none for the defaults", f.Wav)
+	}
+
+	// Settings left out of the file keep their defaults
+	err := cfg.Apply(&config.File{Wav: &config.Wav{Tone: 600, Rise: "2ms"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := wav.Settings{Tone: 600, SampleRate: wav.DefaultSampleRate, Rise: 2 * time.Millisecond}
+	if cfg.WavSettings() != want {
+		t.Errorf("got %+v, want %+v", cfg.WavSettings(), want)
+	}
+
+	err = cfg.SetWavSettings(wav.Settings{Tone: 700, SampleRate: 0})
+	if err == nil || cfg.WavSettings() != want {
+		t.Errorf("got %v and %+v, want an error and the settings unchanged", err, cfg.WavSettings())
+	}
+}
+
+func TestTheme(t *testing.T) {
+	cfg := config.New()
+	colors := map[string]config.Color{"status": {Foreground: "black", Background: "white"}}
+	cfg.SetTheme(config.Theme{Preset: "high-contrast", Colors: colors})
+
+	// The Config keeps its own colours
+	colors["status"] = config.Color{Foreground: "red"}
+	cfg.Theme().Colors["error"] = config.Color{Foreground: "red"}
+	want := config.Theme{Preset: "high-contrast", Colors: map[string]config.Color{"status": {Foreground: "black", Background: "white"}}}
+	if got := cfg.Theme(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if f := config.New().File(); f.Theme != nil || f.Layout != nil {
+		t.Errorf("got theme %+v and layout %+v in the file, want none for the defaults", f.Theme, f.Layout)
+	}
+}
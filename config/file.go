@@ -0,0 +1,300 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/scottmcleodjr/rekl/keys"
+	"github.com/scottmcleodjr/rekl/wav"
+)
+
+// File is the REKL config file, in JSON.  Settings that are left out,
+// or zero, keep their defaults.
+type File struct {
+	Speed      int               `json:"speed,omitempty"`
+	Farnsworth int               `json:"farnsworth,omitempty"`
+	Weight     int               `json:"weight,omitempty"`
+	Call       string            `json:"call,omitempty"`
+	Messages   map[string]string `json:"messages,omitempty"` // By memory position, for the first bank
+	Labels     map[string]string `json:"labels,omitempty"`   // By memory position, for the first bank
+	Bank       string            `json:"bank,omitempty"`     // The active bank
+	Banks      []FileBank        `json:"banks,omitempty"`
+	Keys       map[string]string `json:"keys,omitempty"` // Command lines by key name, like "F1" or "Alt+1"
+	Transcript *Transcript       `json:"transcript,omitempty"`
+	Theme      *Theme            `json:"theme,omitempty"`
+	Layout     *Layout           `json:"layout,omitempty"`
+	Wav        *Wav              `json:"wav,omitempty"`
+}
+
+// Theme is the colours of the TUI, a built-in theme with the colours of
+// some parts changed.
+type Theme struct {
+	Preset string           `json:"preset,omitempty"` // Like "high-contrast" or "monochrome", default if empty
+	Colors map[string]Color `json:"colors,omitempty"` // By part of the TUI, like "error" or "status"
+}
+
+// copy returns a Theme that does not share its colours.
+func (t Theme) copy() Theme {
+	c := Theme{Preset: t.Preset}
+	if t.Colors != nil {
+		c.Colors = map[string]Color{}
+		for part, color := range t.Colors {
+			c.Colors[part] = color
+		}
+	}
+	return c
+}
+
+// Color is the colours of a part of the TUI, by name like "red" or
+// "#ff8800", and its attributes, like "b" for bold.
+type Color struct {
+	Foreground string `json:"fg,omitempty"`
+	Background string `json:"bg,omitempty"`
+	Attributes string `json:"attrs,omitempty"`
+}
+
+// Layout is the sizes of the panes of the TUI.  Sizes that are left
+// out, or zero, keep their defaults.
+type Layout struct {
+	Input   int `json:"input,omitempty"`   // Rows of the input form
+	Events  int `json:"events,omitempty"`  // Share of the rows for the event view
+	Receive int `json:"receive,omitempty"` // Share of the rows for the received text
+}
+
+// Wav is the audio of the WAV files saved by the wav command.  Settings
+// that are left out, or zero, keep their defaults.
+type Wav struct {
+	Tone       int    `json:"tone,omitempty"` // Tone frequency in Hz
+	SampleRate int    `json:"rate,omitempty"` // Samples per second
+	Rise       string `json:"rise,omitempty"` // Rise and fall time of each element, like "5ms"
+}
+
+// settings returns the wav.Settings for a Wav, with the defaults for
+// the settings left out.
+func (w Wav) settings() (wav.Settings, error) {
+	s := wav.DefaultSettings()
+	if w.Tone != 0 {
+		s.Tone = w.Tone
+	}
+	if w.SampleRate != 0 {
+		s.SampleRate = w.SampleRate
+	}
+	if w.Rise != "" {
+		rise, err := time.ParseDuration(w.Rise)
+		if err != nil {
+			return s, fmt.Errorf("unable to parse wav rise time %q", w.Rise)
+		}
+		s.Rise = rise
+	}
+	return s, nil
+}
+
+// Transcript is where the transcript of everything sent is kept.
+type Transcript struct {
+	Path   string `json:"path"`
+	Format string `json:"format,omitempty"` // "text" or "jsonl", text if empty
+}
+
+// FileBank is a memory bank in the config file.  A bank that is not
+// one of the default banks is added.
+type FileBank struct {
+	Name     string            `json:"name"`
+	Messages map[string]string `json:"messages,omitempty"` // By memory position
+	Labels   map[string]string `json:"labels,omitempty"`   // By memory position
+}
+
+// LoadFile reads a config file.  A missing file is an empty File.
+func LoadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &File{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f File
+	err = json.Unmarshal(data, &f)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+	return &f, nil
+}
+
+// SaveFile writes a config file, making its directory if needed.
+func SaveFile(path string, f *File) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Apply sets the settings in a File.  Apply returns an error for the
+// first invalid setting, with the settings before it applied.
+func (cfg *Config) Apply(f *File) error {
+	if f.Speed != 0 {
+		err := cfg.SetSpeed(f.Speed)
+		if err != nil {
+			return err
+		}
+	}
+	err := cfg.SetFarnsworth(f.Farnsworth)
+	if err != nil {
+		return err
+	}
+	if f.Weight != 0 {
+		err = cfg.SetWeight(f.Weight)
+		if err != nil {
+			return err
+		}
+	}
+	err = cfg.SetCall(f.Call)
+	if err != nil {
+		return err
+	}
+	for _, b := range f.Banks {
+		cfg.mu.Lock()
+		i, ok := cfg.findBank(b.Name)
+		cfg.mu.Unlock()
+		if !ok {
+			err = cfg.AddBank(b.Name)
+			if err != nil {
+				return err
+			}
+			i = len(cfg.Banks()) - 1
+		}
+		err = cfg.applyMemories(i, b.Messages, b.Labels)
+		if err != nil {
+			return fmt.Errorf("bank %s: %w", b.Name, err)
+		}
+	}
+	err = cfg.applyMemories(0, f.Messages, f.Labels)
+	if err != nil {
+		return err
+	}
+	if f.Bank != "" {
+		err = cfg.SetBank(f.Bank)
+		if err != nil {
+			return err
+		}
+	}
+	bindings, err := cfg.Bindings().Load(f.Keys)
+	if err != nil {
+		return err
+	}
+	cfg.SetBindings(bindings)
+	if f.Transcript != nil {
+		cfg.SetTranscript(*f.Transcript)
+	}
+	if f.Theme != nil {
+		cfg.SetTheme(*f.Theme)
+	}
+	if f.Layout != nil {
+		cfg.SetLayout(*f.Layout)
+	}
+	if f.Wav != nil {
+		settings, err := f.Wav.settings()
+		if err != nil {
+			return err
+		}
+		err = cfg.SetWavSettings(settings)
+		if err != nil {
+			return fmt.Errorf("wav: %w", err)
+		}
+	}
+	return nil
+}
+
+// applyMemories sets the messages and labels of a bank from a File.
+func (cfg *Config) applyMemories(bank int, messages, labels map[string]string) error {
+	for key, message := range messages {
+		position, err := parsePosition(key)
+		if err != nil {
+			return err
+		}
+		message, err = cleanMessage(message)
+		if err != nil {
+			return fmt.Errorf("message %d: %w", position, err)
+		}
+		cfg.mu.Lock()
+		cfg.banks[bank].memories[position].Message = message
+		name := cfg.banks[bank].name
+		cfg.mu.Unlock()
+		cfg.publish(Change{Kind: KindMemory, Bank: name, Position: position})
+	}
+	for key, label := range labels {
+		position, err := parsePosition(key)
+		if err != nil {
+			return err
+		}
+		label, err = cleanLabel(label)
+		if err != nil {
+			return fmt.Errorf("label %d: %w", position, err)
+		}
+		cfg.mu.Lock()
+		cfg.banks[bank].memories[position].Label = label
+		name := cfg.banks[bank].name
+		cfg.mu.Unlock()
+		cfg.publish(Change{Kind: KindMemory, Bank: name, Position: position})
+	}
+	return nil
+}
+
+func parsePosition(key string) (int, error) {
+	position, err := strconv.Atoi(key)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse memory position %q", key)
+	}
+	return position, checkPosition(position)
+}
+
+// File returns the current configuration as a File, to save it.
+func (cfg *Config) File() *File {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	f := &File{
+		Speed:      cfg.speed,
+		Farnsworth: cfg.farnsworth,
+		Weight:     cfg.weight,
+		Call:       cfg.call,
+		Bank:       cfg.banks[cfg.active].name,
+		Keys:       cfg.bindings.Spec(keys.Default()),
+	}
+	if cfg.transcript.Path != "" {
+		transcript := cfg.transcript
+		f.Transcript = &transcript
+	}
+	if cfg.theme.Preset != "" || len(cfg.theme.Colors) != 0 {
+		theme := cfg.theme.copy()
+		f.Theme = &theme
+	}
+	if cfg.layout != (Layout{}) {
+		layout := cfg.layout
+		f.Layout = &layout
+	}
+	if cfg.wav != wav.DefaultSettings() {
+		f.Wav = &Wav{Tone: cfg.wav.Tone, SampleRate: cfg.wav.SampleRate, Rise: cfg.wav.Rise.String()}
+	}
+	for _, b := range cfg.banks {
+		fb := FileBank{Name: b.name, Messages: map[string]string{}, Labels: map[string]string{}}
+		for position, m := range b.memories {
+			if m.Message != "" {
+				fb.Messages[strconv.Itoa(position)] = m.Message
+			}
+			if m.Label != "" {
+				fb.Labels[strconv.Itoa(position)] = m.Label
+			}
+		}
+		f.Banks = append(f.Banks, fb)
+	}
+	return f
+}
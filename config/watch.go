@@ -0,0 +1,50 @@
+package config
+
+// Kind is the kind of setting in a Change.
+type Kind int
+
+const (
+	KindSpeed    Kind = iota // KindSpeed is the CW speed, Farnsworth speed or weight
+	KindCall                 // KindCall is the current call
+	KindMemory               // KindMemory is the message or label of a memory
+	KindBank                 // KindBank is the active memory bank or the list of banks
+	KindBindings             // KindBindings is the key bindings
+)
+
+// Change is a change to a Config.
+type Change struct {
+	Kind     Kind
+	Bank     string // The memory bank, for KindMemory
+	Position int    // The memory position, for KindMemory
+}
+
+// Subscribe returns a channel of changes to the Config, holding up to
+// buffer changes for a slow subscriber, and a function to end the
+// subscription.  A subscriber that is not keeping up misses changes
+// rather than slowing the Config down, so subscribers should read the
+// current settings from the Config rather than count changes.
+func (cfg *Config) Subscribe(buffer int) (<-chan Change, func()) {
+	changes := make(chan Change, buffer)
+	cfg.subMu.Lock()
+	cfg.subscribers[changes] = struct{}{}
+	cfg.subMu.Unlock()
+	return changes, func() {
+		cfg.subMu.Lock()
+		defer cfg.subMu.Unlock()
+		delete(cfg.subscribers, changes)
+	}
+}
+
+// publish sends a change to the subscribers.  publish must be called
+// without the settings lock held, so publishing never holds up the
+// keyer reading the speed.
+func (cfg *Config) publish(change Change) {
+	cfg.subMu.Lock()
+	defer cfg.subMu.Unlock()
+	for subscriber := range cfg.subscribers {
+		select {
+		case subscriber <- change:
+		default:
+		}
+	}
+}
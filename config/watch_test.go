@@ -0,0 +1,109 @@
+package config_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/scottmcleodjr/rekl/config"
+)
+
+func TestSubscribe(t *testing.T) {
+	cfg := config.New()
+	changes, cancel := cfg.Subscribe(10)
+	other, cancelOther := cfg.Subscribe(10)
+	defer cancelOther()
+
+	cfg.SetSpeed(25)
+	cfg.SetSpeed(99) // Invalid, so not a change
+	cfg.SetCall("W1AW")
+	cfg.NextBank()
+	cfg.SetMessage(3, "TU")
+	cfg.SetBindings(cfg.Bindings())
+
+	want := []config.Change{
+		{Kind: config.KindSpeed},
+		{Kind: config.KindCall},
+		{Kind: config.KindBank},
+		{Kind: config.KindMemory, Bank: "S&P", Position: 3},
+		{Kind: config.KindBindings},
+	}
+	for _, subscriber := range []<-chan config.Change{changes, other} {
+		for i, w := range want {
+			select {
+			case got := <-subscriber:
+				if got != w {
+					t.Errorf("got %+v, want %+v for change %d", got, w, i)
+				}
+			default:
+				t.Fatalf("got no change, want %+v for change %d", w, i)
+			}
+		}
+	}
+
+	cancel()
+	cfg.SetSpeed(30)
+	select {
+	case got := <-changes:
+		t.Errorf("got %+v, want nothing after cancel", got)
+	default:
+	}
+	if got := <-other; got.Kind != config.KindSpeed {
+		t.Errorf("got %+v, want a speed change for the other subscriber", got)
+	}
+}
+
+func TestSubscribeSlow(t *testing.T) {
+	cfg := config.New()
+	_, cancel := cfg.Subscribe(0) // Never read
+	defer cancel()
+	changes, cancelRead := cfg.Subscribe(1)
+	defer cancelRead()
+
+	// The keyer reads the speed while the settings change, as it does
+	// while sending
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				cfg.Speed()
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-changes:
+				cfg.Speed() // Read the current setting on a change
+			}
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		for speed := config.MinSpeed; speed <= config.MaxSpeed; speed++ {
+			cfg.SetSpeed(speed)
+			cfg.SetMessage(speed%10, "CQ")
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("got settings blocked, want slow subscribers to miss changes")
+	}
+	close(stop)
+	wg.Wait()
+	if cfg.Speed() != config.MaxSpeed {
+		t.Errorf("got speed %d, want %d", cfg.Speed(), config.MaxSpeed)
+	}
+}
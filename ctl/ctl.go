@@ -0,0 +1,190 @@
+package ctl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/scottmcleodjr/rekl/bus"
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/handler"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+// Each line of a reply is an event line with one of these prefixes,
+// and an empty line ends the reply.
+const (
+	infoPrefix  = "info: "
+	errorPrefix = "error: "
+)
+
+// Source is the source of events from the control socket.
+const Source = "ctl"
+
+// DefaultPath returns the default control socket path, in the user's
+// runtime directory if there is one.
+func DefaultPath() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		return filepath.Join(os.TempDir(), fmt.Sprintf("rekl-%d.sock", os.Getuid()))
+	}
+	return filepath.Join(dir, "rekl.sock")
+}
+
+// Server handles lines from control socket connections as entering
+// them in the input field does, and replies with the events they write.
+type Server struct {
+	keyer handler.Keyer
+	ui    handler.UserInterface
+	cfg   *config.Config
+	mu    sync.Mutex // Handles one line at a time
+}
+
+// New returns a new Server.  The ui must be safe for use from any
+// goroutine, since lines are handled off the event loop.
+func New(keyer handler.Keyer, ui handler.UserInterface, cfg *config.Config) *Server {
+	return &Server{keyer: keyer, ui: ui, cfg: cfg}
+}
+
+// Listen listens on a control socket at path that only the user can
+// use.  A socket left by a REKL that is no longer running is replaced.
+func Listen(path string) (net.Listener, error) {
+	conn, err := net.Dial("unix", path)
+	if err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("control socket %s is in use", path)
+	}
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	err = os.Chmod(path, 0600)
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// Serve handles connections on listener until it is closed.
+func (s *Server) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	w := bufio.NewWriter(conn)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		s.handleLine(line, w)
+		w.WriteString("\n")
+		err := w.Flush()
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) handleLine(line string, w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if strings.TrimSpace(line) == "" {
+		writeEvent(w, true, "nothing to send")
+		return
+	}
+	handler.HandleLine(line, s.keyer, replyUI{UserInterface: s.ui, line: line, reply: w}, s.cfg)
+}
+
+// replyUI is the UI for a line from a connection.  Events are written
+// to the UI and the reply, and the line is the input text.
+type replyUI struct {
+	handler.UserInterface
+	line  string
+	reply io.Writer
+}
+
+func (ui replyUI) WriteEvent(level tui.Level, message string) {
+	ui.Publish(bus.Message(level, message))
+}
+
+func (ui replyUI) Publish(e bus.Event) {
+	if e.Source == "" {
+		e.Source = Source
+	}
+	bus.Publish(ui.UserInterface, e)
+	if e.Message != "" {
+		writeEvent(ui.reply, e.Kind == bus.KindError, e.Message)
+	}
+}
+
+func (ui replyUI) InputText() string {
+	return ui.line
+}
+
+func (ui replyUI) ClearInputText() {}
+
+// writeEvent writes each line of a message as a reply line, so blank
+// lines in the message do not end the reply.  Only errors fail the line.
+func writeEvent(w io.Writer, failed bool, message string) {
+	prefix := infoPrefix
+	if failed {
+		prefix = errorPrefix
+	}
+	for _, line := range strings.Split(strings.TrimRight(message, "\n"), "\n") {
+		fmt.Fprintf(w, "%s%s\n", prefix, line)
+	}
+}
+
+// Reply is a line of a reply.
+type Reply struct {
+	Level tui.Level
+	Text  string
+}
+
+// Do sends a line on a control connection and returns the reply.
+func Do(conn io.ReadWriter, line string) ([]Reply, error) {
+	if strings.Contains(line, "\n") {
+		return nil, errors.New("a control line can not contain a newline")
+	}
+	_, err := fmt.Fprintf(conn, "%s\n", line)
+	if err != nil {
+		return nil, err
+	}
+
+	// The server sends nothing after the reply until the next line,
+	// so the reader can not read past it
+	reader := bufio.NewReader(conn)
+	var replies []Reply
+	for {
+		text, err := reader.ReadString('\n')
+		if err != nil {
+			return replies, err
+		}
+		text = strings.TrimSuffix(text, "\n")
+		switch {
+		case text == "":
+			return replies, nil
+		case strings.HasPrefix(text, errorPrefix):
+			replies = append(replies, Reply{Level: tui.LevelError, Text: strings.TrimPrefix(text, errorPrefix)})
+		default:
+			replies = append(replies, Reply{Level: tui.LevelInfo, Text: strings.TrimPrefix(text, infoPrefix)})
+		}
+	}
+}
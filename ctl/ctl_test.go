@@ -0,0 +1,140 @@
+package ctl_test
+
+import (
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/ctl"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+type testKeyer struct {
+	mu     sync.Mutex
+	queued []string
+}
+
+func (k *testKeyer) QueueMessage(message string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.queued = append(k.queued, message)
+	return nil
+}
+
+func (k *testKeyer) DrainSendQueue() {}
+
+// testUI is a stub implementation of handler.UserInterface.
+type testUI struct {
+	mu      sync.Mutex
+	events  []string
+	call    string
+	stopped bool
+}
+
+func (ui *testUI) WriteEvent(level tui.Level, message string) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	ui.events = append(ui.events, message)
+}
+
+func (ui *testUI) ClearEvents()                        {}
+func (ui *testUI) InputText() string                   { return "" }
+func (ui *testUI) ClearInputText()                     {}
+func (ui *testUI) SetCurrentCall(c string)             { ui.call = c }
+func (ui *testUI) SetKeyLabels(string, []tui.KeyLabel) {}
+func (ui *testUI) EventLevel() tui.Level               { return tui.LevelInfo }
+func (ui *testUI) SetEventLevel(tui.Level)             {}
+func (ui *testUI) SetEventKinds([]string)              {}
+func (ui *testUI) SearchEvents(string) int             { return 0 }
+func (ui *testUI) EventText() string                   { return "" }
+func (ui *testUI) StopApp()                            { ui.stopped = true }
+
+func TestServer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rekl.sock")
+	listener, err := ctl.Listen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	keyer := &testKeyer{}
+	ui := &testUI{}
+	cfg := config.New()
+	go ctl.New(keyer, ui, cfg).Serve(listener)
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	tests := []struct {
+		line  string
+		level tui.Level
+		want  string
+	}{
+		{line: "\\speed 25", level: tui.LevelInfo, want: "The CW speed is 25 WPM."},
+		{line: "\\speed fast", level: tui.LevelError, want: "unable to parse speed argument"},
+		{line: "\\3 cq test", level: tui.LevelInfo, want: "Saved message 3: CQ TEST"},
+		{line: "cq test", level: tui.LevelInfo, want: "Sending: CQ TEST"},
+		{line: "cq ~", level: tui.LevelError, want: "message contains unsupported rune ~"},
+		{line: "\\call k3gds", level: tui.LevelInfo, want: "The current call is K3GDS."},
+		{line: "", level: tui.LevelError, want: "nothing to send"},
+		{line: "\\nope", level: tui.LevelError, want: "unknown Command"},
+		// The UI sets the level later, as the TUI does on its event loop
+		{line: "\\level error", level: tui.LevelInfo, want: "Showing events at level error and above."},
+	}
+
+	for _, test := range tests {
+		replies, err := ctl.Do(conn, test.line)
+		if err != nil {
+			t.Fatalf("got error %s for %q", err, test.line)
+		}
+		if len(replies) != 1 || replies[0].Level != test.level || replies[0].Text != test.want {
+			t.Errorf("got %+v, want %q at level %d for %q", replies, test.want, test.level, test.line)
+		}
+	}
+
+	// A multiline event keeps its blank lines in the reply
+	replies, err := ctl.Do(conn, "\\config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replies) < 5 || replies[0].Text != "" || replies[1].Text != "Speed: 25 WPM" {
+		t.Errorf("got %+v, want the config", replies)
+	}
+
+	if cfg.Speed() != 25 || cfg.Call() != "K3GDS" || ui.call != "K3GDS" {
+		t.Errorf("got speed %d and call %q, want 25 and K3GDS", cfg.Speed(), cfg.Call())
+	}
+	if len(keyer.queued) != 1 {
+		t.Errorf("got %v queued, want CQ TEST", keyer.queued)
+	}
+	if len(ui.events) != 9 {
+		t.Errorf("got %d events written to the UI, want 9", len(ui.events))
+	}
+}
+
+func TestListen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rekl.sock")
+	listener, err := ctl.Listen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ctl.Listen(path)
+	if err == nil {
+		t.Error("got nil, want error for a socket in use")
+	}
+
+	// A socket file left behind is replaced
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+	listener, err = ctl.Listen(path)
+	if err != nil {
+		t.Errorf("got error %s, want the stale socket replaced", err)
+		return
+	}
+	listener.Close()
+}
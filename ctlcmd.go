@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/ctl"
+	"github.com/scottmcleodjr/rekl/handler"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+// ctlFlags are the flags for the control socket.
+type ctlFlags struct {
+	enabled *bool
+	path    *string
+}
+
+func addCtlFlags(flags *flag.FlagSet) ctlFlags {
+	return ctlFlags{
+		enabled: flags.Bool("ctl", false, "If the REKL should accept commands on a control socket"),
+		path:    flags.String("ctl-socket", ctl.DefaultPath(), "Path for the control socket"),
+	}
+}
+
+// queuedUI is a busUI that is safe for use from any goroutine, with
+// the TUI changes queued to the event loop.
+type queuedUI struct {
+	busUI
+}
+
+func (ui queuedUI) SetCurrentCall(call string) {
+	ui.QueueUpdate(func() { ui.busUI.SetCurrentCall(call) })
+}
+
+func (ui queuedUI) SetKeyLabels(bank string, labels []tui.KeyLabel) {
+	ui.QueueUpdate(func() { ui.busUI.SetKeyLabels(bank, labels) })
+}
+
+func (ui queuedUI) ClearEvents() {
+	ui.QueueUpdate(ui.busUI.ClearEvents)
+}
+
+func (ui queuedUI) SetEventLevel(level tui.Level) {
+	ui.QueueUpdate(func() { ui.busUI.SetEventLevel(level) })
+}
+
+func (ui queuedUI) SetEventKinds(kinds []string) {
+	ui.QueueUpdate(func() { ui.busUI.SetEventKinds(kinds) })
+}
+
+// SearchEvents waits for the search on the event loop, for the number
+// of matches.
+func (ui queuedUI) SearchEvents(text string) int {
+	matches := make(chan int, 1)
+	ui.QueueUpdate(func() { matches <- ui.busUI.SearchEvents(text) })
+	return <-matches
+}
+
+// start serves the control socket in the background if it is enabled.
+// The UI must be safe for use from any goroutine, like a queuedUI.
+// start exits if the socket can not be opened, and the returned function
+// closes it.
+func (cf ctlFlags) start(keyer handler.Keyer, ui handler.UserInterface, cfg *config.Config) func() {
+	if !*cf.enabled {
+		return func() {}
+	}
+	listener, err := ctl.Listen(*cf.path)
+	if err != nil {
+		log.Fatalf("unable to open control socket: %s", err)
+	}
+	go ctl.New(keyer, ui, cfg).Serve(listener)
+	ui.WriteEvent(tui.LevelInfo, fmt.Sprintf("Accepting commands on %s", *cf.path))
+	return func() { listener.Close() }
+}
+
+// runCtl sends a line from the arguments, or each line of stdin, to a
+// running REKL and prints the replies.  The exit status is non-zero if
+// any line fails.
+func runCtl(args []string) {
+	flags := flag.NewFlagSet("rekl ctl", flag.ExitOnError)
+	path := flags.String("socket", ctl.DefaultPath(), "Path of the control socket")
+	flags.Parse(args)
+
+	conn, err := net.Dial("unix", *path)
+	if err != nil {
+		log.Fatalf("unable to connect to the REKL: %s", err)
+	}
+	defer conn.Close()
+
+	var lines []string
+	if flags.NArg() > 0 {
+		lines = []string{strings.Join(flags.Args(), " ")}
+	} else {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			log.Fatalf("unable to read stdin: %s", err)
+		}
+	}
+
+	failed := false
+	for _, line := range lines {
+		replies, err := ctl.Do(conn, line)
+		if err != nil {
+			log.Fatalf("unable to send %q: %s", line, err)
+		}
+		for _, reply := range replies {
+			if reply.Level == tui.LevelError {
+				fmt.Fprintf(os.Stderr, "Error: %s\n", reply.Text)
+				failed = true
+				continue
+			}
+			fmt.Println(reply.Text)
+		}
+	}
+	if failed {
+		conn.Close()
+		os.Exit(1)
+	}
+}
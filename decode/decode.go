@@ -0,0 +1,310 @@
+package decode
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/scottmcleodjr/rekl/morse"
+)
+
+const (
+	DefaultBandwidth = 200   // Hz, gives 5ms detection blocks
+	initWPM          = 20    // Starting guess for the adaptive speed
+	squelchRatio     = 6     // Peak to noise level ratio needed to key
+	speedAdapt       = 0.5   // How fast the dit length follows new marks
+	noiseFall        = 0.3   // How fast the noise level follows lower levels
+	noiseRise        = 0.05  // How fast the noise level follows higher levels
+	peakFollow       = 0.05  // How fast the peak level follows a key down level
+	peakDecay        = 0.999 // Peak level decay per block while key up
+	warmUpBlocks     = 20    // Blocks to measure the noise level before keying
+)
+
+// Char is a decoded character and when it started in the audio.
+// A word space is a Char with the Rune ' ', starting at the end
+// of the word before it.  A Rune of '*'
+// is a character that could not be decoded.
+type Char struct {
+	Offset time.Duration
+	Rune   rune
+}
+
+// Decoder demodulates a single CW tone from audio samples and decodes it
+// to text.  Decoder estimates the speed adaptively from the marks it hears.
+// Samples can be written in any size pieces as they arrive.
+type Decoder struct {
+	sampleRate int
+	blockSize  int
+	coeff      float64
+
+	block     []float64 // Samples of the detection block in progress
+	blocks    int       // Count of completed blocks
+	down      bool
+	pending   int // Block where a key state change was first seen, or -1
+	noise     float64
+	peak      float64
+	stateFrom int       // Block where the current key state started
+	dit       float64   // Estimated dit length in blocks
+	marks     []float64 // Mark lengths in the character in progress
+	charFrom  int       // Block where the current character started
+	spaced    bool      // If a word space was sent since the last character
+}
+
+// New returns a new Decoder for a tone frequency in Hz.  The bandwidth
+// sets the detection block length, so a wider bandwidth follows faster
+// speeds at the cost of more noise.
+func New(sampleRate, tone, bandwidth int) (*Decoder, error) {
+	if sampleRate <= 0 {
+		return nil, errors.New("sample rate must be positive")
+	}
+	if tone <= 0 || tone >= sampleRate/2 {
+		return nil, errors.New("tone must be positive and below half the sample rate")
+	}
+	if bandwidth <= 0 || bandwidth > sampleRate/4 {
+		return nil, errors.New("bandwidth must be positive and at most a quarter of the sample rate")
+	}
+
+	blockSize := sampleRate / bandwidth
+	d := &Decoder{
+		sampleRate: sampleRate,
+		blockSize:  blockSize,
+		coeff:      2 * math.Cos(2*math.Pi*float64(tone)/float64(sampleRate)),
+		block:      make([]float64, 0, blockSize),
+		pending:    -1,
+		spaced:     true,
+	}
+	d.dit = d.blocksFor(morse.Dit(initWPM))
+	return d, nil
+}
+
+// WPM returns the current estimated speed.
+func (d *Decoder) WPM() int {
+	return int(math.Round(1200 / d.blockDuration(d.dit).Seconds() / 1000))
+}
+
+// Write decodes samples and returns any characters completed by them.
+func (d *Decoder) Write(samples []int16) []Char {
+	var chars []Char
+	for _, sample := range samples {
+		d.block = append(d.block, float64(sample))
+		if len(d.block) == d.blockSize {
+			chars = append(chars, d.processBlock(d.magnitude(d.block))...)
+			d.block = d.block[:0]
+		}
+	}
+	return chars
+}
+
+// Flush returns the character in progress, if any.  Flush should
+// be called at the end of the audio.
+func (d *Decoder) Flush() []Char {
+	var chars []Char
+	if d.down {
+		d.down = false
+		chars = append(chars, d.endMark(d.blocks)...)
+	}
+	return append(chars, d.endChar()...)
+}
+
+// magnitude returns the tone amplitude in a block using the Goertzel algorithm.
+func (d *Decoder) magnitude(block []float64) float64 {
+	var s1, s2 float64
+	for _, sample := range block {
+		s0 := sample + d.coeff*s1 - s2
+		s2 = s1
+		s1 = s0
+	}
+	power := s1*s1 + s2*s2 - d.coeff*s1*s2
+	return 2 * math.Sqrt(math.Max(power, 0)) / float64(len(block))
+}
+
+func (d *Decoder) processBlock(level float64) []Char {
+	block := d.blocks
+	d.blocks++
+
+	// The noise level starts as the average of the first blocks
+	if block < warmUpBlocks {
+		d.noise += (level - d.noise) / float64(block+1)
+		d.peak = math.Max(d.peak, level)
+		return nil
+	}
+	switch {
+	case level > d.peak:
+		d.peak = level
+	case d.down:
+		d.peak += (level - d.peak) * peakFollow
+	default:
+		d.peak *= peakDecay
+	}
+
+	// The noise level only follows key up levels below the midpoint,
+	// so the start of a mark does not raise it
+	span := d.peak - d.noise
+	squelched := d.peak < squelchRatio*d.noise
+	if !d.down && (squelched || level < d.noise+0.5*span) {
+		if level < d.noise {
+			d.noise += (level - d.noise) * noiseFall
+		} else {
+			d.noise += (level - d.noise) * noiseRise
+		}
+		span = d.peak - d.noise
+	}
+
+	// Hysteresis around the midpoint between noise and peak levels,
+	// and a change must last two blocks so noise spikes are ignored
+	change := false
+	if d.down {
+		change = squelched || level < d.noise+0.4*span
+	} else {
+		change = !squelched && level > d.noise+0.6*span
+	}
+	if !change {
+		d.pending = -1
+	} else if d.pending == -1 {
+		d.pending = block
+		return nil
+	}
+
+	var chars []Char
+	switch {
+	case change && !d.down:
+		chars = d.endSpace(d.pending)
+		d.down = true
+		d.stateFrom = d.pending
+		d.pending = -1
+	case change && d.down:
+		chars = d.endMark(d.pending)
+		d.down = false
+		d.stateFrom = d.pending
+		d.pending = -1
+	case !d.down && len(d.marks) > 0 && float64(block-d.stateFrom) > 5*d.dit:
+		// A long space ends the character and word without waiting for the next mark
+		chars = append(d.endChar(), Char{Offset: d.blockDuration(float64(d.stateFrom)), Rune: ' '})
+		d.spaced = true
+	}
+	return chars
+}
+
+// endSpace handles a key up period ending at block.  A space shorter
+// than two dits is between elements and is one dit long, so it also
+// updates the speed estimate.
+func (d *Decoder) endSpace(block int) []Char {
+	space := float64(block - d.stateFrom)
+	var chars []Char
+	if len(d.marks) > 0 && space < 2*d.dit {
+		d.dit += (space - d.dit) * speedAdapt
+	} else if len(d.marks) > 0 {
+		chars = d.endChar()
+		if space >= 5*d.dit && !d.spaced {
+			chars = append(chars, Char{Offset: d.blockDuration(float64(d.stateFrom)), Rune: ' '})
+			d.spaced = true
+		}
+	}
+	if len(d.marks) == 0 {
+		d.charFrom = block
+	}
+	return chars
+}
+
+// endMark handles a key down period ending at block.
+func (d *Decoder) endMark(block int) []Char {
+	mark := float64(block - d.stateFrom)
+	d.marks = append(d.marks, mark)
+	if mark < 2*d.dit {
+		d.dit += (mark - d.dit) * speedAdapt
+	} else {
+		d.dit += (mark/3 - d.dit) * speedAdapt
+	}
+	d.spaced = false
+	return nil
+}
+
+// endChar returns the character for the marks received so far.  The marks
+// are sorted into dits and dahs with the speed estimate at the end of the
+// character, so the first character at a new speed is still decoded.
+func (d *Decoder) endChar() []Char {
+	if len(d.marks) == 0 {
+		return nil
+	}
+	var code strings.Builder
+	for _, mark := range d.marks {
+		if mark < 2*d.dit {
+			code.WriteByte('.')
+		} else {
+			code.WriteByte('-')
+		}
+	}
+	d.marks = d.marks[:0]
+
+	r, ok := morse.Rune(code.String())
+	if !ok {
+		r = '*'
+	}
+	return []Char{{Offset: d.blockDuration(float64(d.charFrom)), Rune: r}}
+}
+
+func (d *Decoder) blocksFor(duration time.Duration) float64 {
+	return duration.Seconds() * float64(d.sampleRate) / float64(d.blockSize)
+}
+
+func (d *Decoder) blockDuration(blocks float64) time.Duration {
+	return time.Duration(blocks * float64(d.blockSize) / float64(d.sampleRate) * float64(time.Second))
+}
+
+// Text returns the decoded characters as a string.
+func Text(chars []Char) string {
+	var sb strings.Builder
+	for _, char := range chars {
+		sb.WriteRune(char.Rune)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// Word is a decoded word and when it started in the audio.
+type Word struct {
+	Offset time.Duration
+	Text   string
+}
+
+// Words groups decoded characters into words.
+func Words(chars []Char) []Word {
+	var words []Word
+	var current *Word
+	for _, char := range chars {
+		if char.Rune == ' ' {
+			current = nil
+			continue
+		}
+		if current == nil {
+			words = append(words, Word{Offset: char.Offset})
+			current = &words[len(words)-1]
+		}
+		current.Text += string(char.Rune)
+	}
+	return words
+}
+
+// DetectTone returns the strongest tone between minTone and maxTone
+// in the samples, in steps of the bandwidth.
+func DetectTone(samples []int16, sampleRate, minTone, maxTone, bandwidth int) int {
+	blockSize := sampleRate / bandwidth
+	best, bestLevel := minTone, -1.0
+	for tone := minTone; tone <= maxTone; tone += bandwidth / 2 {
+		coeff := 2 * math.Cos(2*math.Pi*float64(tone)/float64(sampleRate))
+		level := 0.0
+		for start := 0; start+blockSize <= len(samples); start += blockSize {
+			var s1, s2 float64
+			for _, sample := range samples[start : start+blockSize] {
+				s0 := float64(sample) + coeff*s1 - s2
+				s2 = s1
+				s1 = s0
+			}
+			level += math.Sqrt(math.Max(s1*s1+s2*s2-coeff*s1*s2, 0))
+		}
+		if level > bestLevel {
+			best, bestLevel = tone, level
+		}
+	}
+	return best
+}
@@ -0,0 +1,147 @@
+package decode_test
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/scottmcleodjr/rekl/decode"
+	"github.com/scottmcleodjr/rekl/morse"
+	"github.com/scottmcleodjr/rekl/wav"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		message string
+		wpm     int
+		noise   float64 // Standard deviation of added noise
+	}{
+		{message: "CQ CQ DE K3GDS K", wpm: 20, noise: 0},
+		{message: "CQ TEST K3GDS", wpm: 18, noise: 4000},
+		{message: "5NN TU 73", wpm: 30, noise: 4000},
+		{message: "PARIS PARIS PARIS", wpm: 12, noise: 6000},
+		{message: "THE QUICK BROWN FOX 1234567890", wpm: 25, noise: 3000},
+	}
+
+	for _, test := range tests {
+		settings := wav.DefaultSettings()
+		samples := fixture(test.message, test.wpm, test.noise, settings)
+
+		decoder, err := decode.New(settings.SampleRate, settings.Tone, decode.DefaultBandwidth)
+		if err != nil {
+			t.Fatalf("got error %q creating decoder", err)
+		}
+		chars := decoder.Write(samples)
+		chars = append(chars, decoder.Flush()...)
+
+		got := decode.Text(chars)
+		if got != test.message {
+			t.Errorf("got %q, want %q at %d WPM with noise %.0f", got, test.message, test.wpm, test.noise)
+		}
+		if wpm := decoder.WPM(); wpm < test.wpm-2 || wpm > test.wpm+2 {
+			t.Errorf("got %d WPM, want %d +/- 2 for %q", wpm, test.wpm, test.message)
+		}
+	}
+}
+
+func TestDecodeInPieces(t *testing.T) {
+	settings := wav.DefaultSettings()
+	samples := fixture("VVV DE K3GDS", 22, 2000, settings)
+	decoder, _ := decode.New(settings.SampleRate, settings.Tone, decode.DefaultBandwidth)
+
+	// Odd sized pieces, like reads from a pipe
+	var chars []decode.Char
+	for start := 0; start < len(samples); start += 333 {
+		end := start + 333
+		if end > len(samples) {
+			end = len(samples)
+		}
+		chars = append(chars, decoder.Write(samples[start:end])...)
+	}
+	chars = append(chars, decoder.Flush()...)
+
+	if got := decode.Text(chars); got != "VVV DE K3GDS" {
+		t.Errorf("got %q, want %q when written in pieces", got, "VVV DE K3GDS")
+	}
+}
+
+func TestWords(t *testing.T) {
+	settings := wav.DefaultSettings()
+	samples := fixture("CQ DE K3GDS", 20, 0, settings)
+	decoder, _ := decode.New(settings.SampleRate, settings.Tone, decode.DefaultBandwidth)
+	chars := append(decoder.Write(samples), decoder.Flush()...)
+
+	words := decode.Words(chars)
+	if len(words) != 3 {
+		t.Fatalf("got %d words, want 3 from %q", len(words), decode.Text(chars))
+	}
+
+	// Each word starts after the leading silence, the words before, and a word space
+	offset := leadIn
+	for i, text := range []string{"CQ", "DE", "K3GDS"} {
+		if words[i].Text != text {
+			t.Errorf("got word %q, want %q", words[i].Text, text)
+		}
+		diff := words[i].Offset - offset
+		if diff < -10*time.Millisecond || diff > 10*time.Millisecond {
+			t.Errorf("got offset %s, want %s for word %q", words[i].Offset, offset, text)
+		}
+		offset += morse.Duration(text, 20) + morse.WordGap*morse.Dit(20)
+	}
+}
+
+func TestDetectTone(t *testing.T) {
+	for _, tone := range []int{500, 650, 800} {
+		settings := wav.DefaultSettings()
+		settings.Tone = tone
+		samples := fixture("TEST", 20, 3000, settings)
+		got := decode.DetectTone(samples, settings.SampleRate, 300, 1200, decode.DefaultBandwidth)
+		if got < tone-50 || got > tone+50 {
+			t.Errorf("got %d Hz, want %d +/- 50 Hz", got, tone)
+		}
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		sampleRate int
+		tone       int
+		bandwidth  int
+	}{
+		{sampleRate: 0, tone: 700, bandwidth: 200},
+		{sampleRate: 8000, tone: 4500, bandwidth: 200},
+		{sampleRate: 8000, tone: 700, bandwidth: 0},
+		{sampleRate: 8000, tone: 700, bandwidth: 4000},
+	}
+
+	for _, test := range tests {
+		_, err := decode.New(test.sampleRate, test.tone, test.bandwidth)
+		if err == nil {
+			t.Errorf("got nil, want error for %+v", test)
+		}
+	}
+}
+
+// leadIn is the silence before and after each fixture message.
+const leadIn = 500 * time.Millisecond
+
+// fixture renders a message with the REKL WAV renderer and adds noise.
+func fixture(message string, wpm int, noise float64, settings wav.Settings) []int16 {
+	padding := wav.SampleCount(leadIn, settings.SampleRate)
+	tone := wav.Render(morse.Timing{WPM: wpm, Weight: 50}.Elements(message), settings)
+	samples := make([]int16, padding+len(tone)+padding)
+	copy(samples[padding:], tone)
+
+	rng := rand.New(rand.NewSource(int64(wpm)))
+	for i := range samples {
+		value := float64(samples[i]) + rng.NormFloat64()*noise
+		if value > 32767 {
+			value = 32767
+		}
+		if value < -32768 {
+			value = -32768
+		}
+		samples[i] = int16(value)
+	}
+	return samples
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/scottmcleodjr/rekl/decode"
+	"github.com/scottmcleodjr/rekl/wav"
+)
+
+// lineGap is the pause between words that starts a new output line.
+const lineGap = 2 * time.Second
+
+// runDecode decodes CW from a WAV file, or raw PCM on stdin if there
+// is no file argument, and prints the text with timestamps.
+func runDecode(args []string) {
+	flags := flag.NewFlagSet("rekl decode", flag.ExitOnError)
+	tone := flags.Int("tone", 0, "Tone frequency in Hz, 0 to detect it in a WAV file")
+	bandwidth := flags.Int("bandwidth", decode.DefaultBandwidth, "Detection bandwidth in Hz")
+	sampleRate := flags.Int("rate", wav.DefaultSampleRate, "Sample rate of raw 16 bit mono PCM on stdin")
+	flags.Parse(args)
+
+	out := newDecodePrinter(os.Stdout)
+	if flags.NArg() == 0 || flags.Arg(0) == "-" {
+		if *tone == 0 {
+			*tone = wav.DefaultTone
+		}
+		decoder, err := decode.New(*sampleRate, *tone, *bandwidth)
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = decodeStream(bufio.NewReader(os.Stdin), decoder, out)
+		if err != nil {
+			log.Fatalf("unable to read stdin: %s", err)
+		}
+		return
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	samples, rate, err := wav.Read(bufio.NewReader(file))
+	file.Close()
+	if err != nil {
+		log.Fatalf("unable to read WAV file: %s", err)
+	}
+	if *tone == 0 {
+		*tone = decode.DetectTone(samples, rate, 300, 1200, *bandwidth)
+	}
+
+	decoder, err := decode.New(rate, *tone, *bandwidth)
+	if err != nil {
+		log.Fatal(err)
+	}
+	out.print(decoder.Write(samples))
+	out.print(decoder.Flush())
+	out.finish(decoder.WPM())
+}
+
+// decodeStream decodes raw 16 bit little endian PCM as it arrives.
+func decodeStream(r io.Reader, decoder *decode.Decoder, out *decodePrinter) error {
+	buf := make([]int16, 1024)
+	for {
+		err := binary.Read(r, binary.LittleEndian, buf)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			// binary.Read does not fill a partial buffer, so a short tail is dropped
+			out.print(decoder.Flush())
+			out.finish(decoder.WPM())
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		out.print(decoder.Write(buf))
+	}
+}
+
+// decodePrinter prints decoded words, starting a new line with a
+// timestamp after each pause of at least lineGap.
+type decodePrinter struct {
+	w        io.Writer
+	word     []rune
+	wordFrom time.Duration
+	wordEnd  time.Duration // End of the last printed word
+	started  bool
+}
+
+func newDecodePrinter(w io.Writer) *decodePrinter {
+	return &decodePrinter{w: w}
+}
+
+func (p *decodePrinter) print(chars []decode.Char) {
+	for _, char := range chars {
+		if char.Rune == ' ' {
+			p.flushWord()
+			p.wordEnd = char.Offset
+			continue
+		}
+		if len(p.word) == 0 {
+			p.wordFrom = char.Offset
+		}
+		p.word = append(p.word, char.Rune)
+	}
+}
+
+func (p *decodePrinter) flushWord() {
+	if len(p.word) == 0 {
+		return
+	}
+	switch {
+	case !p.started:
+		fmt.Fprintf(p.w, "%s %s", timestamp(p.wordFrom), string(p.word))
+	case p.wordFrom-p.wordEnd >= lineGap:
+		fmt.Fprintf(p.w, "\n%s %s", timestamp(p.wordFrom), string(p.word))
+	default:
+		fmt.Fprintf(p.w, " %s", string(p.word))
+	}
+	p.started = true
+	p.word = p.word[:0]
+}
+
+func (p *decodePrinter) finish(wpm int) {
+	p.flushWord()
+	if p.started {
+		fmt.Fprintln(p.w)
+	}
+	fmt.Fprintf(os.Stderr, "Estimated speed: %d WPM\n", wpm)
+}
+
+// timestamp formats an offset into the audio as MM:SS.mmm.
+func timestamp(offset time.Duration) string {
+	minutes := int(offset / time.Minute)
+	seconds := offset % time.Minute
+	return fmt.Sprintf("%02d:%06.3f", minutes, seconds.Seconds())
+}
@@ -0,0 +1,232 @@
+package fist
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/scottmcleodjr/rekl/morse"
+)
+
+// Kind is a kind of key down or key up period.
+type Kind int
+
+const (
+	Dit Kind = iota
+	Dah
+	ElementGap
+	CharGap
+	WordGap
+	kinds
+)
+
+const pauseUnits = 14 // Key up periods longer than this are pauses, not word gaps
+
+var kindNames = [kinds]string{"dit", "dah", "element gap", "char gap", "word gap"}
+
+var idealUnits = [kinds]float64{morse.DitUnits, morse.DahUnits, morse.ElementGap, morse.CharGap, morse.WordGap}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// SpeedProvider provides the target speed.
+type SpeedProvider interface {
+	Speed() int
+}
+
+// KindStats is the timing of one kind of period, in units of the target speed.
+type KindStats struct {
+	Count int
+	sum   float64
+	sumSq float64
+}
+
+// Mean returns the average length in units.
+func (ks KindStats) Mean() float64 {
+	if ks.Count == 0 {
+		return 0
+	}
+	return ks.sum / float64(ks.Count)
+}
+
+// StdDev returns the standard deviation of the length in units.
+func (ks KindStats) StdDev() float64 {
+	if ks.Count == 0 {
+		return 0
+	}
+	mean := ks.Mean()
+	return math.Sqrt(math.Max(ks.sumSq/float64(ks.Count)-mean*mean, 0))
+}
+
+func (ks *KindStats) add(units float64) {
+	ks.Count++
+	ks.sum += units
+	ks.sumSq += units * units
+}
+
+// Stats is the timing of each kind of period.
+type Stats [kinds]KindStats
+
+// Deviation returns how far the average length of a kind is from the
+// ideal length, as a percentage.
+func (s Stats) Deviation(kind Kind) int {
+	if s[kind].Count == 0 {
+		return 0
+	}
+	return int(math.Round(100 * (s[kind].Mean()/idealUnits[kind] - 1)))
+}
+
+func (s Stats) String() string {
+	var parts []string
+	for kind := Dit; kind < kinds; kind++ {
+		if s[kind].Count == 0 {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s %+d%% ±%.2f", kind, s.Deviation(kind), s[kind].StdDev()))
+	}
+	if len(parts) == 0 {
+		return "No timing yet."
+	}
+	return strings.Join(parts, ", ") + "."
+}
+
+// Analyzer decodes hand sent CW from key down and up times and measures
+// the timing against the target speed.  Periods are sorted by length, so
+// a mark under two units is a dit and a space under two units is between
+// elements and under five units is between characters.
+type Analyzer struct {
+	speed SpeedProvider
+	down  bool
+	from  time.Time // When the current period started
+	code  strings.Builder
+	word  bool // If a character was sent since the last word gap
+	total Stats
+	words Stats // Of the word in progress or the last word
+	ended bool  // If words is for a word that has ended
+}
+
+// New returns a new Analyzer for a target speed.
+func New(speed SpeedProvider) *Analyzer {
+	return &Analyzer{speed: speed}
+}
+
+// Key records the key going down or up at a time and returns any text
+// completed by it.
+func (a *Analyzer) Key(down bool, at time.Time) string {
+	if down == a.down {
+		return ""
+	}
+	text := ""
+	if a.from.IsZero() {
+		a.down, a.from = down, at
+		return ""
+	}
+
+	units := float64(at.Sub(a.from)) / float64(morse.Dit(a.speed.Speed()))
+	if down {
+		text = a.endSpace(units)
+	} else {
+		a.endMark(units)
+	}
+	a.down, a.from = down, at
+	return text
+}
+
+// Idle returns any text completed by the key being up until now, so
+// the last character and word are returned without waiting for the
+// next key down.
+func (a *Analyzer) Idle(now time.Time) string {
+	if a.down || a.from.IsZero() {
+		return ""
+	}
+	units := float64(now.Sub(a.from)) / float64(morse.Dit(a.speed.Speed()))
+	text := ""
+	if units >= 5 && a.code.Len() > 0 {
+		text = a.endChar()
+	}
+	if units >= pauseUnits && a.word {
+		a.word = false
+		a.ended = true
+		text += " "
+	}
+	return text
+}
+
+// Stats returns the timing since the analyzer was created or reset.
+func (a *Analyzer) Stats() Stats {
+	return a.total
+}
+
+// WordStats returns the timing of the word in progress, or the last
+// word if there is none.
+func (a *Analyzer) WordStats() Stats {
+	return a.words
+}
+
+// Reset clears the timing stats.
+func (a *Analyzer) Reset() {
+	a.total = Stats{}
+	a.words = Stats{}
+}
+
+func (a *Analyzer) endMark(units float64) {
+	if units < 2 {
+		a.code.WriteByte('.')
+		a.record(Dit, units)
+	} else {
+		a.code.WriteByte('-')
+		a.record(Dah, units)
+	}
+}
+
+func (a *Analyzer) endSpace(units float64) string {
+	switch {
+	case a.code.Len() == 0 && !a.word:
+		return "" // Start of the first word
+	case units < 2:
+		a.record(ElementGap, units)
+		return ""
+	case units < 5:
+		a.record(CharGap, units)
+		return a.endChar()
+	}
+
+	// The character may already be ended by Idle
+	text := ""
+	if a.code.Len() > 0 {
+		text = a.endChar()
+	}
+	if a.word {
+		text += " "
+	}
+	if units < pauseUnits {
+		a.record(WordGap, units)
+	}
+	a.word = false
+	a.ended = true
+	return text
+}
+
+func (a *Analyzer) endChar() string {
+	a.word = true
+	r, ok := morse.Rune(a.code.String())
+	a.code.Reset()
+	if !ok {
+		return "*"
+	}
+	return string(r)
+}
+
+func (a *Analyzer) record(kind Kind, units float64) {
+	a.total[kind].add(units)
+	if kind == WordGap {
+		return
+	}
+	if a.ended {
+		a.words = Stats{}
+		a.ended = false
+	}
+	a.words[kind].add(units)
+}
@@ -0,0 +1,92 @@
+package fist_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scottmcleodjr/rekl/fist"
+	"github.com/scottmcleodjr/rekl/morse"
+)
+
+type testSpeed int
+
+func (s testSpeed) Speed() int { return int(s) }
+
+// send keys elements into an analyzer and returns the text and the time after.
+func send(analyzer *fist.Analyzer, elements []morse.Element, at time.Time) (string, time.Time) {
+	text := ""
+	for _, element := range elements {
+		text += analyzer.Key(element.Down, at)
+		at = at.Add(element.Duration)
+	}
+	text += analyzer.Key(false, at)
+	return text, at
+}
+
+func TestAnalyzerText(t *testing.T) {
+	tests := []struct {
+		timing morse.Timing
+		want   string
+	}{
+		{timing: morse.Timing{WPM: 20}, want: "CQ TEST DE REKL"},
+		{timing: morse.Timing{WPM: 20, Weight: 65}, want: "CQ TEST DE REKL"},     // Heavy fist
+		{timing: morse.Timing{WPM: 17}, want: "CQ TEST DE REKL"},                 // A bit slow
+		{timing: morse.Timing{WPM: 25}, want: "CQ TEST DE REKL"},                 // A bit fast
+		{timing: morse.Timing{WPM: 20, Farnsworth: 18}, want: "CQ TEST DE REKL"}, // Slow spacing
+	}
+
+	for _, test := range tests {
+		analyzer := fist.New(testSpeed(20))
+		start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		got, end := send(analyzer, test.timing.Elements(test.want), start)
+		got += analyzer.Idle(end.Add(time.Second))
+		if got != test.want+" " {
+			t.Errorf("got %q, want %q for timing %+v", got, test.want+" ", test.timing)
+		}
+	}
+}
+
+func TestAnalyzerStats(t *testing.T) {
+	tests := []struct {
+		timing morse.Timing
+		kind   fist.Kind
+		want   int
+	}{
+		{timing: morse.Timing{WPM: 20}, kind: fist.Dit, want: 0},
+		{timing: morse.Timing{WPM: 20}, kind: fist.WordGap, want: 0},
+		{timing: morse.Timing{WPM: 20, Weight: 60}, kind: fist.Dit, want: 20},
+		{timing: morse.Timing{WPM: 20, Weight: 60}, kind: fist.ElementGap, want: -20},
+		{timing: morse.Timing{WPM: 25}, kind: fist.Dah, want: -20},
+	}
+
+	for _, test := range tests {
+		analyzer := fist.New(testSpeed(20))
+		start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		send(analyzer, test.timing.Elements("PARIS PARIS"), start)
+		if got := analyzer.Stats().Deviation(test.kind); got != test.want {
+			t.Errorf("got %s deviation %d%%, want %d%% for timing %+v", test.kind, got, test.want, test.timing)
+		}
+	}
+}
+
+func TestAnalyzerWordStats(t *testing.T) {
+	analyzer := fist.New(testSpeed(20))
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, at = send(analyzer, morse.Timing{WPM: 25}.Elements("EEE"), at)
+	analyzer.Idle(at.Add(time.Second))
+	_, at = send(analyzer, morse.Timing{WPM: 20}.Elements("TT"), at.Add(time.Second))
+	analyzer.Idle(at.Add(time.Second))
+
+	words := analyzer.WordStats()
+	if words[fist.Dit].Count != 0 || words[fist.Dah].Count != 2 {
+		t.Errorf("got %d dits and %d dahs, want only the 2 dahs of the last word", words[fist.Dit].Count, words[fist.Dah].Count)
+	}
+	if total := analyzer.Stats(); total[fist.Dit].Count != 3 {
+		t.Errorf("got %d dits, want 3 in total", total[fist.Dit].Count)
+	}
+
+	analyzer.Reset()
+	if got := analyzer.Stats().String(); got != "No timing yet." {
+		t.Errorf("got %q, want %q after reset", got, "No timing yet.")
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/scottmcleodjr/cwkeyer"
+	"github.com/scottmcleodjr/rekl/fox"
+	"github.com/scottmcleodjr/rekl/handler"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+// runFox runs the fox controller, either headless or with the TUI
+// for event output and speed changes.
+func runFox(args []string) {
+	flags := flag.NewFlagSet("rekl fox", flag.ExitOnError)
+	keyFlags := addKeyFlags(flags, false)
+	number := flags.Int("number", 1, "ARDF fox number (1-5)")
+	count := flags.Int("count", fox.DefaultFoxes, "Number of foxes in the ARDF rotation")
+	slot := flags.Duration("slot", fox.DefaultSlot, "Length of each fox transmit slot")
+	startText := flags.String("start", "", "Start of the fox cycle as HH:MM[:SS] today or RFC3339 (default this minute)")
+	carrier := flags.Duration("carrier", 0, "Continuous carrier after each fox ID, 0 for ID only")
+	headless := flags.Bool("headless", false, "Run without the terminal UI, logging events to stderr")
+	flags.Parse(args)
+
+	start, err := parseFoxStart(*startText, time.Now())
+	if err != nil {
+		log.Fatalf("unable to parse fox start: %s", err)
+	}
+	schedule := fox.Schedule{Fox: *number, Foxes: *count, Slot: *slot, Start: start}
+
+	key := keyFlags.openKey()
+	cfg := keyFlags.newConfig()
+	keyer := cwkeyer.New(cfg, key)
+
+	var events fox.EventWriter = logEvents{}
+	var ui *tui.TUI
+	if !*headless {
+		ui = tui.New()
+		// The controller has the keyer to itself, so nothing typed is sent
+		ui.SetInputCapture(foxCommands().InputHandler(nil, ui, cfg))
+		events = ui
+	}
+
+	controller, err := fox.NewController(schedule, *carrier, keyer, key, cfg, fox.SystemClock{}, events)
+	if err != nil {
+		log.Fatalf("unable to start fox: %s", err)
+	}
+
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- controller.Run(stop)
+	}()
+
+	if *headless {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		select {
+		case <-signals:
+			close(stop)
+			err = <-done
+		case err = <-done:
+		}
+		if err != nil {
+			log.Fatalf("fox stopped: %s", err)
+		}
+		return
+	}
+
+	go func() {
+		err := <-done
+		if err != nil {
+			ui.WriteEvent(tui.LevelError, fmt.Sprintf("fox stopped: %s", err))
+		}
+	}()
+	err = ui.RunApp()
+	close(stop)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// foxCommands returns the commands for the fox TUI, which change the
+// speed or quit but never send.
+func foxCommands() *handler.Registry {
+	return handler.DefaultCommands().Subset("help", "quit", "speed", "faster", "slower")
+}
+
+// parseFoxStart parses a fox cycle start time.  A clock time is for
+// the current day in local time.  An empty string is the current minute.
+func parseFoxStart(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return now.Truncate(time.Minute), nil
+	}
+	start, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return start, nil
+	}
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		clock, err := time.Parse(layout, value)
+		if err == nil {
+			year, month, day := now.Date()
+			return time.Date(year, month, day, clock.Hour(), clock.Minute(), clock.Second(), 0, now.Location()), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not HH:MM, HH:MM:SS, or RFC3339", value)
+}
+
+// logEvents writes events to the standard logger for headless commands.
+type logEvents struct{}
+
+func (logEvents) WriteEvent(level tui.Level, message string) {
+	if level == tui.LevelError {
+		log.Printf("Error: %s", message)
+		return
+	}
+	log.Print(message)
+}
@@ -0,0 +1,177 @@
+package fox
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/scottmcleodjr/cwkeyer"
+	"github.com/scottmcleodjr/rekl/morse"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+// Clock is the source of time for a Controller.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is a Clock using the system time.
+type SystemClock struct{}
+
+// Now returns the current system time.
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// After waits for the duration to elapse and then sends the current time.
+func (SystemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Keyer is the cwkeyer.Keyer methods used by a Controller.
+type Keyer interface {
+	QueueMessage(message string) error
+	ProcessSendQueue(exitOnEmpty bool) error
+}
+
+// EventWriter is where a Controller reports what it is doing.
+type EventWriter interface {
+	WriteEvent(level tui.Level, message string)
+}
+
+// Controller keys a fox transmitter in its slots of a Schedule.
+// In each slot the Controller repeats the fox identifier, followed
+// by a continuous carrier if the carrier length is not zero.
+type Controller struct {
+	schedule Schedule
+	carrier  time.Duration
+	keyer    Keyer
+	key      cwkeyer.Key
+	speed    cwkeyer.SpeedProvider
+	clock    Clock
+	events   EventWriter
+}
+
+// NewController returns a new Controller.  The Controller uses the keyer
+// to send identifiers and the key directly for the carrier, so the keyer
+// send queue must not be processed anywhere else while it runs.
+func NewController(schedule Schedule, carrier time.Duration, keyer Keyer, key cwkeyer.Key,
+	speed cwkeyer.SpeedProvider, clock Clock, events EventWriter) (*Controller, error) {
+	err := schedule.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if carrier < 0 {
+		return nil, errors.New("carrier length must not be negative")
+	}
+	return &Controller{
+		schedule: schedule,
+		carrier:  carrier,
+		keyer:    keyer,
+		key:      key,
+		speed:    speed,
+		clock:    clock,
+		events:   events,
+	}, nil
+}
+
+// Run transmits in every slot of the schedule until stop is closed.
+// Run only returns an error if keying fails.
+func (c *Controller) Run(stop <-chan struct{}) error {
+	id, _ := Identifier(c.schedule.Fox) // Error is not reachable after Validate
+	for {
+		start, end, ok := c.waitForSlot(stop)
+		if !ok {
+			return nil
+		}
+		c.events.WriteEvent(tui.LevelInfo, fmt.Sprintf("Fox %d transmitting %s from %s to %s.",
+			c.schedule.Fox, id, start.UTC().Format("15:04:05"), end.UTC().Format("15:04:05")))
+
+		err := c.transmit(id, end, stop)
+		if err != nil {
+			return err
+		}
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+	}
+}
+
+// waitForSlot blocks until the next slot starts and returns its start and
+// end times.  waitForSlot returns false if stop was closed while waiting.
+func (c *Controller) waitForSlot(stop <-chan struct{}) (time.Time, time.Time, bool) {
+	now := c.clock.Now()
+	start, end := c.schedule.NextSlot(now)
+	wait := start.Sub(now)
+	if wait <= 0 {
+		return start, end, true
+	}
+
+	c.events.WriteEvent(tui.LevelInfo, fmt.Sprintf("Fox %d waiting %s for slot at %s.",
+		c.schedule.Fox, wait.Round(time.Second), start.UTC().Format("15:04:05")))
+	select {
+	case <-c.clock.After(wait):
+		return start, end, true
+	case <-stop:
+		return start, end, false
+	}
+}
+
+// transmit repeats the ID and carrier pattern until the slot ends.  An ID is
+// only started if it will finish before the end of the slot, otherwise
+// transmit waits out the rest of the slot.
+func (c *Controller) transmit(id string, end time.Time, stop <-chan struct{}) error {
+	for {
+		remaining := end.Sub(c.clock.Now())
+		if morse.Duration(id, c.speed.Speed()) > remaining {
+			select {
+			case <-c.clock.After(remaining):
+			case <-stop:
+			}
+			return nil
+		}
+
+		err := c.keyer.QueueMessage(id + " ")
+		if err != nil {
+			return err
+		}
+		err = c.keyer.ProcessSendQueue(true)
+		if err != nil {
+			return err
+		}
+
+		remaining = end.Sub(c.clock.Now())
+		carrier := c.carrier
+		if carrier > remaining {
+			carrier = remaining
+		}
+		if carrier > 0 {
+			err = c.sendCarrier(carrier, stop)
+			if err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+	}
+}
+
+// sendCarrier holds the key down for a duration or until stop is closed.
+func (c *Controller) sendCarrier(d time.Duration, stop <-chan struct{}) error {
+	err := c.key.Down()
+	if err != nil {
+		return err
+	}
+	select {
+	case <-c.clock.After(d):
+	case <-stop:
+	}
+	return c.key.Up()
+}
@@ -0,0 +1,253 @@
+package fox_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scottmcleodjr/cwkeyer"
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/fox"
+	"github.com/scottmcleodjr/rekl/morse"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+func TestControllerWaitsForSlot(t *testing.T) {
+	clock := newFakeClock()
+	schedule := fox.Schedule{Fox: 2, Foxes: 5, Slot: time.Minute, Start: clock.Now().Add(time.Hour)}
+	cfg := config.New()
+	keyer := cwkeyer.New(cfg, testKey{})
+	events := &testEvents{}
+
+	controller, err := fox.NewController(schedule, 0, keyer, testKey{}, cfg, clock, events)
+	if err != nil {
+		t.Fatalf("got error %q creating controller", err)
+	}
+
+	// Stop is closed, so Run returns without waiting once it reports the wait
+	stop := make(chan struct{})
+	close(stop)
+	clock.block = true
+	err = controller.Run(stop)
+	if err != nil {
+		t.Errorf("got error %q, want nil from Run", err)
+	}
+	if len(events.messages) != 1 || !strings.Contains(events.messages[0], "waiting 1h1m0s") {
+		t.Errorf("got events %q, want one event waiting 1h1m0s", events.messages)
+	}
+	if !keyer.SendQueueIsEmpty() {
+		t.Error("got items in keyer send queue, want empty queue before slot")
+	}
+}
+
+func TestControllerSlots(t *testing.T) {
+	clock := newFakeClock()
+	schedule := fox.Schedule{Fox: 2, Foxes: 3, Slot: time.Minute, Start: clock.Now()}
+	carrier := 10 * time.Second
+	cfg := config.New()
+	keyer := &testKeyer{clock: clock, wpm: cfg.Speed()}
+	key := &testCarrierKey{clock: clock}
+	events := &testEvents{}
+
+	controller, err := fox.NewController(schedule, carrier, keyer, key, cfg, clock, events)
+	if err != nil {
+		t.Fatalf("got error %q creating controller", err)
+	}
+
+	// Run until the clock would pass the end of the third slot
+	stop := clock.stopAt(clock.Now().Add(8 * time.Minute))
+	err = controller.Run(stop)
+	if err != nil {
+		t.Errorf("got error %q, want nil from Run", err)
+	}
+
+	var slots []string
+	for _, message := range events.messages {
+		if strings.Contains(message, "transmitting") {
+			slots = append(slots, message)
+		}
+	}
+	want := []string{
+		"Fox 2 transmitting MOI from 14:01:00 to 14:02:00.",
+		"Fox 2 transmitting MOI from 14:04:00 to 14:05:00.",
+		"Fox 2 transmitting MOI from 14:07:00 to 14:08:00.",
+	}
+	if strings.Join(slots, "|") != strings.Join(want, "|") {
+		t.Errorf("got slots %q, want %q", slots, want)
+	}
+
+	idLength := morse.Duration("MOI", cfg.Speed())
+	if len(keyer.sent) == 0 || len(keyer.sent) != len(key.carriers) {
+		t.Fatalf("got %d IDs and %d carriers, want a carrier after each ID", len(keyer.sent), len(key.carriers))
+	}
+	perSlot := map[time.Time]int{}
+	for i, id := range keyer.sent {
+		if id.message != "MOI " {
+			t.Errorf("got ID %q, want %q", id.message, "MOI ")
+		}
+		slotStart, slotEnd := schedule.NextSlot(id.at)
+		if slotStart.After(id.at) {
+			t.Errorf("got ID at %s, want it inside a slot", id.at.Format("15:04:05.000"))
+			continue
+		}
+		perSlot[slotStart]++
+
+		// Each carrier follows its ID and is cut short only by the slot end
+		c := key.carriers[i]
+		if !c.down.Equal(id.at.Add(idLength)) {
+			t.Errorf("got carrier at %s, want it right after the ID at %s",
+				c.down.Format("15:04:05.000"), id.at.Format("15:04:05.000"))
+		}
+		length := c.up.Sub(c.down)
+		if c.up.After(slotEnd) {
+			t.Errorf("got carrier until %s, want it to stop by the slot end %s",
+				c.up.Format("15:04:05.000"), slotEnd.Format("15:04:05.000"))
+		}
+		if length != carrier && !c.up.Equal(slotEnd) {
+			t.Errorf("got carrier of %s, want %s", length, carrier)
+		}
+	}
+
+	// The same pattern repeats in every slot
+	wantPerSlot := 0
+	for at := time.Duration(0); at+idLength <= time.Minute; at += idLength + carrier {
+		wantPerSlot++
+	}
+	if len(perSlot) != 3 {
+		t.Errorf("got IDs in %d slots, want 3", len(perSlot))
+	}
+	for start, n := range perSlot {
+		if n != wantPerSlot {
+			t.Errorf("got %d IDs in the slot at %s, want %d", n, start.Format("15:04:05"), wantPerSlot)
+		}
+	}
+}
+
+func TestNewControllerErrors(t *testing.T) {
+	clock := newFakeClock()
+	cfg := config.New()
+	keyer := cwkeyer.New(cfg, testKey{})
+
+	_, err := fox.NewController(fox.Schedule{Fox: 9, Foxes: 5, Slot: time.Minute}, 0, keyer, testKey{}, cfg, clock, &testEvents{})
+	if err == nil {
+		t.Error("got nil, want error for invalid schedule")
+	}
+	_, err = fox.NewController(fox.Schedule{Fox: 1, Foxes: 5, Slot: time.Minute}, -time.Second, keyer, testKey{}, cfg, clock, &testEvents{})
+	if err == nil {
+		t.Error("got nil, want error for negative carrier")
+	}
+}
+
+// fakeClock is a fox.Clock that only moves when advanced.
+type fakeClock struct {
+	epoch time.Time
+	now   time.Time
+	block bool // If After should never fire
+	limit time.Time
+	stop  chan struct{} // Closed instead of moving the clock past limit
+}
+
+func newFakeClock() *fakeClock {
+	epoch := time.Date(2023, time.June, 10, 14, 0, 0, 0, time.UTC)
+	return &fakeClock{epoch: epoch, now: epoch}
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	if c.block {
+		return ch
+	}
+	if c.stop != nil && c.now.Add(d).After(c.limit) {
+		close(c.stop)
+		c.stop = nil
+		c.block = true
+		return ch
+	}
+	c.now = c.now.Add(d)
+	ch <- c.now
+	return ch
+}
+
+// stopAt returns a channel that is closed, and the clock stopped, when
+// the clock would move past limit.
+func (c *fakeClock) stopAt(limit time.Time) <-chan struct{} {
+	c.limit = limit
+	c.stop = make(chan struct{})
+	return c.stop
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// testEvents records the messages written by a controller.
+type testEvents struct {
+	messages []string
+}
+
+func (e *testEvents) WriteEvent(level tui.Level, message string) {
+	e.messages = append(e.messages, message)
+}
+
+// testKey is a no-op implementation of cwkeyer.Key.
+type testKey struct{}
+
+func (ts testKey) Down() error {
+	return nil
+}
+
+func (ts testKey) Up() error {
+	return nil
+}
+
+// testKeyer is a fox.Keyer that records the messages sent and advances
+// the clock by the time taken to send them.
+type testKeyer struct {
+	clock  *fakeClock
+	wpm    int
+	queued []string
+	sent   []sentMessage
+}
+
+type sentMessage struct {
+	at      time.Time
+	message string
+}
+
+func (k *testKeyer) QueueMessage(message string) error {
+	k.queued = append(k.queued, message)
+	return nil
+}
+
+func (k *testKeyer) ProcessSendQueue(exitOnEmpty bool) error {
+	for _, message := range k.queued {
+		k.sent = append(k.sent, sentMessage{at: k.clock.Now(), message: message})
+		k.clock.Advance(morse.Duration(strings.TrimSpace(message), k.wpm))
+	}
+	k.queued = nil
+	return nil
+}
+
+// testCarrierKey is a cwkeyer.Key that records when it is held down.
+type testCarrierKey struct {
+	clock    *fakeClock
+	carriers []carrierTime
+}
+
+type carrierTime struct {
+	down, up time.Time
+}
+
+func (k *testCarrierKey) Down() error {
+	k.carriers = append(k.carriers, carrierTime{down: k.clock.Now()})
+	return nil
+}
+
+func (k *testCarrierKey) Up() error {
+	k.carriers[len(k.carriers)-1].up = k.clock.Now()
+	return nil
+}
@@ -0,0 +1,74 @@
+package fox
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultFoxes = 5                // Standard ARDF rotation of five foxes
+	DefaultSlot  = 60 * time.Second // Standard ARDF transmit slot
+)
+
+var identifiers = []string{"MOE", "MOI", "MOS", "MOH", "MO5"}
+
+// Identifier returns the ARDF identifier for fox number N.
+func Identifier(fox int) (string, error) {
+	if fox < 1 || fox > len(identifiers) {
+		return "", fmt.Errorf("fox number must be between 1 and %d", len(identifiers))
+	}
+	return identifiers[fox-1], nil
+}
+
+// Schedule is the timed rotation of foxes in an ARDF course.  Foxes
+// transmit in turn, each for one Slot, starting at Start with fox 1.
+type Schedule struct {
+	Fox   int           // The fox number this transmitter is
+	Foxes int           // The number of foxes in the rotation
+	Slot  time.Duration // How long each fox transmits
+	Start time.Time     // When fox 1 first transmits
+}
+
+// Validate returns an error if the schedule can not be run.
+func (s Schedule) Validate() error {
+	if s.Foxes < 1 || s.Foxes > len(identifiers) {
+		return fmt.Errorf("fox count must be between 1 and %d", len(identifiers))
+	}
+	if s.Fox < 1 || s.Fox > s.Foxes {
+		return fmt.Errorf("fox number must be between 1 and %d", s.Foxes)
+	}
+	if s.Slot <= 0 {
+		return errors.New("slot length must be positive")
+	}
+	return nil
+}
+
+// Cycle returns the length of one full rotation through all foxes.
+func (s Schedule) Cycle() time.Duration {
+	return s.Slot * time.Duration(s.Foxes)
+}
+
+// NextSlot returns the start and end of the slot for this fox that is
+// in progress at time now, or the next one to start if none is.
+func (s Schedule) NextSlot(now time.Time) (time.Time, time.Time) {
+	first := s.Start.Add(s.Slot * time.Duration(s.Fox-1))
+	if now.Before(first) {
+		return first, first.Add(s.Slot)
+	}
+
+	cycles := now.Sub(first) / s.Cycle()
+	start := first.Add(cycles * s.Cycle())
+	end := start.Add(s.Slot)
+	if !now.Before(end) {
+		start = start.Add(s.Cycle())
+		end = end.Add(s.Cycle())
+	}
+	return start, end
+}
+
+// Active returns true if this fox should be transmitting at time now.
+func (s Schedule) Active(now time.Time) bool {
+	start, _ := s.NextSlot(now)
+	return !now.Before(start)
+}
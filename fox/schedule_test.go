@@ -0,0 +1,93 @@
+package fox_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scottmcleodjr/rekl/fox"
+)
+
+func TestIdentifier(t *testing.T) {
+	tests := []struct {
+		fox         int
+		want        string
+		errorWanted bool
+	}{
+		{fox: 1, want: "MOE", errorWanted: false},
+		{fox: 3, want: "MOS", errorWanted: false},
+		{fox: 5, want: "MO5", errorWanted: false},
+		{fox: 0, want: "", errorWanted: true},
+		{fox: 6, want: "", errorWanted: true},
+	}
+
+	for _, test := range tests {
+		got, err := fox.Identifier(test.fox)
+		if got != test.want {
+			t.Errorf("got %q, want %q for fox %d", got, test.want, test.fox)
+		}
+		if test.errorWanted && (err == nil) {
+			t.Errorf("got nil, want error for fox %d", test.fox)
+		}
+		if !test.errorWanted && (err != nil) {
+			t.Errorf("got error, want nil for fox %d", test.fox)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		schedule    fox.Schedule
+		errorWanted bool
+	}{
+		{schedule: fox.Schedule{Fox: 1, Foxes: 5, Slot: time.Minute}, errorWanted: false},
+		{schedule: fox.Schedule{Fox: 3, Foxes: 3, Slot: time.Minute}, errorWanted: false},
+		{schedule: fox.Schedule{Fox: 4, Foxes: 3, Slot: time.Minute}, errorWanted: true}, // Fox not in rotation
+		{schedule: fox.Schedule{Fox: 1, Foxes: 6, Slot: time.Minute}, errorWanted: true}, // Too many foxes
+		{schedule: fox.Schedule{Fox: 0, Foxes: 5, Slot: time.Minute}, errorWanted: true}, // Bad fox number
+		{schedule: fox.Schedule{Fox: 1, Foxes: 5, Slot: 0}, errorWanted: true},           // No slot length
+	}
+
+	for _, test := range tests {
+		err := test.schedule.Validate()
+		if test.errorWanted && (err == nil) {
+			t.Errorf("got nil, want error for schedule %+v", test.schedule)
+		}
+		if !test.errorWanted && (err != nil) {
+			t.Errorf("got error, want nil for schedule %+v", test.schedule)
+		}
+	}
+}
+
+func TestNextSlot(t *testing.T) {
+	clock := newFakeClock()
+	schedule := fox.Schedule{Fox: 3, Foxes: 5, Slot: time.Minute, Start: clock.Now().Add(10 * time.Minute)}
+
+	tests := []struct {
+		advance    time.Duration // Clock advance before the check
+		wantStart  time.Duration // Wanted slot start, relative to the fake clock epoch
+		wantActive bool
+	}{
+		{advance: 0, wantStart: 12 * time.Minute, wantActive: false},                                       // Before the course starts
+		{advance: 11 * time.Minute, wantStart: 12 * time.Minute, wantActive: false},                        // Fox 2 is transmitting
+		{advance: time.Minute, wantStart: 12 * time.Minute, wantActive: true},                              // Start of the slot
+		{advance: 59 * time.Second, wantStart: 12 * time.Minute, wantActive: true},                         // End of the slot
+		{advance: time.Second, wantStart: 17 * time.Minute, wantActive: false},                             // Fox 4 is transmitting
+		{advance: 4 * time.Minute, wantStart: 17 * time.Minute, wantActive: true},                          // Second cycle
+		{advance: 5*time.Hour + 30*time.Second, wantStart: 5*time.Hour + 17*time.Minute, wantActive: true}, // Much later
+	}
+
+	for _, test := range tests {
+		clock.Advance(test.advance)
+		start, end := schedule.NextSlot(clock.Now())
+		wantStart := clock.epoch.Add(test.wantStart)
+		if !start.Equal(wantStart) {
+			t.Errorf("got start %s, want %s at %s", start, wantStart, clock.Now())
+		}
+		if end.Sub(start) != schedule.Slot {
+			t.Errorf("got slot length %s, want %s at %s", end.Sub(start), schedule.Slot, clock.Now())
+		}
+		if schedule.Active(clock.Now()) != test.wantActive {
+			t.Errorf("got active %t, want %t at %s", !test.wantActive, test.wantActive, clock.Now())
+		}
+	}
+}
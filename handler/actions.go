@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/scottmcleodjr/rekl/bus"
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+// EventWriter is the part of UserInterface that reports what happened.
+// An EventWriter that is also a bus.Publisher gets typed events.
+type EventWriter interface {
+	WriteEvent(level tui.Level, message string)
+}
+
+// SendText validates text and queues it for sending, as entering it in
+// the input field does.  SendText returns the message queued.
+func SendText(keyer Keyer, events EventWriter, text string) (string, error) {
+	message, err := ValidateMessage(text)
+	if err != nil {
+		return "", err
+	}
+	err = keyer.QueueMessage(message)
+	if err != nil {
+		return "", err
+	}
+	bus.Publish(events, bus.Event{Kind: bus.KindSent, Level: tui.LevelInfo, Message: fmt.Sprintf("Sending: %s", message), Text: message})
+	return message, nil
+}
+
+// SendMemory queues the message at a memory position for sending.
+// SendMemory returns the message queued.
+func SendMemory(keyer Keyer, events EventWriter, cfg *config.Config, position int) (string, error) {
+	message, err := cfg.Message(position)
+	if err != nil {
+		return "", err
+	}
+	err = keyer.QueueMessage(message)
+	if err != nil {
+		return "", err
+	}
+	bus.Publish(events, bus.Event{Kind: bus.KindSent, Level: tui.LevelInfo, Message: fmt.Sprintf("Sending: %s", message), Text: message})
+	return message, nil
+}
+
+// Stop stops sending all queued messages.
+func Stop(keyer Keyer, events EventWriter) {
+	keyer.DrainSendQueue()
+	bus.Publish(events, bus.Event{Kind: bus.KindAborted, Level: tui.LevelInfo, Message: "All messages stopped."})
+}
+
+// SetSpeed sets the CW speed and reports the new speed.
+func SetSpeed(events EventWriter, cfg *config.Config, speed int) error {
+	err := cfg.SetSpeed(speed)
+	if err != nil {
+		return err
+	}
+	reportSpeed(events, cfg)
+	return nil
+}
+
+// reportSpeed reports the CW speed after it is changed.
+func reportSpeed(events EventWriter, cfg *config.Config) {
+	speed := cfg.Speed()
+	bus.Publish(events, bus.Event{Kind: bus.KindSpeedChanged, Level: tui.LevelInfo, Message: fmt.Sprintf("The CW speed is %d WPM.", speed), Speed: speed})
+}
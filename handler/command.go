@@ -5,93 +5,287 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/gdamore/tcell/v2"
-	"github.com/scottmcleodjr/cwkeyer"
+	"github.com/scottmcleodjr/rekl/bus"
 	"github.com/scottmcleodjr/rekl/config"
 	"github.com/scottmcleodjr/rekl/tui"
+	"github.com/scottmcleodjr/rekl/wav"
 )
 
-func commandHandler(capture *tcell.EventKey, keyer *cwkeyer.Keyer, ui UserInterface, cfg *config.Config) bool {
-	if !(capture.Key() == tcell.KeyEnter && strings.HasPrefix(ui.InputText(), "\\")) {
+// commands are the built-in commands for the input field.  Callers add
+// their own to a Registry from DefaultCommands.
+var commands = DefaultCommands()
+
+const helpIntro = `
+A command should be entered as input with no additional text on the line.
+A hotkey can be used at any time without submitting the input field.
+A line starting with a slash searches the event view, like "/K3GDS".
+A line starting with two slashes sends a slash, like "//QRP" for "/QRP".
+Any other inputs will be sent as CW if all characters are sendable.
+
+`
+
+// DefaultCommands returns a new Registry of the built-in commands.
+func DefaultCommands() *Registry {
+	r := NewRegistry()
+	builtins := []Command{
+		{Name: "help", Aliases: []string{"?"}, Help: "Display this help text", Run: handleHelpCommand},
+		{Name: "quit", Aliases: []string{"exit"}, Help: "Exit the program", Run: func(c Context, _ string) { c.UI.StopApp() }},
+		{Name: "clear", Help: "Clear the display", Run: handleClearCommand},
+		{Name: "config", Help: "Display the current REKL configurations", Run: handleConfigCommand},
+		{Name: "speed", Args: "[N]", Help: "Display the CW speed, or set it to N WPM", Run: handleSpeedCommand},
+		{Name: "farnsworth", Args: "[N]", Help: "Set the Farnsworth speed to N WPM for audio, 0 for off", Run: handleFarnsworthCommand},
+		{Name: "weight", Args: "[N]", Help: "Set the weight to N percent for audio", Run: handleWeightCommand},
+		{Name: "wav", Args: "N PATH", Help: "Save the message at memory position N as a WAV file", Run: handleWavCommand},
+		{Name: "call", Args: "[CALL]", Help: `Display or set the current call, "\call -" to clear it`, Run: handleCallCommand},
+		{Name: "N", Args: "...", Help: "Save a message at memory position N", Run: handleMessageSetCommand, Match: isMemoryPosition},
+		{Name: "mem", Args: "[...]", Help: `List the memories, or change them with "add LABEL ...", "rename N [LABEL]" or "rm N"`, Run: handleMemCommand},
+		{Name: "bank", Args: "[NAME]", Help: "Switch to the next memory bank, or to bank NAME", Run: handleBankCommand},
+		{Name: "save", Help: "Save the configuration to the config file", Run: handleSaveCommand},
+		{Name: "send", Args: "N", Help: "Send the message at memory position N", Run: handleSendCommand},
+		{Name: "stop", Help: "Stop sending CW immediately", Run: handleStopCommand},
+		{Name: "faster", Help: "Increment the CW speed by 1 WPM", Run: func(c Context, _ string) { incrementSpeed(c.UI, c.Config); c.UI.ClearInputText() }},
+		{Name: "slower", Help: "Decrement the CW speed by 1 WPM", Run: func(c Context, _ string) { decrementSpeed(c.UI, c.Config); c.UI.ClearInputText() }},
+		{Name: "level", Args: "[LEVEL]", Help: "Display or set the lowest level of events shown: debug, info, warn or error", Run: handleLevelCommand},
+		{Name: "filter", Args: "[KIND...]", Help: "Show only events of the kinds, like sends or errors, or every kind", Run: handleFilterCommand},
+		{Name: "save-events", Args: "PATH", Help: "Save the events shown as plain text", Run: handleSaveEventsCommand},
+		{Name: "keys", Help: "Display the keys bound to commands", Run: handleKeysCommand},
+		{Name: "press", Aliases: []string{"k"}, Args: "KEY", Help: "Run the command bound to KEY, like F1 or Esc, as pressing it does", Run: handlePressCommand},
+	}
+	for _, cmd := range builtins {
+		err := r.Register(cmd)
+		if err != nil {
+			panic(err) // The built-in commands are fixed, so this is a bug
+		}
+	}
+	return r
+}
+
+func isMemoryPosition(name string) bool {
+	if name == "" || strings.Trim(name, "0123456789") != "" {
 		return false
 	}
+	position, err := strconv.Atoi(name)
+	return err == nil && position <= config.MaxPosition
+}
+
+// HelpText returns the help for the commands in a registry and the hotkeys.
+func HelpText(r *Registry) string {
+	return helpIntro + FormatHelp(append(r.HelpLines(), hotkeyHelp()...))
+}
+
+func handleHelpCommand(c Context, _ string) {
+	c.UI.WriteEvent(tui.LevelInfo, HelpText(c.Commands))
+	c.UI.ClearInputText()
+}
+
+func handleClearCommand(c Context, _ string) {
+	c.UI.ClearEvents()
+	c.UI.ClearInputText()
+}
 
-	splitInput := strings.SplitN(ui.InputText(), " ", 2)
-	command := splitInput[0]
-	var commandArg string
-	if len(splitInput) > 1 {
-		commandArg = splitInput[1]
-	}
-
-	switch command {
-	case "\\speed":
-		handleSpeedCommand(ui, cfg, commandArg)
-	case "\\1":
-		handleMessageSetCommand(ui, cfg, 1, commandArg)
-	case "\\2":
-		handleMessageSetCommand(ui, cfg, 2, commandArg)
-	case "\\3":
-		handleMessageSetCommand(ui, cfg, 3, commandArg)
-	case "\\4":
-		handleMessageSetCommand(ui, cfg, 4, commandArg)
-	case "\\5":
-		handleMessageSetCommand(ui, cfg, 5, commandArg)
-	case "\\6":
-		handleMessageSetCommand(ui, cfg, 6, commandArg)
-	case "\\7":
-		handleMessageSetCommand(ui, cfg, 7, commandArg)
-	case "\\8":
-		handleMessageSetCommand(ui, cfg, 8, commandArg)
-	case "\\9":
-		handleMessageSetCommand(ui, cfg, 9, commandArg)
-	case "\\0":
-		handleMessageSetCommand(ui, cfg, 0, commandArg)
-	case "\\config":
-		ui.WriteEvent(tui.LevelInfo, cfg.String())
-		ui.ClearInputText()
-	case "\\help":
-		ui.WriteEvent(tui.LevelInfo, config.HelpText)
-		ui.ClearInputText()
-	case "\\clear":
-		ui.ClearEvents()
-		ui.ClearInputText()
-	case "\\quit":
-		ui.StopApp()
-	default:
-		ui.WriteEvent(tui.LevelError, "unknown Command")
-	}
-
-	return true
-}
-
-func handleSpeedCommand(ui UserInterface, cfg *config.Config, arg string) {
+func handleLevelCommand(c Context, arg string) {
+	level := c.UI.EventLevel()
+	if arg != "" {
+		var err error
+		level, err = tui.ParseLevel(arg)
+		if err != nil {
+			c.UI.WriteEvent(tui.LevelError, err.Error())
+			return
+		}
+		// The UI may only set the level later, like over the control
+		// socket, so the level set is reported rather than read back
+		c.UI.SetEventLevel(level)
+	}
+	// Written at the level shown, so it is never hidden, but not as an error
+	bus.Publish(c.UI, bus.Event{Kind: bus.KindMessage, Level: level, Message: fmt.Sprintf("Showing events at level %s and above.", level)})
+	c.UI.ClearInputText()
+}
+
+func handleSaveCommand(c Context, _ string) {
+	path := c.Config.Path()
+	if path == "" {
+		c.UI.WriteEvent(tui.LevelError, "there is no config file to save to")
+		return
+	}
+	err := config.SaveFile(path, c.Config.File())
+	if err != nil {
+		c.UI.WriteEvent(tui.LevelError, err.Error())
+		return
+	}
+	c.UI.WriteEvent(tui.LevelInfo, fmt.Sprintf("Saved the configuration to %s", path))
+	c.UI.ClearInputText()
+}
+
+func handleConfigCommand(c Context, _ string) {
+	c.UI.WriteEvent(tui.LevelInfo, c.Config.String())
+	c.UI.ClearInputText()
+}
+
+func handleSendCommand(c Context, arg string) {
+	if c.Keyer == nil {
+		c.UI.WriteEvent(tui.LevelError, "nothing can be sent here")
+		return
+	}
+	position, err := strconv.Atoi(strings.TrimSpace(arg))
+	if err != nil {
+		c.UI.WriteEvent(tui.LevelError, "unable to parse memory position argument")
+		return
+	}
+	_, err = SendMemory(c.Keyer, c.UI, c.Config, position)
+	if err != nil {
+		c.UI.WriteEvent(tui.LevelError, err.Error())
+		return
+	}
+	c.UI.ClearInputText()
+}
+
+func handleStopCommand(c Context, _ string) {
+	if c.Keyer == nil {
+		c.UI.WriteEvent(tui.LevelError, "nothing can be sent here")
+		return
+	}
+	Stop(c.Keyer, c.UI)
+	c.UI.ClearInputText()
+}
+
+func handleKeysCommand(c Context, _ string) {
+	bindings := c.Config.Bindings()
+	lines := make([]HelpLine, 0, len(bindings))
+	for _, k := range bindings.Keys() {
+		lines = append(lines, HelpLine{Usage: k.String(), Kind: "KEY", Help: bindings[k]})
+	}
+	c.UI.WriteEvent(tui.LevelInfo, "\nKeys bound to commands:\n"+FormatHelp(lines))
+	c.UI.ClearInputText()
+}
+
+func handleSpeedCommand(c Context, arg string) {
 	if arg != "" {
 		newSpeed, err := strconv.Atoi(arg)
 		if err != nil {
-			ui.WriteEvent(tui.LevelError, "unable to parse speed argument")
+			c.UI.WriteEvent(tui.LevelError, "unable to parse speed argument")
 			return
 		}
 
-		err = cfg.SetSpeed(newSpeed)
+		err = SetSpeed(c.UI, c.Config, newSpeed)
 		if err != nil {
-			ui.WriteEvent(tui.LevelError, err.Error())
+			c.UI.WriteEvent(tui.LevelError, err.Error())
+		} else {
+			c.UI.ClearInputText()
+			return
 		}
 	}
 
-	ui.WriteEvent(tui.LevelInfo, fmt.Sprintf("The CW speed is %d WPM.", cfg.Speed()))
-	ui.ClearInputText()
+	c.UI.WriteEvent(tui.LevelInfo, fmt.Sprintf("The CW speed is %d WPM.", c.Config.Speed()))
+	c.UI.ClearInputText()
 }
 
-func handleMessageSetCommand(ui UserInterface, cfg *config.Config, position int, arg string) {
-	err := cfg.SetMessage(position, arg)
+func handleMessageSetCommand(c Context, arg string) {
+	position, _ := strconv.Atoi(c.Name) // Checked by isMemoryPosition
+	err := c.Config.SetMessage(position, arg)
 	if err != nil {
-		ui.WriteEvent(tui.LevelError, err.Error())
+		c.UI.WriteEvent(tui.LevelError, err.Error())
 		return
 	}
+	ShowKeyLabels(c.UI, c.Config)
 
 	// Fetch it back from config so we get any formatting changes
 	// Ignore err because we just set this message, will be nil
-	message, _ := cfg.Message(position)
-	ui.WriteEvent(tui.LevelInfo, fmt.Sprintf("Saved message %d: %s", position, message))
-	ui.ClearInputText()
+	message, _ := c.Config.Message(position)
+	c.UI.WriteEvent(tui.LevelInfo, fmt.Sprintf("Saved message %d: %s", position, message))
+	c.UI.ClearInputText()
+}
+
+func handleFarnsworthCommand(c Context, arg string) {
+	if arg != "" {
+		newSpeed, err := strconv.Atoi(arg)
+		if err != nil {
+			c.UI.WriteEvent(tui.LevelError, "unable to parse Farnsworth speed argument")
+			return
+		}
+
+		err = c.Config.SetFarnsworth(newSpeed)
+		if err != nil {
+			c.UI.WriteEvent(tui.LevelError, err.Error())
+		}
+	}
+
+	c.UI.WriteEvent(tui.LevelInfo, fmt.Sprintf("The Farnsworth speed is %d WPM.", c.Config.Farnsworth()))
+	c.UI.ClearInputText()
+}
+
+func handleWeightCommand(c Context, arg string) {
+	if arg != "" {
+		newWeight, err := strconv.Atoi(arg)
+		if err != nil {
+			c.UI.WriteEvent(tui.LevelError, "unable to parse weight argument")
+			return
+		}
+
+		err = c.Config.SetWeight(newWeight)
+		if err != nil {
+			c.UI.WriteEvent(tui.LevelError, err.Error())
+		}
+	}
+
+	c.UI.WriteEvent(tui.LevelInfo, fmt.Sprintf("The weight is %d%%.", c.Config.Weight()))
+	c.UI.ClearInputText()
+}
+
+func handleWavCommand(c Context, arg string) {
+	splitArg := strings.SplitN(arg, " ", 2)
+	if len(splitArg) != 2 || strings.TrimSpace(splitArg[1]) == "" {
+		c.UI.WriteEvent(tui.LevelError, "wav command needs a memory position and a path")
+		return
+	}
+	position, err := strconv.Atoi(splitArg[0])
+	if err != nil {
+		c.UI.WriteEvent(tui.LevelError, "unable to parse memory position argument")
+		return
+	}
+	message, err := c.Config.Message(position)
+	if err != nil {
+		c.UI.WriteEvent(tui.LevelError, err.Error())
+		return
+	}
+	if message == "" {
+		c.UI.WriteEvent(tui.LevelError, fmt.Sprintf("message %d is empty", position))
+		return
+	}
+
+	path := strings.TrimSpace(splitArg[1])
+	err = wav.WriteFile(path, message, c.Config.Timing(), c.Config.WavSettings())
+	if err != nil {
+		c.UI.WriteEvent(tui.LevelError, err.Error())
+		return
+	}
+	c.UI.WriteEvent(tui.LevelInfo, fmt.Sprintf("Saved message %d to %s", position, path))
+	c.UI.ClearInputText()
+}
+
+func handleCallCommand(c Context, arg string) {
+	if arg == "-" {
+		SetCurrentCall(c.UI, c.Config, "")
+	} else if arg != "" {
+		SetCurrentCall(c.UI, c.Config, arg)
+	} else {
+		c.UI.WriteEvent(tui.LevelInfo, fmt.Sprintf("The current call is %s.", c.Config.Call()))
+	}
+	c.UI.ClearInputText()
+}
+
+// SetCurrentCall sets the current call in the Config and shows it in
+// the UI.  SetCurrentCall writes an error event if the call can not be
+// sent.  An empty call clears the current call.
+func SetCurrentCall(ui UserInterface, cfg *config.Config, call string) {
+	err := cfg.SetCall(call)
+	if err != nil {
+		ui.WriteEvent(tui.LevelError, err.Error())
+		return
+	}
+	ui.SetCurrentCall(cfg.Call())
+	if cfg.Call() == "" {
+		ui.WriteEvent(tui.LevelInfo, "Cleared the current call.")
+		return
+	}
+	ui.WriteEvent(tui.LevelInfo, fmt.Sprintf("The current call is %s.", cfg.Call()))
 }
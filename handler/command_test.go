@@ -2,6 +2,9 @@ package handler_test
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/scottmcleodjr/cwkeyer"
@@ -34,6 +37,113 @@ func TestSpeedCommand(t *testing.T) {
 	}
 }
 
+func TestWeightCommand(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{input: "\\weight 60", want: 60},                 // Valid weight
+		{input: "\\weight 99", want: config.InitWeight},  // Too high
+		{input: "\\weight abc", want: config.InitWeight}, // Not a number
+	}
+
+	for _, test := range tests {
+		cfg := config.New()
+		keyer := cwkeyer.New(cfg, testKey{})
+		ui := &testUI{}
+		inputHandler := handler.InputHandler(keyer, ui, cfg)
+
+		ui.inputFieldText = test.input
+		inputHandler(enterKey)
+		if cfg.Weight() != test.want {
+			t.Errorf("got %d, want %d for input %q", cfg.Weight(), test.want, test.input)
+		}
+	}
+}
+
+func TestFarnsworthCommand(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{input: "\\farnsworth 10", want: 10}, // Valid speed
+		{input: "\\farnsworth 0", want: 0},   // Off
+		{input: "\\farnsworth 99", want: 0},  // Too high
+	}
+
+	for _, test := range tests {
+		cfg := config.New()
+		keyer := cwkeyer.New(cfg, testKey{})
+		ui := &testUI{}
+		inputHandler := handler.InputHandler(keyer, ui, cfg)
+
+		ui.inputFieldText = test.input
+		inputHandler(enterKey)
+		if cfg.Farnsworth() != test.want {
+			t.Errorf("got %d, want %d for input %q", cfg.Farnsworth(), test.want, test.input)
+		}
+	}
+}
+
+func TestWavCommand(t *testing.T) {
+	cfg := config.New()
+	keyer := cwkeyer.New(cfg, testKey{})
+	ui := &testUI{}
+	inputHandler := handler.InputHandler(keyer, ui, cfg)
+	cfg.SetMessage(1, "CQ TEST")
+
+	tests := []struct {
+		input    string
+		wantFile bool
+	}{
+		{input: "\\wav 1 %s", wantFile: true},
+		{input: "\\wav 2 %s", wantFile: false},  // Empty message
+		{input: "\\wav 10 %s", wantFile: false}, // Bad position
+	}
+
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), "test.wav")
+		ui.inputFieldText = fmt.Sprintf(test.input, path)
+		inputHandler(enterKey)
+		_, err := os.Stat(path)
+		if test.wantFile && err != nil {
+			t.Errorf("got error %q, want WAV file for input %q", err, ui.inputFieldText)
+		}
+		if !test.wantFile && err == nil {
+			t.Errorf("got WAV file, want none for input %q", ui.inputFieldText)
+		}
+	}
+}
+
+func TestCallCommand(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "\\call k3gds", want: "K3GDS"},
+		{input: "\\call K3$GDS", want: "W1AW"}, // Invalid call does not change it
+		{input: "\\call -", want: ""},          // Clear
+		{input: "\\call", want: "W1AW"},        // Display only
+	}
+
+	for _, test := range tests {
+		cfg := config.New()
+		keyer := cwkeyer.New(cfg, testKey{})
+		ui := &testUI{currentCall: "W1AW"}
+		inputHandler := handler.InputHandler(keyer, ui, cfg)
+		cfg.SetCall("W1AW")
+
+		ui.inputFieldText = test.input
+		inputHandler(enterKey)
+		if cfg.Call() != test.want {
+			t.Errorf("got %q, want %q for input %q", cfg.Call(), test.want, test.input)
+		}
+		if ui.currentCall != test.want {
+			t.Errorf("got %q in UI, want %q for input %q", ui.currentCall, test.want, test.input)
+		}
+	}
+}
+
 func TestMessageSet(t *testing.T) {
 	tests := []struct {
 		input        string
@@ -50,6 +160,7 @@ func TestMessageSet(t *testing.T) {
 		{input: "\\8 TEST MESSAGE", wantPosition: 8, wantMessage: "TEST MESSAGE"},
 		{input: "\\9 TEST MESSAGE", wantPosition: 9, wantMessage: "TEST MESSAGE"},
 		{input: "\\0 TEST MESSAGE", wantPosition: 0, wantMessage: "TEST MESSAGE"},
+		{input: "\\12 TEST MESSAGE", wantPosition: 12, wantMessage: "TEST MESSAGE"},
 	}
 
 	for _, test := range tests {
@@ -90,8 +201,13 @@ func TestHelp(t *testing.T) {
 	ui.inputFieldText = "\\help"
 	inputHandler(enterKey)
 	lastEvent := ui.lastEvent()
-	if lastEvent != config.HelpText {
-		t.Errorf("got event %q, want %q for help", lastEvent, config.HelpText)
+	if lastEvent != handler.HelpText(handler.DefaultCommands()) {
+		t.Errorf("got event %q, want %q for help", lastEvent, handler.HelpText(handler.DefaultCommands()))
+	}
+	for _, want := range []string{`"\speed [N]"`, `"\N ..."`, `"\keys"`, "[Bound keys]"} {
+		if !strings.Contains(lastEvent, want) {
+			t.Errorf("got help %q, want it to include %s", lastEvent, want)
+		}
 	}
 }
 
@@ -111,6 +227,35 @@ func TestClear(t *testing.T) {
 	}
 }
 
+func TestLevelCommand(t *testing.T) {
+	tests := []struct {
+		input     string
+		want      tui.Level
+		wantEvent string
+	}{
+		{input: "\\level debug", want: tui.LevelDebug, wantEvent: "Showing events at level debug and above."},
+		{input: "\\level WARN", want: tui.LevelWarn, wantEvent: "Showing events at level warn and above."},
+		{input: "\\level loud", want: tui.LevelInfo, wantEvent: `unknown level "loud", want debug, info, warn or error`},
+		{input: "\\level", want: tui.LevelInfo, wantEvent: "Showing events at level info and above."},
+	}
+
+	for _, test := range tests {
+		cfg := config.New()
+		keyer := cwkeyer.New(cfg, testKey{})
+		ui := &testUI{eventLevel: tui.LevelInfo}
+		inputHandler := handler.InputHandler(keyer, ui, cfg)
+
+		ui.inputFieldText = test.input
+		inputHandler(enterKey)
+		if ui.eventLevel != test.want {
+			t.Errorf("got level %s, want %s for input %q", ui.eventLevel, test.want, test.input)
+		}
+		if ui.lastEvent() != test.wantEvent {
+			t.Errorf("got event %q, want %q for input %q", ui.lastEvent(), test.wantEvent, test.input)
+		}
+	}
+}
+
 func TestQuit(t *testing.T) {
 	cfg := config.New()
 	keyer := cwkeyer.New(cfg, testKey{})
@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/history"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+const maxCalls = 50 // Recently worked calls kept for completion
+
+// EditorUI is an interface of the TUI methods used by Editor.
+type EditorUI interface {
+	EventWriter
+	InputText() string
+	SetInputText(text string)
+}
+
+// Editor adds history and completion to the input field.  Ctrl+P and
+// Ctrl+N recall older and newer lines, Ctrl+R searches back through the
+// history for the input text, and Tab completes commands and recently
+// worked calls.  Up and Down stay with the CW speed.
+type Editor struct {
+	ui       EditorUI
+	history  *history.History
+	cfg      *config.Config
+	commands *Registry // The commands completed
+	calls    []string  // Recently worked calls, newest last
+	browsing int       // History line shown, or the history length for the line being entered
+	draft    string    // The line being entered, kept while browsing
+	query    string    // Text being searched for, while searching
+	found    int       // History line found by the search
+	searched bool      // If the last key was a search
+}
+
+// NewEditor returns a new Editor with a history, completing the
+// built-in commands.
+func NewEditor(ui EditorUI, hist *history.History, cfg *config.Config) *Editor {
+	return &Editor{ui: ui, history: hist, cfg: cfg, commands: commands, browsing: hist.Len()}
+}
+
+// SetCommands sets the commands completed, for a Registry with more
+// commands than the built-in ones.
+func (e *Editor) SetCommands(r *Registry) {
+	e.commands = r
+}
+
+// Handler returns a capture function handling the editing keys before
+// the next capture function.  Entered lines are added to the history.
+func (e *Editor) Handler(next func(*tcell.EventKey) *tcell.EventKey) func(*tcell.EventKey) *tcell.EventKey {
+	return func(capture *tcell.EventKey) *tcell.EventKey {
+		searched := e.searched
+		e.searched = false
+		switch capture.Key() {
+		case tcell.KeyCtrlP:
+			e.previous()
+			return nil
+		case tcell.KeyCtrlN:
+			e.next()
+			return nil
+		case tcell.KeyCtrlR:
+			e.search(searched)
+			e.searched = true
+			return nil
+		case tcell.KeyTab:
+			e.complete()
+			return nil
+		case tcell.KeyEnter:
+			err := e.history.Add(e.ui.InputText())
+			if err != nil {
+				e.ui.WriteEvent(tui.LevelError, fmt.Sprintf("unable to save history: %s", err))
+			}
+			e.browsing = e.history.Len()
+		}
+
+		capture = next(capture)
+		e.addCall(e.cfg.Call())
+		return capture
+	}
+}
+
+func (e *Editor) previous() {
+	if e.browsing >= e.history.Len() {
+		e.browsing = e.history.Len()
+		e.draft = e.ui.InputText()
+	}
+	if e.browsing > 0 {
+		e.browsing--
+		e.ui.SetInputText(e.history.Line(e.browsing))
+	}
+}
+
+func (e *Editor) next() {
+	if e.browsing >= e.history.Len() {
+		return
+	}
+	e.browsing++
+	if e.browsing == e.history.Len() {
+		e.ui.SetInputText(e.draft)
+		return
+	}
+	e.ui.SetInputText(e.history.Line(e.browsing))
+}
+
+// search shows the newest line containing the input text, or the next
+// older one if the last key was a search too.
+func (e *Editor) search(again bool) {
+	if !again {
+		e.query = e.ui.InputText()
+		e.found = e.history.Len()
+	}
+	found, ok := e.history.Search(e.query, e.found)
+	if !ok {
+		e.ui.WriteEvent(tui.LevelError, fmt.Sprintf("no earlier line contains %q", e.query))
+		return
+	}
+	e.found = found
+	e.browsing = found
+	e.ui.SetInputText(e.history.Line(found))
+}
+
+// complete completes the last word of the input text: a command at the
+// start of the line, or else a recently worked call.  With more than
+// one match, the word is completed as far as they agree and they are
+// listed.
+func (e *Editor) complete() {
+	text := e.ui.InputText()
+	start := strings.LastIndex(text, " ") + 1
+	word := text[start:]
+	if word == "" {
+		return
+	}
+
+	var matches []string
+	if start == 0 && strings.HasPrefix(word, "\\") {
+		matches = e.commands.Complete(word)
+	} else {
+		for i := len(e.calls) - 1; i >= 0; i-- {
+			if strings.HasPrefix(e.calls[i], strings.ToUpper(word)) {
+				matches = append(matches, e.calls[i])
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return
+	case 1:
+		e.ui.SetInputText(text[:start] + matches[0] + " ")
+		return
+	}
+	prefix := commonPrefix(matches)
+	if len(prefix) > len(word) {
+		e.ui.SetInputText(text[:start] + prefix)
+		return
+	}
+	e.ui.WriteEvent(tui.LevelInfo, fmt.Sprintf("Completions: %s", strings.Join(matches, " ")))
+}
+
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// addCall keeps a call for completion.
+func (e *Editor) addCall(call string) {
+	if call == "" || (len(e.calls) > 0 && e.calls[len(e.calls)-1] == call) {
+		return
+	}
+	for i, c := range e.calls {
+		if c == call {
+			e.calls = append(e.calls[:i], e.calls[i+1:]...)
+			break
+		}
+	}
+	e.calls = append(e.calls, call)
+	if len(e.calls) > maxCalls {
+		e.calls = e.calls[1:]
+	}
+}
@@ -0,0 +1,114 @@
+package handler_test
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/scottmcleodjr/cwkeyer"
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/handler"
+	"github.com/scottmcleodjr/rekl/history"
+)
+
+// newTestEditor returns an input handler with an Editor, after entering lines.
+func newTestEditor(t *testing.T, lines ...string) (func(*tcell.EventKey) *tcell.EventKey, *testUI, *config.Config) {
+	cfg := config.New()
+	keyer := cwkeyer.New(cfg, testKey{})
+	ui := &testUI{}
+	hist, err := history.Load("", history.DefaultMax)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputHandler := handler.NewEditor(ui, hist, cfg).Handler(handler.InputHandler(keyer, ui, cfg))
+	for _, line := range lines {
+		ui.inputFieldText = line
+		inputHandler(enterKey)
+	}
+	return inputHandler, ui, cfg
+}
+
+func TestEditorHistory(t *testing.T) {
+	inputHandler, ui, cfg := newTestEditor(t, "\\speed 20", "CQ TEST", "\\speed 25")
+
+	ui.inputFieldText = "TU"
+	tests := []struct {
+		key  *tcell.EventKey
+		want string
+	}{
+		{key: ctrlPKey, want: "\\speed 25"},
+		{key: ctrlPKey, want: "CQ TEST"},
+		{key: ctrlPKey, want: "\\speed 20"},
+		{key: ctrlPKey, want: "\\speed 20"}, // Oldest line
+		{key: ctrlNKey, want: "CQ TEST"},
+		{key: ctrlNKey, want: "\\speed 25"},
+		{key: ctrlNKey, want: "TU"}, // The line being entered
+		{key: ctrlNKey, want: "TU"},
+	}
+
+	for i, test := range tests {
+		inputHandler(test.key)
+		if ui.inputFieldText != test.want {
+			t.Errorf("got %q, want %q after key %d", ui.inputFieldText, test.want, i)
+		}
+	}
+	if cfg.Speed() != 25 {
+		t.Errorf("got speed %d, want 25 with history keys not changing it", cfg.Speed())
+	}
+}
+
+func TestEditorSearch(t *testing.T) {
+	inputHandler, ui, _ := newTestEditor(t, "\\speed 20", "CQ TEST", "\\speed 25", "TU")
+
+	ui.inputFieldText = "speed"
+	for _, want := range []string{"\\speed 25", "\\speed 20", "\\speed 20"} {
+		inputHandler(ctrlRKey)
+		if ui.inputFieldText != want {
+			t.Errorf("got %q, want %q", ui.inputFieldText, want)
+		}
+	}
+	if ui.lastEvent() != `no earlier line contains "speed"` {
+		t.Errorf("got %q, want no earlier line", ui.lastEvent())
+	}
+
+	// Another key starts a new search
+	ui.inputFieldText = "cq"
+	inputHandler(upKey)
+	inputHandler(ctrlRKey)
+	if ui.inputFieldText != "CQ TEST" {
+		t.Errorf("got %q, want CQ TEST", ui.inputFieldText)
+	}
+}
+
+func TestEditorComplete(t *testing.T) {
+	inputHandler, ui, _ := newTestEditor(t, "\\call k3gds", "\\call k1abc", "\\call w1aw", "\\call k3gds")
+
+	tests := []struct {
+		input     string
+		want      string
+		wantEvent string
+	}{
+		{input: "\\sp", want: "\\speed "},
+		{input: "\\f", want: "\\f", wantEvent: "Completions: \\farnsworth \\faster \\filter"},
+		{input: "\\sa", want: "\\save"},
+		{input: "\\far", want: "\\farnsworth "},
+		{input: "\\c", want: "\\c", wantEvent: "Completions: \\call \\clear \\config"},
+		{input: "\\cl", want: "\\clear "},
+		{input: "5NN w", want: "5NN W1AW "},
+		{input: "TU k", want: "TU k", wantEvent: "Completions: K3GDS K1ABC"},
+		{input: "TU k3", want: "TU K3GDS "},
+		{input: "x", want: "x"},
+		{input: "TU ", want: "TU "},
+	}
+
+	for _, test := range tests {
+		ui.inputFieldText = test.input
+		ui.events = nil
+		inputHandler(tabKey)
+		if ui.inputFieldText != test.want {
+			t.Errorf("got %q, want %q for %q", ui.inputFieldText, test.want, test.input)
+		}
+		if test.wantEvent != "" && (len(ui.events) == 0 || ui.lastEvent() != test.wantEvent) {
+			t.Errorf("got events %q, want %q for %q", ui.events, test.wantEvent, test.input)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/scottmcleodjr/rekl/bus"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+// kindAliases are other names for event kinds in the filter command.
+var kindAliases = map[string]bus.Kind{
+	"sends":  bus.KindSent,
+	"errors": bus.KindError,
+}
+
+// parseKinds returns the event kinds for names like "sent" or "errors".
+func parseKinds(names []string) ([]string, error) {
+	var kinds []string
+	for _, name := range names {
+		name = strings.ToLower(name)
+		kind, ok := kindAliases[name]
+		if !ok {
+			kind = bus.Kind(name)
+		}
+		known := false
+		for _, k := range bus.Kinds {
+			known = known || k == kind
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown event kind %q", name)
+		}
+		if kind == bus.KindKeyState || kind == bus.KindConfig {
+			return nil, fmt.Errorf("%s events are not shown in the event view", name)
+		}
+		kinds = append(kinds, string(kind))
+	}
+	return kinds, nil
+}
+
+func handleFilterCommand(c Context, arg string) {
+	kinds, err := parseKinds(strings.Fields(arg))
+	if err != nil {
+		c.UI.WriteEvent(tui.LevelError, err.Error())
+		return
+	}
+	c.UI.SetEventKinds(kinds)
+	c.UI.ClearInputText()
+}
+
+func handleSaveEventsCommand(c Context, arg string) {
+	path := strings.TrimSpace(arg)
+	if path == "" {
+		c.UI.WriteEvent(tui.LevelError, "save-events needs a path")
+		return
+	}
+	err := os.WriteFile(path, []byte(c.UI.EventText()), 0644)
+	if err != nil {
+		c.UI.WriteEvent(tui.LevelError, err.Error())
+		return
+	}
+	c.UI.WriteEvent(tui.LevelInfo, fmt.Sprintf("Saved the events to %s", path))
+	c.UI.ClearInputText()
+}
+
+// searchEvents highlights the text in the event view, or ends the
+// search if there is none.
+func searchEvents(ui UserInterface, text string) {
+	text = strings.TrimSpace(text)
+	matches := ui.SearchEvents(text)
+	if text != "" && matches == 0 {
+		ui.WriteEvent(tui.LevelError, "no events match the search")
+		return
+	}
+	ui.ClearInputText()
+}
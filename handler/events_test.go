@@ -0,0 +1,127 @@
+package handler_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/scottmcleodjr/cwkeyer"
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/handler"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+func TestFilterCommand(t *testing.T) {
+	tests := []struct {
+		input     string
+		want      []string
+		wantInput string
+	}{
+		{input: "\\filter sends", want: []string{"sent"}},
+		{input: "\\filter ERRORS aborted", want: []string{"error", "aborted"}},
+		{input: "\\filter qso speed", want: []string{"qso", "speed"}},
+		{input: "\\filter qso key", want: []string{"sent"}, wantInput: "\\filter qso key"}, // Not in the event view
+		{input: "\\filter", want: nil},                                                     // Every kind
+		{input: "\\filter loud", want: []string{"sent"}, wantInput: "\\filter loud"},
+	}
+
+	for _, test := range tests {
+		cfg := config.New()
+		keyer := cwkeyer.New(cfg, testKey{})
+		ui := &testUI{eventKinds: []string{"sent"}}
+		inputHandler := handler.InputHandler(keyer, ui, cfg)
+
+		ui.inputFieldText = test.input
+		inputHandler(enterKey)
+		if !reflect.DeepEqual(ui.eventKinds, test.want) {
+			t.Errorf("got kinds %q, want %q for input %q", ui.eventKinds, test.want, test.input)
+		}
+		if ui.inputFieldText != test.wantInput {
+			t.Errorf("got input %q, want %q for input %q", ui.inputFieldText, test.wantInput, test.input)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		input      string
+		wantSearch string
+		wantInput  string
+	}{
+		{input: "/k3gds", wantSearch: "k3gds"},
+		{input: "/ 5NN ", wantSearch: "5NN"},
+		{input: "/W1AW", wantSearch: "W1AW", wantInput: "/W1AW"}, // No matches
+		{input: "/", wantSearch: ""},                             // Ends the search
+	}
+
+	for _, test := range tests {
+		cfg := config.New()
+		keyer := cwkeyer.New(cfg, testKey{})
+		ui := &testUI{}
+		inputHandler := handler.InputHandler(keyer, ui, cfg)
+		ui.WriteEvent(tui.LevelInfo, "Sending: CQ TEST K3GDS")
+		ui.WriteEvent(tui.LevelInfo, "Sending: 5NN 001")
+
+		ui.inputFieldText = test.input
+		inputHandler(enterKey)
+		if ui.search != test.wantSearch {
+			t.Errorf("got search %q, want %q for input %q", ui.search, test.wantSearch, test.input)
+		}
+		if ui.inputFieldText != test.wantInput {
+			t.Errorf("got input %q, want %q for input %q", ui.inputFieldText, test.wantInput, test.input)
+		}
+		if !keyer.SendQueueIsEmpty() {
+			t.Errorf("got items in keyer send queue, want a search for input %q", test.input)
+		}
+	}
+}
+
+func TestSendSlash(t *testing.T) {
+	cfg := config.New()
+	keyer := cwkeyer.New(cfg, testKey{})
+	ui := &testUI{}
+	inputHandler := handler.InputHandler(keyer, ui, cfg)
+
+	ui.inputFieldText = "//QRP"
+	inputHandler(enterKey)
+	if ui.search != "" {
+		t.Errorf("got search %q, want none for a line starting with two slashes", ui.search)
+	}
+	if ui.inputFieldText != "" {
+		t.Errorf("got input %q, want it cleared after sending", ui.inputFieldText)
+	}
+	if keyer.SendQueueIsEmpty() {
+		t.Error("got empty keyer send queue, want /QRP queued")
+	}
+	if !strings.Contains(ui.EventText(), "/QRP") || strings.Contains(ui.EventText(), "//QRP") {
+		t.Errorf("got events %q, want /QRP sent", ui.EventText())
+	}
+}
+
+func TestSaveEventsCommand(t *testing.T) {
+	cfg := config.New()
+	keyer := cwkeyer.New(cfg, testKey{})
+	ui := &testUI{}
+	inputHandler := handler.InputHandler(keyer, ui, cfg)
+	ui.WriteEvent(tui.LevelInfo, "Sending: CQ TEST K3GDS")
+	want := ui.EventText()
+
+	path := filepath.Join(t.TempDir(), "events.txt")
+	ui.inputFieldText = "\\save-events " + path
+	inputHandler(enterKey)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Errorf("got %q, want %q saved", data, want)
+	}
+
+	ui.inputFieldText = "\\save-events " + filepath.Join(t.TempDir(), "none", "events.txt")
+	inputHandler(enterKey)
+	if ui.inputFieldText == "" {
+		t.Error("got input cleared, want it kept when saving fails")
+	}
+}
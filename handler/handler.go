@@ -12,37 +12,93 @@ import (
 
 // UserInterface is an interface of the TUI methods used by InputHandler.
 type UserInterface interface {
-	WriteEvent(level tui.Level, message string)
+	EventWriter
 	ClearEvents()
 	InputText() string
 	ClearInputText()
+	SetCurrentCall(call string)
+	SetKeyLabels(bank string, labels []tui.KeyLabel)
+	EventLevel() tui.Level
+	SetEventLevel(level tui.Level)
+	SetEventKinds(kinds []string)
+	SearchEvents(text string) int
+	EventText() string
 	StopApp()
 }
 
+// Keyer is an interface of the cwkeyer.Keyer methods used by InputHandler.
+type Keyer interface {
+	QueueMessage(message string) error
+	DrainSendQueue()
+}
+
 // InputHandler processes user input to the TUI.  InputHandler accepts a Keyer, TUI,
 // and Config and returns a function to use as the TUI input field capture function.
-func InputHandler(keyer *cwkeyer.Keyer, ui UserInterface, cfg *config.Config) func(*tcell.EventKey) *tcell.EventKey {
+func InputHandler(keyer Keyer, ui UserInterface, cfg *config.Config) func(*tcell.EventKey) *tcell.EventKey {
+	return commands.InputHandler(keyer, ui, cfg)
+}
+
+// InputHandler is like the package InputHandler, with the commands in
+// the Registry instead of the built-in ones.
+func (r *Registry) InputHandler(keyer Keyer, ui UserInterface, cfg *config.Config) func(*tcell.EventKey) *tcell.EventKey {
 	return func(capture *tcell.EventKey) *tcell.EventKey {
 
-		if hotkeyHandler(capture, keyer, ui, cfg) {
+		if hotkeyHandler(r, capture, keyer, ui, cfg) {
 			return nil // Don't return the capture for hotkeys
 		}
 
-		if commandHandler(capture, keyer, ui, cfg) {
-			return capture
-		}
-
 		if capture.Key() == tcell.KeyEnter {
-			message := strings.ToUpper(ui.InputText())
-			err := keyer.QueueMessage(message)
-			if err != nil {
-				ui.WriteEvent(tui.LevelError, err.Error())
-				return capture
-			}
-			ui.WriteEvent(tui.LevelInfo, fmt.Sprintf("Sending: %s", message))
-			ui.ClearInputText()
+			r.HandleLine(ui.InputText(), keyer, ui, cfg)
 		}
 
 		return capture
 	}
 }
+
+// HandleLine handles a line of input as entering it in the input field
+// does.  A line starting with a backslash is a command, a line starting
+// with a slash searches the event view, and any other line is sent as
+// CW.  A line starting with two slashes sends the line from the second
+// slash, like "//P" for "/P".  The input text is cleared if the line
+// succeeds.
+func HandleLine(line string, keyer Keyer, ui UserInterface, cfg *config.Config) {
+	commands.HandleLine(line, keyer, ui, cfg)
+}
+
+// HandleLine is like the package HandleLine, with the commands in the
+// Registry instead of the built-in ones.  With a nil Keyer, for modes
+// that do their own sending, only commands and searches are handled.
+func (r *Registry) HandleLine(line string, keyer Keyer, ui UserInterface, cfg *config.Config) {
+	if strings.HasPrefix(line, "\\") {
+		r.Run(line, Context{Keyer: keyer, UI: ui, Config: cfg})
+		return
+	}
+	if strings.HasPrefix(line, "//") {
+		line = line[1:]
+	} else if strings.HasPrefix(line, "/") {
+		searchEvents(ui, line[1:])
+		return
+	}
+	if keyer == nil {
+		ui.WriteEvent(tui.LevelError, "only commands can be entered here")
+		return
+	}
+	_, err := SendText(keyer, ui, line)
+	if err != nil {
+		ui.WriteEvent(tui.LevelError, err.Error())
+		return
+	}
+	ui.ClearInputText()
+}
+
+// ValidateMessage formats a message for sending as CW.  ValidateMessage
+// returns an error if the message contains a character that can not be sent.
+func ValidateMessage(message string) (string, error) {
+	message = strings.ToUpper(message)
+	for _, r := range message {
+		if !cwkeyer.IsKeyable(r) {
+			return "", fmt.Errorf("message contains unsupported rune %c", r)
+		}
+	}
+	return message, nil
+}
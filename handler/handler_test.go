@@ -1,11 +1,14 @@
 package handler_test
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/scottmcleodjr/cwkeyer"
+	"github.com/scottmcleodjr/rekl/bus"
 	"github.com/scottmcleodjr/rekl/config"
 	"github.com/scottmcleodjr/rekl/handler"
+	"github.com/scottmcleodjr/rekl/tui"
 )
 
 func TestCWSend(t *testing.T) {
@@ -33,3 +36,87 @@ func TestCWSend(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateMessage(t *testing.T) {
+	tests := []struct {
+		input       string
+		want        string
+		errorWanted bool
+	}{
+		{input: "cq test k3gds", want: "CQ TEST K3GDS", errorWanted: false},
+		{input: "5NN TU", want: "5NN TU", errorWanted: false},
+		{input: "5NN @ TU", want: "", errorWanted: true},
+	}
+
+	for _, test := range tests {
+		got, err := handler.ValidateMessage(test.input)
+		if got != test.want {
+			t.Errorf("got %q, want %q for input %q", got, test.want, test.input)
+		}
+		if test.errorWanted && (err == nil) {
+			t.Errorf("got nil, want error for input %q", test.input)
+		}
+		if !test.errorWanted && (err != nil) {
+			t.Errorf("got error, want nil for input %q", test.input)
+		}
+	}
+}
+
+func TestHandleLine(t *testing.T) {
+	tests := []struct {
+		line      string
+		wantCW    bool
+		wantSpeed int
+		wantEvent string
+	}{
+		{line: "CQ TEST", wantCW: true, wantSpeed: config.InitSpeed, wantEvent: "Sending: CQ TEST"},
+		{line: "5NN @ TU", wantCW: false, wantSpeed: config.InitSpeed, wantEvent: "message contains unsupported rune @"},
+		{line: "\\speed 30", wantCW: false, wantSpeed: 30, wantEvent: "The CW speed is 30 WPM."},
+		{line: "\\unknown", wantCW: false, wantSpeed: config.InitSpeed, wantEvent: "unknown Command"},
+	}
+
+	for _, test := range tests {
+		cfg := config.New()
+		keyer := cwkeyer.New(cfg, testKey{})
+		ui := &testUI{}
+
+		handler.HandleLine(test.line, keyer, ui, cfg)
+		if keyer.SendQueueIsEmpty() == test.wantCW {
+			t.Errorf("got empty queue %t, want CW %t for line %q", keyer.SendQueueIsEmpty(), test.wantCW, test.line)
+		}
+		if cfg.Speed() != test.wantSpeed {
+			t.Errorf("got %d, want %d for line %q", cfg.Speed(), test.wantSpeed, test.line)
+		}
+		if ui.lastEvent() != test.wantEvent {
+			t.Errorf("got %q, want %q for line %q", ui.lastEvent(), test.wantEvent, test.line)
+		}
+	}
+}
+
+func TestPublishedEvents(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bus.Event
+	}{
+		{input: "cq test", want: bus.Event{Kind: bus.KindSent, Level: tui.LevelInfo, Message: "Sending: CQ TEST", Text: "CQ TEST"}},
+		{input: "\\stop", want: bus.Event{Kind: bus.KindAborted, Level: tui.LevelInfo, Message: "All messages stopped."}},
+		{input: "\\speed 30", want: bus.Event{Kind: bus.KindSpeedChanged, Level: tui.LevelInfo, Message: "The CW speed is 30 WPM.", Speed: 30}},
+		{input: "\\faster", want: bus.Event{Kind: bus.KindSpeedChanged, Level: tui.LevelInfo, Message: fmt.Sprintf("The CW speed is %d WPM.", config.InitSpeed+1), Speed: config.InitSpeed + 1}},
+	}
+
+	for _, test := range tests {
+		cfg := config.New()
+		keyer := cwkeyer.New(cfg, testKey{})
+		ui := &testUI{}
+		inputHandler := handler.InputHandler(keyer, ui, cfg)
+
+		ui.inputFieldText = test.input
+		inputHandler(enterKey)
+		if len(ui.published) != 1 || ui.published[0] != test.want {
+			t.Errorf("got %+v, want %+v for input %q", ui.published, test.want, test.input)
+		}
+		if ui.lastEvent() != test.want.Message {
+			t.Errorf("got event %q, want %q for input %q", ui.lastEvent(), test.want.Message, test.input)
+		}
+	}
+}
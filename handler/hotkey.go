@@ -5,65 +5,115 @@ import (
 	"strings"
 
 	"github.com/gdamore/tcell/v2"
-	"github.com/scottmcleodjr/cwkeyer"
+	"github.com/scottmcleodjr/rekl/bus"
 	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/keys"
 	"github.com/scottmcleodjr/rekl/tui"
 )
 
-func hotkeyHandler(capture *tcell.EventKey, keyer *cwkeyer.Keyer, ui UserInterface, cfg *config.Config) bool {
-	switch capture.Key() {
-	case tcell.KeyUp:
-		incrementSpeed(ui, cfg)
-		return true
-	case tcell.KeyDown:
-		decrementSpeed(ui, cfg)
-		return true
-	case tcell.KeyESC:
-		stopCW(keyer, ui)
-		return true
+func hotkeyHandler(r *Registry, capture *tcell.EventKey, keyer Keyer, ui UserInterface, cfg *config.Config) bool {
+	line, ok := cfg.Bindings().Lookup(capture)
+	if !ok {
+		return false
 	}
+	// Keys bound to commands the Registry does not have are left alone
+	name := strings.SplitN(strings.TrimPrefix(line, "\\"), " ", 2)[0]
+	if _, ok := r.Lookup(name); !ok {
+		return false
+	}
+	// Keys that type are only hotkeys with nothing typed yet
+	if keys.FromEvent(capture).Types() && ui.InputText() != "" {
+		return false
+	}
+	r.Run(line, Context{Keyer: keyer, UI: hotkeyUI{ui}, Config: cfg})
+	return true
+}
 
-	position := strings.IndexRune(")!@#$%^&*(", capture.Rune())
-	if ui.InputText() == "" && position != -1 {
-		sendMessage(keyer, ui, cfg, position)
-		return true
+// hotkeyUI leaves the input text alone when a hotkey runs a command.
+type hotkeyUI struct {
+	UserInterface
+}
+
+func (hotkeyUI) ClearInputText() {}
+
+func (ui hotkeyUI) Publish(e bus.Event) {
+	bus.Publish(ui.UserInterface, e)
+}
+
+// CheckBindings returns an error if a key is bound to a line that is
+// not a command.
+func CheckBindings(bindings keys.Bindings) error {
+	for _, k := range bindings.Keys() {
+		line := bindings[k]
+		name := strings.SplitN(strings.TrimPrefix(line, "\\"), " ", 2)[0]
+		if _, ok := commands.Lookup(name); !ok || !strings.HasPrefix(line, "\\") {
+			return fmt.Errorf("key %s is bound to %q, which is not a command", k, line)
+		}
 	}
+	return nil
+}
 
-	return false
+// handlePressCommand runs the command bound to a key, for user
+// interfaces without hotkeys.
+func handlePressCommand(c Context, arg string) {
+	k, err := keys.Parse(strings.TrimSpace(arg))
+	if err != nil {
+		c.UI.WriteEvent(tui.LevelError, err.Error())
+		return
+	}
+	line, ok := c.Config.Bindings()[k]
+	if !ok {
+		c.UI.WriteEvent(tui.LevelError, fmt.Sprintf("key %s is not bound to a command", k))
+		return
+	}
+	name := strings.SplitN(strings.TrimPrefix(line, "\\"), " ", 2)[0]
+	if cmd, ok := c.Commands.Lookup(name); ok && cmd.Name == "press" {
+		c.UI.WriteEvent(tui.LevelError, fmt.Sprintf("key %s is bound to another key", k))
+		return
+	}
+	c.Commands.Run(line, c)
 }
 
 func incrementSpeed(ui UserInterface, cfg *config.Config) {
 	err := cfg.IncrementSpeed()
 	if err != nil {
 		ui.WriteEvent(tui.LevelError, err.Error())
+		ui.WriteEvent(tui.LevelInfo, fmt.Sprintf("The CW speed is %d WPM.", cfg.Speed()))
+		return
 	}
-	ui.WriteEvent(tui.LevelInfo, fmt.Sprintf("The CW speed is %d WPM.", cfg.Speed()))
+	reportSpeed(ui, cfg)
 }
 
 func decrementSpeed(ui UserInterface, cfg *config.Config) {
 	err := cfg.DecrementSpeed()
 	if err != nil {
 		ui.WriteEvent(tui.LevelError, err.Error())
+		ui.WriteEvent(tui.LevelInfo, fmt.Sprintf("The CW speed is %d WPM.", cfg.Speed()))
+		return
 	}
-	ui.WriteEvent(tui.LevelInfo, fmt.Sprintf("The CW speed is %d WPM.", cfg.Speed()))
+	reportSpeed(ui, cfg)
 }
 
-func stopCW(keyer *cwkeyer.Keyer, ui UserInterface) {
-	keyer.DrainSendQueue()
-	ui.WriteEvent(tui.LevelInfo, "All messages stopped.")
+// hotkeys are the hotkeys for help.
+var hotkeys = []struct {
+	key  string
+	help string
+}{
+	{key: "[Bound keys]", help: `Run a command, see "\keys" for the keys and their commands`},
+	{key: "[Ctrl+G]", help: "Set the current call to the last received callsign"},
+	{key: "[Ctrl+P]", help: "Recall the previous line from history"},
+	{key: "[Ctrl+N]", help: "Recall the next line from history"},
+	{key: "[Ctrl+R]", help: "Search back through history for the input text"},
+	{key: "[Tab]", help: "Complete a command or a recently worked call"},
+	{key: "[PgUp]", help: "Page back through the event view"},
+	{key: "[PgDn]", help: "Page forward through the event view, following new events at the end"},
 }
 
-func sendMessage(keyer *cwkeyer.Keyer, ui UserInterface, cfg *config.Config, position int) {
-	message, err := cfg.Message(position)
-	if err != nil {
-		ui.WriteEvent(tui.LevelError, err.Error())
-		return
-	}
-
-	err = keyer.QueueMessage(message)
-	if err != nil {
-		ui.WriteEvent(tui.LevelError, err.Error())
-		return
+// hotkeyHelp returns a line of help for each hotkey.
+func hotkeyHelp() []HelpLine {
+	lines := make([]HelpLine, len(hotkeys))
+	for i, hotkey := range hotkeys {
+		lines[i] = HelpLine{Usage: hotkey.key, Kind: "HOTKEY", Help: hotkey.help}
 	}
-	ui.WriteEvent(tui.LevelInfo, fmt.Sprintf("Sending: %s", message))
+	return lines
 }
@@ -1,12 +1,14 @@
 package handler_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/scottmcleodjr/cwkeyer"
 	"github.com/scottmcleodjr/rekl/config"
 	"github.com/scottmcleodjr/rekl/handler"
+	"github.com/scottmcleodjr/rekl/keys"
 )
 
 func TestSpeedIncrement(t *testing.T) {
@@ -93,3 +95,122 @@ func TestMessageSend(t *testing.T) {
 		}
 	}
 }
+
+func TestKeyBindings(t *testing.T) {
+	tests := []struct {
+		input     string
+		key       *tcell.EventKey
+		wantCW    bool
+		wantInput string
+	}{
+		{input: "", key: tcell.NewEventKey(tcell.KeyF1, 0, tcell.ModNone), wantCW: true, wantInput: ""},
+		{input: "5NN", key: tcell.NewEventKey(tcell.KeyF1, 0, tcell.ModNone), wantCW: true, wantInput: "5NN"},
+		{input: "5NN", key: tcell.NewEventKey(tcell.KeyRune, '1', tcell.ModAlt), wantCW: true, wantInput: "5NN"},
+		{input: "", key: message1Key, wantCW: false, wantInput: ""}, // Unbound
+		{input: "5NN", key: tcell.NewEventKey(tcell.KeyRune, '@', tcell.ModNone), wantCW: false, wantInput: "5NN"},
+		{input: "", key: tcell.NewEventKey(tcell.KeyRune, '@', tcell.ModNone), wantCW: true, wantInput: ""},
+	}
+
+	for _, test := range tests {
+		cfg := config.New()
+		bindings, err := cfg.Bindings().Load(map[string]string{"F1": "\\send 1", "Alt+1": "\\send 1", "!": ""})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg.SetBindings(bindings)
+		cfg.SetMessage(1, "CQ TEST")
+		cfg.SetMessage(2, "CQ TEST")
+		keyer := cwkeyer.New(cfg, testKey{})
+		ui := &testUI{inputFieldText: test.input}
+		inputHandler := handler.InputHandler(keyer, ui, cfg)
+
+		inputHandler(test.key)
+		if keyer.SendQueueIsEmpty() == test.wantCW {
+			t.Errorf("got empty queue %t, want CW %t for %s with %q", keyer.SendQueueIsEmpty(), test.wantCW, test.key.Name(), test.input)
+		}
+		if ui.inputFieldText != test.wantInput {
+			t.Errorf("got input %q, want %q for %s", ui.inputFieldText, test.wantInput, test.key.Name())
+		}
+	}
+}
+
+func TestCheckBindings(t *testing.T) {
+	tests := []struct {
+		spec        map[string]string
+		errorWanted bool
+	}{
+		{spec: map[string]string{"F1": "\\send 1", "F2": "\\speed 30"}, errorWanted: false},
+		{spec: map[string]string{"F1": "\\nope"}, errorWanted: true},
+		{spec: map[string]string{"F1": "CQ TEST"}, errorWanted: true},
+	}
+
+	for _, test := range tests {
+		bindings, _ := keys.Default().Load(test.spec)
+		err := handler.CheckBindings(bindings)
+		if test.errorWanted && (err == nil) {
+			t.Errorf("got nil, want error for %v", test.spec)
+		}
+		if !test.errorWanted && (err != nil) {
+			t.Errorf("got error %s, want nil for %v", err, test.spec)
+		}
+	}
+	if err := handler.CheckBindings(keys.Default()); err != nil {
+		t.Errorf("got error %s, want nil for the default bindings", err)
+	}
+}
+
+func TestKeysCommand(t *testing.T) {
+	cfg := config.New()
+	keyer := cwkeyer.New(cfg, testKey{})
+	ui := &testUI{}
+	inputHandler := handler.InputHandler(keyer, ui, cfg)
+
+	ui.inputFieldText = "\\keys"
+	inputHandler(enterKey)
+	// Compared without the padding, which depends on the longest key name
+	got := strings.Join(strings.Fields(ui.lastEvent()), " ")
+	for _, want := range []string{"Esc KEY \\stop", "Up KEY \\faster", "! KEY \\send 1", "Ctrl+T KEY \\bank"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("got %q, want it to include %q", ui.lastEvent(), want)
+		}
+	}
+}
+
+func TestPressCommand(t *testing.T) {
+	tests := []struct {
+		input     string
+		wantCW    bool
+		wantSpeed int
+		wantInput string
+	}{
+		{input: "\\press F1", wantCW: true, wantSpeed: config.InitSpeed},
+		{input: "\\k up", wantSpeed: config.InitSpeed + 1},
+		{input: "\\press F13", wantSpeed: config.InitSpeed, wantInput: "\\press F13"}, // Unbound
+		{input: "\\press Hyper+X", wantSpeed: config.InitSpeed, wantInput: "\\press Hyper+X"},
+		{input: "\\press F2", wantSpeed: config.InitSpeed, wantInput: "\\press F2"}, // Bound to a press
+	}
+
+	for _, test := range tests {
+		cfg := config.New()
+		bindings, err := cfg.Bindings().Load(map[string]string{"F1": "\\send 1", "F2": "\\k F2"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg.SetBindings(bindings)
+		cfg.SetMessage(1, "CQ TEST")
+		keyer := cwkeyer.New(cfg, testKey{})
+		ui := &testUI{inputFieldText: test.input}
+		inputHandler := handler.InputHandler(keyer, ui, cfg)
+
+		inputHandler(enterKey)
+		if keyer.SendQueueIsEmpty() == test.wantCW {
+			t.Errorf("got empty queue %t, want CW %t for %q", keyer.SendQueueIsEmpty(), test.wantCW, test.input)
+		}
+		if cfg.Speed() != test.wantSpeed {
+			t.Errorf("got speed %d, want %d for %q", cfg.Speed(), test.wantSpeed, test.input)
+		}
+		if ui.inputFieldText != test.wantInput {
+			t.Errorf("got input %q, want %q for %q", ui.inputFieldText, test.wantInput, test.input)
+		}
+	}
+}
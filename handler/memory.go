@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/keys"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+// KeyLabels returns the labels for the keys that send a memory, in
+// order of memory position.  Keys that type text and empty memories
+// are left out.
+func KeyLabels(cfg *config.Config) []tui.KeyLabel {
+	type boundKey struct {
+		position int
+		key      keys.Key
+	}
+	var bound []boundKey
+	bindings := cfg.Bindings()
+	for _, k := range bindings.Keys() {
+		fields := strings.Fields(bindings[k])
+		if k.Types() || len(fields) != 2 || fields[0] != "\\send" {
+			continue
+		}
+		position, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		bound = append(bound, boundKey{position: position, key: k})
+	}
+	sort.SliceStable(bound, func(i, j int) bool { return bound[i].position < bound[j].position })
+
+	var labels []tui.KeyLabel
+	for _, b := range bound {
+		m, err := cfg.Memory(b.position)
+		if err != nil || m.IsEmpty() {
+			continue
+		}
+		labels = append(labels, tui.KeyLabel{Key: b.key.String(), Label: m.Name()})
+	}
+	return labels
+}
+
+// KeyLabelUI is an interface of the UI methods used by ShowKeyLabels.
+type KeyLabelUI interface {
+	SetKeyLabels(bank string, labels []tui.KeyLabel)
+}
+
+// ShowKeyLabels shows the active memory bank and the labels of the keys
+// that send a memory in the UI.
+func ShowKeyLabels(ui KeyLabelUI, cfg *config.Config) {
+	ui.SetKeyLabels(cfg.Bank(), KeyLabels(cfg))
+}
+
+func handleBankCommand(c Context, arg string) {
+	if arg == "" {
+		c.Config.NextBank()
+	} else {
+		err := c.Config.SetBank(arg)
+		if err != nil {
+			c.UI.WriteEvent(tui.LevelError, err.Error())
+			return
+		}
+	}
+	ShowKeyLabels(c.UI, c.Config)
+	c.UI.WriteEvent(tui.LevelInfo, fmt.Sprintf("The memory bank is %s.", c.Config.Bank()))
+	c.UI.ClearInputText()
+}
+
+func handleMemCommand(c Context, arg string) {
+	fields := strings.Fields(arg)
+	if len(fields) == 0 {
+		c.UI.WriteEvent(tui.LevelInfo, memoryList(c.Config))
+		c.UI.ClearInputText()
+		return
+	}
+
+	var err error
+	switch fields[0] {
+	case "add":
+		err = addMemory(c, fields[1:])
+	case "rename":
+		err = renameMemory(c, fields[1:])
+	case "rm":
+		err = removeMemory(c, fields[1:])
+	default:
+		err = fmt.Errorf("unknown mem command %q", fields[0])
+	}
+	if err != nil {
+		c.UI.WriteEvent(tui.LevelError, err.Error())
+		return
+	}
+	ShowKeyLabels(c.UI, c.Config)
+	c.UI.ClearInputText()
+}
+
+func addMemory(c Context, fields []string) error {
+	if len(fields) < 2 {
+		return errors.New("mem add needs a label and a message")
+	}
+	position, err := c.Config.AddMemory(fields[0], strings.Join(fields[1:], " "))
+	if err != nil {
+		return err
+	}
+	m, _ := c.Config.Memory(position) // Just added, so the position is valid
+	c.UI.WriteEvent(tui.LevelInfo, fmt.Sprintf("Saved message %d [%s]: %s", position, m.Label, m.Message))
+	return nil
+}
+
+func renameMemory(c Context, fields []string) error {
+	if len(fields) == 0 {
+		return errors.New("mem rename needs a memory position")
+	}
+	position, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return errors.New("unable to parse memory position argument")
+	}
+	err = c.Config.SetLabel(position, strings.Join(fields[1:], " "))
+	if err != nil {
+		return err
+	}
+	m, _ := c.Config.Memory(position) // Just set, so the position is valid
+	if m.Label == "" {
+		c.UI.WriteEvent(tui.LevelInfo, fmt.Sprintf("Removed the label of message %d.", position))
+		return nil
+	}
+	c.UI.WriteEvent(tui.LevelInfo, fmt.Sprintf("Message %d is labelled %s.", position, m.Label))
+	return nil
+}
+
+func removeMemory(c Context, fields []string) error {
+	if len(fields) != 1 {
+		return errors.New("mem rm needs a memory position")
+	}
+	position, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return errors.New("unable to parse memory position argument")
+	}
+	err = c.Config.ClearMemory(position)
+	if err != nil {
+		return err
+	}
+	c.UI.WriteEvent(tui.LevelInfo, fmt.Sprintf("Cleared message %d.", position))
+	return nil
+}
+
+// memoryList returns the memories that are not empty, in keyboard order.
+func memoryList(cfg *config.Config) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("\nMemories in %s:\n", cfg.Bank()))
+	for _, position := range config.Positions() {
+		m, _ := cfg.Memory(position) // Error is not reachable here
+		if m.IsEmpty() {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("    %2d  %-*s  %s\n", position, config.MaxLabel, m.Label, m.Message))
+	}
+	return sb.String()
+}
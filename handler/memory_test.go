@@ -0,0 +1,139 @@
+package handler_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/scottmcleodjr/cwkeyer"
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/handler"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+func TestMemCommand(t *testing.T) {
+	tests := []struct {
+		input       string
+		position    int
+		want        config.Memory
+		errorWanted bool
+	}{
+		{input: "\\mem add CQ cq test k3gds", position: 1, want: config.Memory{Label: "CQ", Message: "CQ TEST K3GDS"}},
+		{input: "\\mem add EXCH 5nn", position: 2, want: config.Memory{Label: "EXCH", Message: "5NN"}},
+		{input: "\\mem rename 2 My Exch", position: 2, want: config.Memory{Label: "My Exch", Message: "5NN"}},
+		{input: "\\mem rename 2", position: 2, want: config.Memory{Message: "5NN"}},
+		{input: "\\mem rm 1", position: 1, want: config.Memory{}},
+		{input: "\\mem add TU tu ee", position: 1, want: config.Memory{Label: "TU", Message: "TU EE"}},
+		{input: "\\mem add TU", position: 3, errorWanted: true},
+		{input: "\\mem add TU 5NN ~", position: 3, errorWanted: true},
+		{input: "\\mem rename 99 CQ", position: 3, errorWanted: true},
+		{input: "\\mem rm x", position: 3, errorWanted: true},
+		{input: "\\mem nope", position: 3, errorWanted: true},
+	}
+
+	cfg := config.New()
+	keyer := cwkeyer.New(cfg, testKey{})
+	ui := &testUI{}
+	inputHandler := handler.InputHandler(keyer, ui, cfg)
+
+	for _, test := range tests {
+		ui.inputFieldText = test.input
+		inputHandler(enterKey)
+		if test.errorWanted && ui.inputFieldText == "" {
+			t.Errorf("got input cleared, want an error for %q", test.input)
+		}
+		if !test.errorWanted && ui.inputFieldText != "" {
+			t.Errorf("got error %q, want nil for %q", ui.lastEvent(), test.input)
+		}
+		m, _ := cfg.Memory(test.position)
+		if !test.errorWanted && m != test.want {
+			t.Errorf("got %+v, want %+v for %q", m, test.want, test.input)
+		}
+	}
+
+	ui.inputFieldText = "\\mem"
+	inputHandler(enterKey)
+	want := "\nMemories in Run:\n     1  TU        TU EE\n     2            5NN\n"
+	if ui.lastEvent() != want {
+		t.Errorf("got %q, want %q", ui.lastEvent(), want)
+	}
+}
+
+func TestKeyLabels(t *testing.T) {
+	cfg := config.New()
+	cfg.AddMemory("CQ", "CQ TEST K3GDS")
+	cfg.SetMessage(2, "5NN")
+	cfg.SetMessage(13, "QRZ") // Not bound to a key by default
+	bindings, _ := cfg.Bindings().Load(map[string]string{"Shift+F1": "\\send 1", "F3": "\\speed 30"})
+	cfg.SetBindings(bindings)
+
+	want := []tui.KeyLabel{{Key: "F1", Label: "CQ"}, {Key: "Shift+F1", Label: "CQ"}, {Key: "F2", Label: "5NN"}}
+	if got := handler.KeyLabels(cfg); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	keyer := cwkeyer.New(cfg, testKey{})
+	ui := &testUI{}
+	inputHandler := handler.InputHandler(keyer, ui, cfg)
+	ui.inputFieldText = "\\mem rm 2"
+	inputHandler(enterKey)
+	if !reflect.DeepEqual(ui.keyLabels, want[:2]) {
+		t.Errorf("got %v, want the labels shown without F2", ui.keyLabels)
+	}
+}
+
+func TestBankCommand(t *testing.T) {
+	cfg := config.New()
+	cfg.SetMessage(1, "CQ TEST K3GDS")
+	cfg.SetBank("S&P")
+	cfg.SetMessage(1, "K3GDS")
+	cfg.SetBank("Run")
+	keyer := &queueKeyer{}
+	ui := &testUI{}
+	inputHandler := handler.InputHandler(keyer, ui, cfg)
+
+	ctrlTKey := tcell.NewEventKey(tcell.KeyCtrlT, 0, tcell.ModCtrl)
+	f1Key := tcell.NewEventKey(tcell.KeyF1, 0, tcell.ModNone)
+	inputHandler(f1Key)
+	inputHandler(ctrlTKey)
+	inputHandler(f1Key)
+	if ui.bank != "S&P" || len(ui.keyLabels) != 1 || ui.keyLabels[0].Label != "K3GDS" {
+		t.Errorf("got %s with labels %v, want S&P shown", ui.bank, ui.keyLabels)
+	}
+	ui.inputFieldText = "\\bank run"
+	inputHandler(enterKey)
+	inputHandler(f1Key)
+	ui.inputFieldText = "\\bank nope"
+	inputHandler(enterKey)
+
+	want := []string{"CQ TEST K3GDS", "K3GDS", "CQ TEST K3GDS"}
+	if !reflect.DeepEqual(keyer.queued, want) {
+		t.Errorf("got %q sent, want %q", keyer.queued, want)
+	}
+	if cfg.Bank() != "Run" || ui.lastEvent() != `unknown memory bank "nope"` {
+		t.Errorf("got %s and %q, want Run and an unknown bank", cfg.Bank(), ui.lastEvent())
+	}
+}
+
+func TestSaveCommand(t *testing.T) {
+	cfg := config.New()
+	keyer := cwkeyer.New(cfg, testKey{})
+	ui := &testUI{}
+	inputHandler := handler.InputHandler(keyer, ui, cfg)
+
+	ui.inputFieldText = "\\save"
+	inputHandler(enterKey)
+	if ui.inputFieldText == "" {
+		t.Error("got input cleared, want an error with no config file")
+	}
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	cfg.SetPath(path)
+	cfg.SetMessage(1, "CQ TEST")
+	inputHandler(enterKey)
+	f, err := config.LoadFile(path)
+	if err != nil || f.Banks[0].Messages["1"] != "CQ TEST" {
+		t.Errorf("got %+v and %v, want the saved config", f, err)
+	}
+}
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+// Context is what a command runs with.
+type Context struct {
+	Keyer    Keyer // Nil where nothing is sent, like in the trainers
+	UI       UserInterface
+	Config   *config.Config
+	Commands *Registry // The registry the command is in
+	Name     string    // The name the command was entered as, without the backslash
+}
+
+// Command is a backslash command for the input field.
+type Command struct {
+	Name    string   // Name without the backslash, only shown in help if Match is set
+	Aliases []string // Other names for the command
+	Args    string   // Arguments for help, like "N" or "[CALL]"
+	Help    string   // Short description for help
+	Run     func(c Context, arg string)
+
+	// Match matches names that are not known ahead, like memory
+	// positions.  Commands with Match are not completed.
+	Match func(name string) bool
+}
+
+// Registry holds the commands for the input field, and builds the help
+// and completions from them.
+type Registry struct {
+	commands []*Command
+	names    map[string]*Command
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{names: map[string]*Command{}}
+}
+
+// Register adds a command.  Register returns an error if the command
+// has no name or Run function, or if a name is already registered.
+func (r *Registry) Register(cmd Command) error {
+	if cmd.Name == "" || cmd.Run == nil {
+		return errors.New("command needs a name and a Run function")
+	}
+	names := cmd.Aliases
+	if cmd.Match == nil {
+		names = append([]string{cmd.Name}, cmd.Aliases...)
+	}
+	for _, name := range names {
+		if _, ok := r.names[name]; ok {
+			return fmt.Errorf("command \\%s is already registered", name)
+		}
+	}
+	r.commands = append(r.commands, &cmd)
+	for _, name := range names {
+		r.names[name] = &cmd
+	}
+	return nil
+}
+
+// Subset returns a new Registry with only the commands of r that have
+// one of the names, in the same order.
+func (r *Registry) Subset(names ...string) *Registry {
+	keep := map[string]bool{}
+	for _, name := range names {
+		keep[name] = true
+	}
+	subset := NewRegistry()
+	for _, cmd := range r.commands {
+		if keep[cmd.Name] {
+			subset.Register(*cmd) // The names are already unique in r
+		}
+	}
+	return subset
+}
+
+// Lookup returns the command for a name without the backslash.  Names
+// and aliases are checked before the Match functions.
+func (r *Registry) Lookup(name string) (*Command, bool) {
+	if cmd, ok := r.names[name]; ok {
+		return cmd, true
+	}
+	for _, cmd := range r.commands {
+		if cmd.Match != nil && cmd.Match(name) {
+			return cmd, true
+		}
+	}
+	return nil, false
+}
+
+// Commands returns the commands in the order they were registered.
+func (r *Registry) Commands() []Command {
+	commands := make([]Command, len(r.commands))
+	for i, cmd := range r.commands {
+		commands[i] = *cmd
+	}
+	return commands
+}
+
+// Run runs a command line starting with a backslash.  Run writes an
+// error event if there is no such command.
+func (r *Registry) Run(line string, c Context) {
+	splitLine := strings.SplitN(strings.TrimPrefix(line, "\\"), " ", 2)
+	var arg string
+	if len(splitLine) > 1 {
+		arg = splitLine[1]
+	}
+	cmd, ok := r.Lookup(splitLine[0])
+	if !ok {
+		c.UI.WriteEvent(tui.LevelError, "unknown Command")
+		return
+	}
+	c.Commands = r
+	c.Name = splitLine[0]
+	cmd.Run(c, arg)
+}
+
+// Complete returns the command names and aliases, with the backslash,
+// that start with prefix, in order.
+func (r *Registry) Complete(prefix string) []string {
+	var completions []string
+	for name, cmd := range r.names {
+		if cmd.Match == nil && strings.HasPrefix("\\"+name, prefix) {
+			completions = append(completions, "\\"+name)
+		}
+	}
+	sort.Strings(completions)
+	return completions
+}
+
+// HelpLine is a line of help for a command or hotkey.
+type HelpLine struct {
+	Usage string // How it is entered
+	Kind  string // COMMAND or HOTKEY
+	Help  string
+}
+
+// HelpLines returns a line of help for each command.
+func (r *Registry) HelpLines() []HelpLine {
+	lines := make([]HelpLine, len(r.commands))
+	for i, cmd := range r.commands {
+		help := cmd.Help
+		if len(cmd.Aliases) > 0 {
+			help = fmt.Sprintf("%s (also \\%s)", help, strings.Join(cmd.Aliases, ", \\"))
+		}
+		usage := fmt.Sprintf(`"\%s"`, strings.TrimSpace(cmd.Name+" "+cmd.Args))
+		lines[i] = HelpLine{Usage: usage, Kind: "COMMAND", Help: help}
+	}
+	return lines
+}
+
+// FormatHelp returns help lines as aligned columns.
+func FormatHelp(lines []HelpLine) string {
+	width := 0
+	for _, line := range lines {
+		if len(line.Usage) > width {
+			width = len(line.Usage)
+		}
+	}
+	var sb strings.Builder
+	for _, line := range lines {
+		sb.WriteString(fmt.Sprintf("    %-*s  %-7s    %s\n", width, line.Usage, line.Kind, line.Help))
+	}
+	return sb.String()
+}
@@ -0,0 +1,194 @@
+package handler_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/scottmcleodjr/cwkeyer"
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/handler"
+	"github.com/scottmcleodjr/rekl/history"
+)
+
+func TestRegister(t *testing.T) {
+	run := func(handler.Context, string) {}
+
+	tests := []struct {
+		cmd         handler.Command
+		errorWanted bool
+	}{
+		{cmd: handler.Command{Name: "log", Aliases: []string{"l"}, Run: run}, errorWanted: false},
+		{cmd: handler.Command{Name: "log", Run: run}, errorWanted: true},                          // Name taken
+		{cmd: handler.Command{Name: "list", Aliases: []string{"l"}, Run: run}, errorWanted: true}, // Alias taken
+		{cmd: handler.Command{Name: "score"}, errorWanted: true},                                  // No Run
+		{cmd: handler.Command{Run: run}, errorWanted: true},                                       // No name
+	}
+
+	r := handler.NewRegistry()
+	for _, test := range tests {
+		err := r.Register(test.cmd)
+		if test.errorWanted && (err == nil) {
+			t.Errorf("got nil, want error for %+v", test.cmd)
+		}
+		if !test.errorWanted && (err != nil) {
+			t.Errorf("got error %s, want nil for %+v", err, test.cmd)
+		}
+	}
+	if len(r.Commands()) != 1 {
+		t.Errorf("got %d commands, want 1", len(r.Commands()))
+	}
+}
+
+func TestRegistryRun(t *testing.T) {
+	var gotName, gotArg string
+	r := handler.NewRegistry()
+	r.Register(handler.Command{
+		Name:    "log",
+		Aliases: []string{"l"},
+		Run:     func(c handler.Context, arg string) { gotName, gotArg = c.Name, arg },
+	})
+	r.Register(handler.Command{
+		Name:  "N",
+		Match: func(name string) bool { return name == "7" },
+		Run:   func(c handler.Context, arg string) { gotName, gotArg = c.Name, arg },
+	})
+
+	tests := []struct {
+		line     string
+		wantName string
+		wantArg  string
+	}{
+		{line: "\\log K3GDS 001", wantName: "log", wantArg: "K3GDS 001"},
+		{line: "\\l K3GDS", wantName: "l", wantArg: "K3GDS"},
+		{line: "\\7 CQ", wantName: "7", wantArg: "CQ"},
+		{line: "\\N CQ", wantName: "", wantArg: ""}, // Only matched names run a Match command
+		{line: "\\score", wantName: "", wantArg: ""},
+	}
+
+	for _, test := range tests {
+		gotName, gotArg = "", ""
+		ui := &testUI{}
+		r.Run(test.line, handler.Context{UI: ui, Config: config.New()})
+		if gotName != test.wantName || gotArg != test.wantArg {
+			t.Errorf("got %q and %q, want %q and %q for %q", gotName, gotArg, test.wantName, test.wantArg, test.line)
+		}
+		if test.wantName == "" && (len(ui.events) == 0 || ui.lastEvent() != "unknown Command") {
+			t.Errorf("got %v, want unknown Command for %q", ui.events, test.line)
+		}
+	}
+}
+
+func TestComplete(t *testing.T) {
+	r := handler.DefaultCommands()
+
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{prefix: "\\sp", want: "\\speed"},
+		{prefix: "\\s", want: "\\save \\save-events \\send \\slower \\speed \\stop"},
+		{prefix: "\\c", want: "\\call \\clear \\config"},
+		{prefix: "\\", want: "\\? \\bank \\call \\clear \\config \\exit \\farnsworth \\faster \\filter \\help \\k \\keys \\level \\mem \\press \\quit \\save \\save-events \\send \\slower \\speed \\stop \\wav \\weight"},
+		{prefix: "\\x", want: ""},
+		{prefix: "\\1", want: ""},
+	}
+
+	for _, test := range tests {
+		got := strings.Join(r.Complete(test.prefix), " ")
+		if got != test.want {
+			t.Errorf("got %q, want %q for %q", got, test.want, test.prefix)
+		}
+	}
+}
+
+func TestFormatHelp(t *testing.T) {
+	got := handler.FormatHelp([]handler.HelpLine{
+		{Usage: `"\speed [N]"`, Kind: "COMMAND", Help: "Set the speed"},
+		{Usage: "[ESC[]", Kind: "HOTKEY", Help: "Stop"},
+	})
+	want := "    \"\\speed [N]\"  COMMAND    Set the speed\n" +
+		"    [ESC[]        HOTKEY     Stop\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRegistryInputHandler(t *testing.T) {
+	r := handler.DefaultCommands()
+	scores := 0
+	err := r.Register(handler.Command{Name: "score", Help: "Display the score", Run: func(c handler.Context, _ string) {
+		scores++
+		c.UI.ClearInputText()
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.New()
+	keyer := cwkeyer.New(cfg, testKey{})
+	ui := &testUI{}
+	hist, err := history.Load("", history.DefaultMax)
+	if err != nil {
+		t.Fatal(err)
+	}
+	editor := handler.NewEditor(ui, hist, cfg)
+	editor.SetCommands(r)
+	inputHandler := editor.Handler(r.InputHandler(keyer, ui, cfg))
+
+	ui.inputFieldText = "\\sc"
+	inputHandler(tabKey)
+	if ui.inputFieldText != "\\score " {
+		t.Errorf("got %q, want the added command completed", ui.inputFieldText)
+	}
+	inputHandler(enterKey)
+	if scores != 1 || ui.inputFieldText != "" {
+		t.Errorf("got %d runs and input %q, want the added command run once", scores, ui.inputFieldText)
+	}
+	ui.inputFieldText = "\\help"
+	inputHandler(enterKey)
+	if !strings.Contains(ui.lastEvent(), "\\score") {
+		t.Errorf("got help %q, want the added command in it", ui.lastEvent())
+	}
+
+	// The built-in commands are left as they were
+	ui.inputFieldText = "\\score"
+	handler.InputHandler(keyer, ui, cfg)(enterKey)
+	if scores != 1 || ui.lastEvent() != "unknown Command" {
+		t.Errorf("got %d runs and event %q, want unknown Command from the built-in commands", scores, ui.lastEvent())
+	}
+}
+
+func TestSubset(t *testing.T) {
+	r := handler.DefaultCommands().Subset("help", "quit", "faster")
+	cfg := config.New()
+	ui := &testUI{}
+	inputHandler := r.InputHandler(nil, ui, cfg)
+
+	tests := []struct {
+		input     string
+		wantEvent string
+	}{
+		{input: "CQ TEST", wantEvent: "only commands can be entered here"},
+		{input: "\\send 1", wantEvent: "unknown Command"},
+		{input: "\\stop", wantEvent: "unknown Command"},
+		{input: "\\faster", wantEvent: "The CW speed is 19 WPM."},
+	}
+
+	for _, test := range tests {
+		ui.inputFieldText = test.input
+		inputHandler(enterKey)
+		if ui.lastEvent() != test.wantEvent {
+			t.Errorf("got event %q, want %q for %q", ui.lastEvent(), test.wantEvent, test.input)
+		}
+	}
+
+	// Keys bound to commands left out are not hotkeys
+	if inputHandler(escKey) == nil {
+		t.Error("got Esc handled, want it left alone without \\stop")
+	}
+	if inputHandler(upKey) != nil || cfg.Speed() != config.InitSpeed+2 {
+		t.Errorf("got speed %d, want Up to run \\faster", cfg.Speed())
+	}
+	if got := strings.Join(r.Complete("\\"), " "); got != "\\? \\exit \\faster \\help \\quit" {
+		t.Errorf("got completions %q, want only the subset", got)
+	}
+}
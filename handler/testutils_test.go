@@ -1,7 +1,10 @@
 package handler_test
 
 import (
+	"strings"
+
 	"github.com/gdamore/tcell/v2"
+	"github.com/scottmcleodjr/rekl/bus"
 	"github.com/scottmcleodjr/rekl/tui"
 )
 
@@ -9,6 +12,10 @@ var (
 	enterKey    = tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone)
 	escKey      = tcell.NewEventKey(tcell.KeyESC, 0, tcell.ModNone)
 	upKey       = tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+	tabKey      = tcell.NewEventKey(tcell.KeyTab, 0, tcell.ModNone)
+	ctrlPKey    = tcell.NewEventKey(tcell.KeyCtrlP, 0, tcell.ModCtrl)
+	ctrlNKey    = tcell.NewEventKey(tcell.KeyCtrlN, 0, tcell.ModCtrl)
+	ctrlRKey    = tcell.NewEventKey(tcell.KeyCtrlR, 0, tcell.ModCtrl)
 	downKey     = tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
 	message1Key = tcell.NewEventKey(tcell.KeyRune, '!', tcell.ModNone)
 	message2Key = tcell.NewEventKey(tcell.KeyRune, '@', tcell.ModNone)
@@ -26,6 +33,13 @@ var (
 type testUI struct {
 	events         []string
 	inputFieldText string
+	currentCall    string
+	bank           string
+	keyLabels      []tui.KeyLabel
+	eventLevel     tui.Level
+	eventKinds     []string
+	search         string
+	published      []bus.Event
 	stopped        bool
 }
 
@@ -41,10 +55,59 @@ func (ui *testUI) InputText() string {
 	return ui.inputFieldText
 }
 
+func (ui *testUI) SetInputText(text string) {
+	ui.inputFieldText = text
+}
+
 func (ui *testUI) ClearInputText() {
 	ui.inputFieldText = ""
 }
 
+func (ui *testUI) SetCurrentCall(call string) {
+	ui.currentCall = call
+}
+
+func (ui *testUI) SetKeyLabels(bank string, labels []tui.KeyLabel) {
+	ui.bank = bank
+	ui.keyLabels = labels
+}
+
+func (ui *testUI) EventLevel() tui.Level {
+	return ui.eventLevel
+}
+
+func (ui *testUI) SetEventLevel(level tui.Level) {
+	ui.eventLevel = level
+}
+
+func (ui *testUI) SetEventKinds(kinds []string) {
+	ui.eventKinds = kinds
+}
+
+// SearchEvents returns the number of events with the text in them.
+func (ui *testUI) SearchEvents(text string) int {
+	ui.search = text
+	matches := 0
+	for _, event := range ui.events {
+		if text != "" && strings.Contains(strings.ToUpper(event), strings.ToUpper(text)) {
+			matches++
+		}
+	}
+	return matches
+}
+
+func (ui *testUI) EventText() string {
+	return strings.Join(ui.events, "\n") + "\n"
+}
+
+// Publish records the event and writes its message like the bus does.
+func (ui *testUI) Publish(e bus.Event) {
+	ui.published = append(ui.published, e)
+	if e.Message != "" {
+		ui.WriteEvent(e.Level, e.Message)
+	}
+}
+
 func (ui *testUI) StopApp() {
 	ui.stopped = true
 }
@@ -63,3 +126,17 @@ func (ts testKey) Down() error {
 func (ts testKey) Up() error {
 	return nil
 }
+
+// queueKeyer is a Keyer that records the queued messages.
+type queueKeyer struct {
+	queued []string
+}
+
+func (k *queueKeyer) QueueMessage(message string) error {
+	k.queued = append(k.queued, message)
+	return nil
+}
+
+func (k *queueKeyer) DrainSendQueue() {
+	k.queued = nil
+}
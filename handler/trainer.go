@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+// Trainer is a CW copy trainer run by TrainerHandler.
+type Trainer interface {
+	Next() string                              // The next text to send
+	Check(sent, copied string) (string, error) // Score copied text and return feedback
+	Summary() string                           // The state of the training
+}
+
+// Player plays trainer text as CW in the background.
+type Player interface {
+	Play(text string)
+	Stop()
+}
+
+// TrainerHandler processes user input to the TUI while training.  Enter
+// with copied text in the input field checks it and plays the next text,
+// and Enter with an empty input field plays the current text again.  The
+// keys bound in the Config and the commands work as they do in InputHandler,
+// except that the key bound to the stop command stops the player.
+func TrainerHandler(trainer Trainer, player Player, ui UserInterface, cfg *config.Config) func(*tcell.EventKey) *tcell.EventKey {
+	current := ""
+	return func(capture *tcell.EventKey) *tcell.EventKey {
+		if line, ok := cfg.Bindings().Lookup(capture); ok {
+			name := strings.SplitN(strings.TrimPrefix(line, "\\"), " ", 2)[0]
+			if cmd, ok := commands.Lookup(name); ok && cmd.Name == "stop" {
+				player.Stop()
+				ui.WriteEvent(tui.LevelInfo, "Stopped.  Press Enter to play it again.")
+				return nil
+			}
+		}
+		if hotkeyHandler(commands, capture, nil, ui, cfg) {
+			return nil // Don't return the capture for hotkeys
+		}
+
+		if capture.Key() == tcell.KeyEnter && strings.HasPrefix(ui.InputText(), "\\") {
+			commands.Run(ui.InputText(), Context{UI: ui, Config: cfg})
+			return capture
+		}
+
+		if capture.Key() == tcell.KeyEnter {
+			copied := ui.InputText()
+			switch {
+			case current == "":
+				ui.WriteEvent(tui.LevelInfo, trainer.Summary())
+			case copied == "":
+				player.Play(current)
+				return capture
+			default:
+				feedback, err := trainer.Check(current, copied)
+				ui.WriteEvent(tui.LevelInfo, feedback)
+				if err != nil {
+					ui.WriteEvent(tui.LevelError, err.Error())
+				}
+				ui.ClearInputText()
+			}
+			current = trainer.Next()
+			player.Play(current)
+		}
+
+		return capture
+	}
+}
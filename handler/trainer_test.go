@@ -0,0 +1,120 @@
+package handler_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/handler"
+)
+
+func TestTrainerHandler(t *testing.T) {
+	cfg := config.New()
+	ui := &testUI{}
+	trainer := &testTrainer{texts: []string{"KMKMK", "MMKKM"}}
+	player := &testPlayer{}
+	trainerHandler := handler.TrainerHandler(trainer, player, ui, cfg)
+
+	// Enter starts training
+	trainerHandler(enterKey)
+	if player.lastPlayed() != "KMKMK" {
+		t.Errorf("got %q, want %q played at the start", player.lastPlayed(), "KMKMK")
+	}
+
+	// Enter with no input plays it again
+	trainerHandler(enterKey)
+	if len(player.played) != 2 || player.lastPlayed() != "KMKMK" {
+		t.Errorf("got %q, want %q played again", player.played, "KMKMK")
+	}
+
+	// Enter with input checks it and plays the next text
+	ui.inputFieldText = "KMKMM"
+	trainerHandler(enterKey)
+	if trainer.checked != "KMKMK=KMKMM" {
+		t.Errorf("got %q, want %q checked", trainer.checked, "KMKMK=KMKMM")
+	}
+	if player.lastPlayed() != "MMKKM" {
+		t.Errorf("got %q, want %q played next", player.lastPlayed(), "MMKKM")
+	}
+	if ui.inputFieldText != "" {
+		t.Errorf("got %q, want empty input after check", ui.inputFieldText)
+	}
+
+	// Hotkeys stop and change speed
+	trainerHandler(escKey)
+	if !player.stopped {
+		t.Error("player not stopped after stop hotkey")
+	}
+	trainerHandler(upKey)
+	if cfg.Speed() != config.InitSpeed+1 {
+		t.Errorf("got %d, want %d after speed hotkey", cfg.Speed(), config.InitSpeed+1)
+	}
+}
+
+func TestTrainerHandlerBindings(t *testing.T) {
+	cfg := config.New()
+	bindings, err := cfg.Bindings().Load(map[string]string{"Esc": "", "Ctrl+X": "\\stop", "Up": "", "Ctrl+F": "\\faster"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.SetBindings(bindings)
+	ui := &testUI{}
+	player := &testPlayer{}
+	trainerHandler := handler.TrainerHandler(&testTrainer{}, player, ui, cfg)
+
+	// Unbound keys are left for the input field
+	if trainerHandler(escKey) == nil || player.stopped {
+		t.Error("got Esc handled after unbinding it, want it returned")
+	}
+	if trainerHandler(upKey) == nil || cfg.Speed() != config.InitSpeed {
+		t.Error("got Up handled after unbinding it, want it returned")
+	}
+
+	// The keys bound to stop and faster are used instead
+	if trainerHandler(tcell.NewEventKey(tcell.KeyCtrlX, 0, tcell.ModCtrl)) != nil || !player.stopped {
+		t.Error("player not stopped by the key bound to stop")
+	}
+	if trainerHandler(tcell.NewEventKey(tcell.KeyCtrlF, 0, tcell.ModCtrl)) != nil || cfg.Speed() != config.InitSpeed+1 {
+		t.Errorf("got %d, want %d after the key bound to faster", cfg.Speed(), config.InitSpeed+1)
+	}
+}
+
+// testTrainer is a stub implementation of handler.Trainer.
+type testTrainer struct {
+	texts   []string
+	checked string
+}
+
+func (tr *testTrainer) Next() string {
+	text := tr.texts[0]
+	tr.texts = tr.texts[1:]
+	return text
+}
+
+func (tr *testTrainer) Check(sent, copied string) (string, error) {
+	tr.checked = sent + "=" + copied
+	return "checked", nil
+}
+
+func (tr *testTrainer) Summary() string {
+	return strings.Join(tr.texts, " ")
+}
+
+// testPlayer is a stub implementation of handler.Player.
+type testPlayer struct {
+	played  []string
+	stopped bool
+}
+
+func (p *testPlayer) Play(text string) {
+	p.played = append(p.played, text)
+}
+
+func (p *testPlayer) Stop() {
+	p.stopped = true
+}
+
+func (p *testPlayer) lastPlayed() string {
+	return p.played[len(p.played)-1]
+}
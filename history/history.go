@@ -0,0 +1,129 @@
+package history
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const DefaultMax = 1000
+
+// History is the lines entered in the input field, oldest first.  Lines
+// are appended to a file as they are added, so history persists across
+// sessions.  History is safe for use from any goroutine.
+type History struct {
+	mu    sync.Mutex
+	lines []string
+	max   int
+	path  string
+}
+
+// Load returns the History in the file at path, keeping up to max lines.
+// A missing file is an empty History, and an empty path keeps the
+// History in memory only.
+func Load(path string, max int) (*History, error) {
+	h := &History{max: max, path: path}
+	if path == "" {
+		return h, nil
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		h.lines = append(h.lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(h.lines) > max {
+		h.lines = h.lines[len(h.lines)-max:]
+		return h, h.rewrite()
+	}
+	return h, nil
+}
+
+// rewrite replaces the file with the lines kept.
+func (h *History) rewrite() error {
+	tmpPath := h.path + ".tmp"
+	err := os.WriteFile(tmpPath, []byte(strings.Join(h.lines, "\n")+"\n"), 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, h.path)
+}
+
+// Add adds a line to the end of the History.  Blank lines and repeats
+// of the last line are not added.
+func (h *History) Add(line string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if strings.TrimSpace(line) == "" || strings.Contains(line, "\n") {
+		return nil
+	}
+	if len(h.lines) > 0 && h.lines[len(h.lines)-1] == line {
+		return nil
+	}
+	h.lines = append(h.lines, line)
+	if len(h.lines) > h.max {
+		h.lines = h.lines[1:]
+	}
+	if h.path == "" {
+		return nil
+	}
+
+	err := os.MkdirAll(filepath.Dir(h.path), 0755)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = file.WriteString(line + "\n")
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Len returns the number of lines.
+func (h *History) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.lines)
+}
+
+// Line returns line i, with 0 the oldest.
+func (h *History) Line(i int) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.lines[i]
+}
+
+// Search returns the index of the newest line before index before that
+// contains query, and if there is one.
+func (h *History) Search(query string, before int) (int, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if before > len(h.lines) {
+		before = len(h.lines)
+	}
+	query = strings.ToUpper(query)
+	for i := before - 1; i >= 0; i-- {
+		if strings.Contains(strings.ToUpper(h.lines[i]), query) {
+			return i, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,90 @@
+package history_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scottmcleodjr/rekl/history"
+)
+
+func lines(h *history.History) string {
+	var all []string
+	for i := 0; i < h.Len(); i++ {
+		all = append(all, h.Line(i))
+	}
+	return strings.Join(all, "|")
+}
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rekl", "history")
+	h, err := history.Load(path, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{"CQ TEST", "CQ TEST", " ", "\\speed 25", "K3GDS 5NN", "TU"} {
+		err = h.Add(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := lines(h), "\\speed 25|K3GDS 5NN|TU"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Every line is in the file, and only the last are loaded
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "CQ TEST\n\\speed 25\nK3GDS 5NN\nTU\n"; got != want {
+		t.Errorf("got file %q, want %q", got, want)
+	}
+	loaded, err := history.Load(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := lines(loaded), "K3GDS 5NN|TU"; got != want {
+		t.Errorf("got %q loaded, want %q", got, want)
+	}
+	data, _ = os.ReadFile(path)
+	if got, want := string(data), "K3GDS 5NN\nTU\n"; got != want {
+		t.Errorf("got file %q after loading, want %q", got, want)
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	h, err := history.Load(filepath.Join(t.TempDir(), "none"), history.DefaultMax)
+	if err != nil || h.Len() != 0 {
+		t.Errorf("got %d lines and error %v, want an empty history", h.Len(), err)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	h, _ := history.Load("", history.DefaultMax)
+	for _, line := range []string{"CQ TEST K3GDS", "W1AW 5NN", "CQ TEST K3GDS K3GDS", "TU"} {
+		h.Add(line)
+	}
+
+	tests := []struct {
+		query   string
+		before  int
+		want    int
+		wantHit bool
+	}{
+		{query: "cq", before: 4, want: 2, wantHit: true},
+		{query: "cq", before: 2, want: 0, wantHit: true},
+		{query: "cq", before: 0, want: 0, wantHit: false},
+		{query: "5nn", before: 99, want: 1, wantHit: true},
+		{query: "qrz", before: 4, want: 0, wantHit: false},
+	}
+
+	for _, test := range tests {
+		got, hit := h.Search(test.query, test.before)
+		if got != test.want || hit != test.wantHit {
+			t.Errorf("got %d and %t, want %d and %t for %q before %d", got, hit, test.want, test.wantHit, test.query, test.before)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+
+	"github.com/scottmcleodjr/rekl/api"
+	"github.com/scottmcleodjr/rekl/bus"
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/handler"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+// apiFlags are the flags for the HTTP control API.
+type apiFlags struct {
+	enabled *bool
+	addr    *string
+	token   *string
+}
+
+func addAPIFlags(flags *flag.FlagSet) apiFlags {
+	return apiFlags{
+		enabled: flags.Bool("http", false, "If the REKL should serve the web UI and HTTP control API"),
+		addr:    flags.String("http-addr", api.DefaultAddr, "Address for the HTTP control API"),
+		token:   flags.String("http-token", "", "Token required by the HTTP control API, as a bearer token or token query parameter"),
+	}
+}
+
+// start serves the API and web UI in the background if it is enabled.
+// Serving errors are written to the event view.
+func (af apiFlags) start(keyer handler.Keyer, events *bus.Bus, cfg *config.Config) {
+	if !*af.enabled {
+		return
+	}
+	server := api.New(keyer, events, cfg, *af.addr, *af.token)
+	go func() {
+		err := http.ListenAndServe(*af.addr, server.Handler())
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			events.WriteEvent(tui.LevelError, fmt.Sprintf("HTTP control API stopped: %s", err))
+		}
+	}()
+	events.WriteEvent(tui.LevelInfo, fmt.Sprintf("Serving the web UI and HTTP control API on http://%s", *af.addr))
+}
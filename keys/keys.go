@@ -0,0 +1,208 @@
+package keys
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Key is a key with its modifiers, as it is bound.
+type Key struct {
+	Key  tcell.Key
+	Rune rune          // For tcell.KeyRune
+	Mod  tcell.ModMask // Ctrl letters have no modifier, and runes only Alt
+}
+
+// Parse returns the Key for a name like "F1", "Shift+F1", "Alt+1",
+// "Ctrl+S", "Esc" or "!".  Modifiers and key names are not case sensitive.
+func Parse(name string) (Key, error) {
+	base := name
+	var mod tcell.ModMask
+	for {
+		i := strings.Index(base, "+")
+		if i <= 0 || i == len(base)-1 {
+			break
+		}
+		switch strings.ToLower(base[:i]) {
+		case "ctrl":
+			mod |= tcell.ModCtrl
+		case "alt":
+			mod |= tcell.ModAlt
+		case "shift":
+			mod |= tcell.ModShift
+		default:
+			return Key{}, fmt.Errorf("unknown modifier in key %q", name)
+		}
+		base = base[i+1:]
+	}
+
+	if utf8.RuneCountInString(base) == 1 {
+		r, _ := utf8.DecodeRuneInString(base)
+		switch {
+		case mod == tcell.ModCtrl && r >= 'a' && r <= 'z':
+			return Key{Key: tcell.KeyCtrlA + tcell.Key(r-'a')}, nil
+		case mod == tcell.ModCtrl && r >= 'A' && r <= 'Z':
+			return Key{Key: tcell.KeyCtrlA + tcell.Key(r-'A')}, nil
+		case mod&^tcell.ModAlt == 0:
+			return Key{Key: tcell.KeyRune, Rune: r, Mod: mod}, nil
+		}
+		return Key{}, fmt.Errorf("unsupported modifiers in key %q", name)
+	}
+	for k, keyName := range tcell.KeyNames {
+		if strings.EqualFold(base, keyName) && !strings.HasPrefix(keyName, "Ctrl-") {
+			return Key{Key: k, Mod: mod}, nil
+		}
+	}
+	return Key{}, fmt.Errorf("unknown key %q", name)
+}
+
+// FromEvent returns the Key for a key event.
+func FromEvent(capture *tcell.EventKey) Key {
+	switch {
+	case capture.Key() == tcell.KeyRune:
+		return Key{Key: tcell.KeyRune, Rune: capture.Rune(), Mod: capture.Modifiers() & tcell.ModAlt}
+	case capture.Key() >= tcell.KeyCtrlA && capture.Key() <= tcell.KeyCtrlZ:
+		return Key{Key: capture.Key()}
+	}
+	return Key{Key: capture.Key(), Mod: capture.Modifiers() & (tcell.ModCtrl | tcell.ModAlt | tcell.ModShift)}
+}
+
+// String returns the name of the Key, as Parse accepts it.
+func (k Key) String() string {
+	var sb strings.Builder
+	for _, modifier := range []struct {
+		mod  tcell.ModMask
+		name string
+	}{{tcell.ModCtrl, "Ctrl+"}, {tcell.ModAlt, "Alt+"}, {tcell.ModShift, "Shift+"}} {
+		if k.Mod&modifier.mod != 0 {
+			sb.WriteString(modifier.name)
+		}
+	}
+	if k.Key == tcell.KeyRune {
+		sb.WriteRune(k.Rune)
+		return sb.String()
+	}
+	name, ok := tcell.KeyNames[k.Key]
+	if !ok {
+		name = fmt.Sprintf("Key%d", k.Key)
+	}
+	sb.WriteString(strings.Replace(name, "Ctrl-", "Ctrl+", 1))
+	return sb.String()
+}
+
+// Types returns if the Key types text in the input field.
+func (k Key) Types() bool {
+	return k.Key == tcell.KeyRune && k.Mod == 0
+}
+
+// reserved are the keys used by the input field and editor.
+var reserved = map[Key]bool{
+	{Key: tcell.KeyEnter}:     true,
+	{Key: tcell.KeyTab}:       true,
+	{Key: tcell.KeyBackspace}: true,
+	{Key: tcell.KeyCtrlC}:     true, // Quits the TUI
+	{Key: tcell.KeyCtrlG}:     true,
+	{Key: tcell.KeyCtrlN}:     true,
+	{Key: tcell.KeyCtrlP}:     true,
+	{Key: tcell.KeyCtrlR}:     true,
+	{Key: tcell.KeyPgUp}:      true, // Pages the event view
+	{Key: tcell.KeyPgDn}:      true,
+}
+
+// Bindings maps keys to command lines.
+type Bindings map[Key]string
+
+// Default returns the default Bindings.  Up and Down change the speed,
+// Esc stops sending, Ctrl+T switches the memory bank, and the shifted
+// digit row and F1 to F12 send the memories.
+func Default() Bindings {
+	b := Bindings{
+		{Key: tcell.KeyUp}:    "\\faster",
+		{Key: tcell.KeyDown}:  "\\slower",
+		{Key: tcell.KeyEsc}:   "\\stop",
+		{Key: tcell.KeyCtrlT}: "\\bank",
+	}
+	for position, r := range ")!@#$%^&*(" {
+		b[Key{Key: tcell.KeyRune, Rune: r}] = fmt.Sprintf("\\send %d", position)
+	}
+	for position := 1; position <= 12; position++ {
+		b[Key{Key: tcell.KeyF1 + tcell.Key(position-1)}] = fmt.Sprintf("\\send %d", position)
+	}
+	return b
+}
+
+// Load returns a copy of the Bindings with bindings from a map of key
+// names to command lines.  A key bound to an empty line is unbound.
+// Load returns an error if a name is invalid or reserved, or if two
+// names are the same key.
+func (b Bindings) Load(spec map[string]string) (Bindings, error) {
+	loaded := Bindings{}
+	for k, line := range b {
+		loaded[k] = line
+	}
+
+	// Sorted so errors are the same every time
+	names := make([]string, 0, len(spec))
+	for name := range spec {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	seen := map[Key]string{}
+	for _, name := range names {
+		k, err := Parse(name)
+		if err != nil {
+			return nil, err
+		}
+		if reserved[k] {
+			return nil, fmt.Errorf("key %s is reserved", k)
+		}
+		if other, ok := seen[k]; ok {
+			return nil, fmt.Errorf("keys %q and %q are both %s", other, name, k)
+		}
+		seen[k] = name
+
+		line := strings.TrimSpace(spec[name])
+		if line == "" {
+			delete(loaded, k)
+			continue
+		}
+		loaded[k] = line
+	}
+	return loaded, nil
+}
+
+// Spec returns the map of key names to command lines that Loads base
+// into the Bindings.
+func (b Bindings) Spec(base Bindings) map[string]string {
+	spec := map[string]string{}
+	for k, line := range b {
+		if base[k] != line {
+			spec[k.String()] = line
+		}
+	}
+	for k := range base {
+		if _, ok := b[k]; !ok {
+			spec[k.String()] = ""
+		}
+	}
+	return spec
+}
+
+// Lookup returns the command line bound to the key of an event.
+func (b Bindings) Lookup(capture *tcell.EventKey) (string, bool) {
+	line, ok := b[FromEvent(capture)]
+	return line, ok
+}
+
+// Keys returns the bound keys in order of their names.
+func (b Bindings) Keys() []Key {
+	keys := make([]Key, 0, len(b))
+	for k := range b {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	return keys
+}
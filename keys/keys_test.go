@@ -0,0 +1,106 @@
+package keys_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/scottmcleodjr/rekl/keys"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		event       *tcell.EventKey
+		want        string
+		errorWanted bool
+	}{
+		{name: "F1", event: tcell.NewEventKey(tcell.KeyF1, 0, tcell.ModNone), want: "F1"},
+		{name: "shift+f12", event: tcell.NewEventKey(tcell.KeyF12, 0, tcell.ModShift), want: "Shift+F12"},
+		{name: "Alt+1", event: tcell.NewEventKey(tcell.KeyRune, '1', tcell.ModAlt), want: "Alt+1"},
+		{name: "Ctrl+s", event: tcell.NewEventKey(tcell.KeyCtrlS, 0, tcell.ModCtrl), want: "Ctrl+S"},
+		{name: "esc", event: tcell.NewEventKey(tcell.KeyEsc, 0, tcell.ModNone), want: "Esc"},
+		{name: "!", event: tcell.NewEventKey(tcell.KeyRune, '!', tcell.ModNone), want: "!"},
+		{name: "+", event: tcell.NewEventKey(tcell.KeyRune, '+', tcell.ModNone), want: "+"},
+		{name: "Alt++", event: tcell.NewEventKey(tcell.KeyRune, '+', tcell.ModAlt), want: "Alt++"},
+		{name: "Hyper+1", errorWanted: true},
+		{name: "Ctrl+1", errorWanted: true},
+		{name: "F99", errorWanted: true},
+	}
+
+	for _, test := range tests {
+		got, err := keys.Parse(test.name)
+		if test.errorWanted {
+			if err == nil {
+				t.Errorf("got nil, want error for %q", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("got error %s, want nil for %q", err, test.name)
+			continue
+		}
+		if got.String() != test.want {
+			t.Errorf("got %s, want %s for %q", got, test.want, test.name)
+		}
+		if keys.FromEvent(test.event) != got {
+			t.Errorf("got %s from the event, want %s", keys.FromEvent(test.event), got)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		spec        map[string]string
+		errorWanted bool
+	}{
+		{spec: map[string]string{"F1": "\\send 1", "Alt+1": "\\send 1", "Up": ""}, errorWanted: false},
+		{spec: map[string]string{"Ctrl+S": "\\stop", "ctrl+s": "\\faster"}, errorWanted: true}, // Same key
+		{spec: map[string]string{"Enter": "\\stop"}, errorWanted: true},                        // Reserved
+		{spec: map[string]string{"Ctrl+M": "\\stop"}, errorWanted: true},                       // Enter
+		{spec: map[string]string{"PgUp": "\\stop"}, errorWanted: true},                         // Pages the event view
+		{spec: map[string]string{"Ctrl+R": "\\stop"}, errorWanted: true},                       // Reverse search
+		{spec: map[string]string{"Nope": "\\stop"}, errorWanted: true},
+	}
+
+	for _, test := range tests {
+		_, err := keys.Default().Load(test.spec)
+		if test.errorWanted && (err == nil) {
+			t.Errorf("got nil, want error for %v", test.spec)
+		}
+		if !test.errorWanted && (err != nil) {
+			t.Errorf("got error %s, want nil for %v", err, test.spec)
+		}
+	}
+
+	defaults := keys.Default()
+	b, _ := defaults.Load(map[string]string{"F1": " \\send 1 ", "Up": ""})
+	if line, ok := b.Lookup(tcell.NewEventKey(tcell.KeyF1, 0, tcell.ModNone)); !ok || line != "\\send 1" {
+		t.Errorf("got %q and %t for F1, want \\send 1", line, ok)
+	}
+	if _, ok := b.Lookup(tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)); ok {
+		t.Error("got Up bound, want it unbound")
+	}
+	if _, ok := defaults.Lookup(tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)); !ok {
+		t.Error("got Up unbound in the defaults, want Load to leave them")
+	}
+	if line, _ := b.Lookup(tcell.NewEventKey(tcell.KeyRune, '#', tcell.ModNone)); line != "\\send 3" {
+		t.Errorf("got %q for #, want \\send 3", line)
+	}
+}
+
+func TestSpec(t *testing.T) {
+	defaults := keys.Default()
+	b, err := defaults.Load(map[string]string{"F1": "\\speed 30", "Alt+1": "\\send 1", "Up": ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"F1": "\\speed 30", "Alt+1": "\\send 1", "Up": ""}
+	if got := b.Spec(defaults); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	loaded, _ := keys.Default().Load(b.Spec(defaults))
+	if !reflect.DeepEqual(loaded, b) {
+		t.Errorf("got %v, want %v after loading the spec", loaded, b)
+	}
+}
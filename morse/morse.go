@@ -0,0 +1,180 @@
+package morse
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	ParisUnits  = 50 // Units in the standard word "PARIS ", including the word space
+	CharGap     = 3  // Units between characters in a word
+	WordGap     = 7  // Units between words
+	ElementGap  = 1  // Units between elements in a character
+	DitUnits    = 1  // Units in a dit
+	DahUnits    = 3  // Units in a dah
+	unitsPerMin = 1200
+)
+
+var codes = map[rune]string{
+	'A': ".-", 'B': "-...", 'C': "-.-.", 'D': "-..", 'E': ".", 'F': "..-.",
+	'G': "--.", 'H': "....", 'I': "..", 'J': ".---", 'K': "-.-", 'L': ".-..",
+	'M': "--", 'N': "-.", 'O': "---", 'P': ".--.", 'Q': "--.-", 'R': ".-.",
+	'S': "...", 'T': "-", 'U': "..-", 'V': "...-", 'W': ".--", 'X': "-..-",
+	'Y': "-.--", 'Z': "--..",
+	'0': "-----", '1': ".----", '2': "..---", '3': "...--", '4': "....-",
+	'5': ".....", '6': "-....", '7': "--...", '8': "---..", '9': "----.",
+	'.': ".-.-.-", ',': "--..--", '?': "..--..", '/': "-..-.", '=': "-...-",
+}
+
+var runes = func() map[string]rune {
+	runes := make(map[string]rune, len(codes))
+	for r, code := range codes {
+		runes[code] = r
+	}
+	return runes
+}()
+
+// Rune returns the rune for a string of '.' and '-'.  Rune
+// returns false if the code is not a known character.
+func Rune(code string) (rune, bool) {
+	r, ok := runes[code]
+	return r, ok
+}
+
+// Code returns the dits and dahs for a rune as a string of '.' and '-'.
+// Code returns false if the rune has no Morse code representation.
+func Code(r rune) (string, bool) {
+	code, ok := codes[r]
+	return code, ok
+}
+
+// Dit returns the length of one unit at a WPM speed using PARIS timing.
+func Dit(wpm int) time.Duration {
+	return time.Duration(unitsPerMin/wpm) * time.Millisecond
+}
+
+// Units returns the length of a message in PARIS units.  The count
+// includes the gaps between characters and words, but not any trailing
+// gap after the last character.  Runes with no code are ignored.
+func Units(message string) int {
+	units := 0
+	for i, word := range strings.Fields(strings.ToUpper(message)) {
+		if i > 0 {
+			units += WordGap
+		}
+		first := true
+		for _, r := range word {
+			code, ok := codes[r]
+			if !ok {
+				continue
+			}
+			if !first {
+				units += CharGap
+			}
+			first = false
+			units += charUnits(code)
+		}
+	}
+	return units
+}
+
+// Duration returns how long a message takes to send at a WPM speed.
+func Duration(message string, wpm int) time.Duration {
+	return time.Duration(Units(message)) * Dit(wpm)
+}
+
+func charUnits(code string) int {
+	units := 0
+	for i, element := range code {
+		if i > 0 {
+			units += ElementGap
+		}
+		if element == '-' {
+			units += DahUnits
+		} else {
+			units += DitUnits
+		}
+	}
+	return units
+}
+
+// Timing is the timing used to send a message.  Farnsworth spacing is
+// used if Farnsworth is above zero and below WPM.  Weight is the key down
+// percentage of a dit and the following element gap, 50 for standard.
+type Timing struct {
+	WPM        int // Character speed
+	Farnsworth int // Overall speed with longer character and word gaps
+	Weight     int // Key down weight as a percentage
+}
+
+// Element is a key down or key up period in a sent message.
+type Element struct {
+	Down     bool
+	Duration time.Duration
+}
+
+// Elements returns the key down and key up periods for a message, starting
+// with the first key down and ending with the last.  Runes with no code
+// are ignored.  Elements uses the same PARIS unit as Dit, so a message
+// sent with standard timing has the same length as from Duration.
+func (t Timing) Elements(message string) []Element {
+	unit := Dit(t.WPM)
+	charGap, wordGap := t.Gaps()
+
+	// Weight moves time from the gap after an element to the element
+	extra := time.Duration(0)
+	if t.Weight != 0 {
+		extra = unit * time.Duration(t.Weight-50) / 50
+	}
+
+	var elements []Element
+	gap := func(d time.Duration) {
+		if len(elements) > 0 {
+			elements = append(elements, Element{Down: false, Duration: d - extra})
+		}
+	}
+	for i, word := range strings.Fields(strings.ToUpper(message)) {
+		first := true
+		for _, r := range word {
+			code, ok := codes[r]
+			if !ok {
+				continue
+			}
+			switch {
+			case i > 0 && first:
+				gap(wordGap)
+			case !first:
+				gap(charGap)
+			}
+			first = false
+			for j, element := range code {
+				if j > 0 {
+					gap(ElementGap * unit)
+				}
+				length := DitUnits * unit
+				if element == '-' {
+					length = DahUnits * unit
+				}
+				elements = append(elements, Element{Down: true, Duration: length + extra})
+			}
+		}
+	}
+	return elements
+}
+
+// Gaps returns the gaps between characters and between words.
+func (t Timing) Gaps() (time.Duration, time.Duration) {
+	if t.Farnsworth > 0 && t.Farnsworth < t.WPM {
+		return farnsworthGaps(t.WPM, t.Farnsworth)
+	}
+	return CharGap * Dit(t.WPM), WordGap * Dit(t.WPM)
+}
+
+// farnsworthGaps returns the character and word gaps for Farnsworth
+// spacing using the ARRL formula for the total added delay.
+func farnsworthGaps(charWPM, overallWPM int) (time.Duration, time.Duration) {
+	c := float64(charWPM)
+	s := float64(overallWPM)
+	delay := (60*c - 37.2*s) / (s * c) * float64(time.Second)
+	return time.Duration(3 * delay / 19), time.Duration(7 * delay / 19)
+}
@@ -0,0 +1,171 @@
+package morse_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scottmcleodjr/rekl/morse"
+)
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		input  rune
+		want   string
+		wantOk bool
+	}{
+		{input: 'A', want: ".-", wantOk: true},
+		{input: '5', want: ".....", wantOk: true},
+		{input: '/', want: "-..-.", wantOk: true},
+		{input: 'a', want: "", wantOk: false}, // Only upper case is in the table
+		{input: '$', want: "", wantOk: false},
+	}
+
+	for _, test := range tests {
+		got, ok := morse.Code(test.input)
+		if got != test.want || ok != test.wantOk {
+			t.Errorf("got %q, %t, want %q, %t for rune %c", got, ok, test.want, test.wantOk, test.input)
+		}
+	}
+}
+
+func TestRune(t *testing.T) {
+	for _, r := range "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789.,?/=" {
+		code, _ := morse.Code(r)
+		got, ok := morse.Rune(code)
+		if got != r || !ok {
+			t.Errorf("got %c, %t, want %c, true for code %q", got, ok, r, code)
+		}
+	}
+
+	_, ok := morse.Rune("........")
+	if ok {
+		t.Error("got true, want false for unknown code")
+	}
+}
+
+func TestUnits(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{input: "PARIS", want: morse.ParisUnits - morse.WordGap}, // No trailing word space
+		{input: "paris paris", want: 2*morse.ParisUnits - morse.WordGap},
+		{input: "E", want: 1},
+		{input: "T", want: 3},
+		{input: "EE", want: 5},
+		{input: "  E   E  ", want: 9}, // Extra spaces are one word gap
+		{input: "MOE", want: 25},
+		{input: "", want: 0},
+	}
+
+	for _, test := range tests {
+		got := morse.Units(test.input)
+		if got != test.want {
+			t.Errorf("got %d, want %d units for %q", got, test.want, test.input)
+		}
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		input string
+		wpm   int
+		want  time.Duration
+	}{
+		{input: "E", wpm: 20, want: 60 * time.Millisecond},
+		{input: "T", wpm: 12, want: 300 * time.Millisecond},
+		{input: "PARIS", wpm: 20, want: 43 * 60 * time.Millisecond},
+	}
+
+	for _, test := range tests {
+		got := morse.Duration(test.input, test.wpm)
+		if got != test.want {
+			t.Errorf("got %s, want %s for %q at %d WPM", got, test.want, test.input, test.wpm)
+		}
+	}
+}
+
+func TestElements(t *testing.T) {
+	dit := morse.Dit(20)
+	tests := []struct {
+		input  string
+		timing morse.Timing
+		want   []morse.Element
+	}{
+		{
+			input:  "A",
+			timing: morse.Timing{WPM: 20},
+			want:   []morse.Element{{true, dit}, {false, dit}, {true, 3 * dit}},
+		},
+		{
+			input:  "E T",
+			timing: morse.Timing{WPM: 20, Weight: 50},
+			want:   []morse.Element{{true, dit}, {false, 7 * dit}, {true, 3 * dit}},
+		},
+		{
+			input:  "EE",
+			timing: morse.Timing{WPM: 20, Weight: 60}, // 10% of a dit moves to key down
+			want:   []morse.Element{{true, dit + dit/5}, {false, 3*dit - dit/5}, {true, dit + dit/5}},
+		},
+		{
+			input:  "EE",
+			timing: morse.Timing{WPM: 20, Farnsworth: 30}, // Faster than WPM is ignored
+			want:   []morse.Element{{true, dit}, {false, 3 * dit}, {true, dit}},
+		},
+	}
+
+	for _, test := range tests {
+		got := test.timing.Elements(test.input)
+		if len(got) != len(test.want) {
+			t.Errorf("got %v, want %v for %q with %+v", got, test.want, test.input, test.timing)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("got %v, want %v for %q with %+v", got, test.want, test.input, test.timing)
+				break
+			}
+		}
+	}
+}
+
+func TestElementsFarnsworth(t *testing.T) {
+	standard := totalDuration(morse.Timing{WPM: 18}.Elements("PARIS PARIS"))
+	farnsworth := totalDuration(morse.Timing{WPM: 18, Farnsworth: 10}.Elements("PARIS PARIS"))
+	if farnsworth <= standard {
+		t.Errorf("got %s, want longer than %s with Farnsworth spacing", farnsworth, standard)
+	}
+
+	// Key down time is the same, only the gaps change
+	if keyDown(morse.Timing{WPM: 18, Farnsworth: 10}.Elements("PARIS")) != keyDown(morse.Timing{WPM: 18}.Elements("PARIS")) {
+		t.Error("got different key down time, want the same with Farnsworth spacing")
+	}
+}
+
+func TestElementsMatchesDuration(t *testing.T) {
+	for _, message := range []string{"PARIS", "CQ TEST K3GDS", "5NN TU"} {
+		got := totalDuration(morse.Timing{WPM: 25, Weight: 50}.Elements(message))
+		want := morse.Duration(message, 25)
+		if got != want {
+			t.Errorf("got %s, want %s for %q", got, want, message)
+		}
+	}
+}
+
+func totalDuration(elements []morse.Element) time.Duration {
+	var total time.Duration
+	for _, element := range elements {
+		total += element.Duration
+	}
+	return total
+}
+
+func keyDown(elements []morse.Element) time.Duration {
+	var total time.Duration
+	for _, element := range elements {
+		if element.Down {
+			total += element.Duration
+		}
+	}
+	return total
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/handler"
+	"github.com/scottmcleodjr/rekl/morse"
+	"github.com/scottmcleodjr/rekl/paddle"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+// paddleFlags are the flags for keying from a straight key or paddle.
+type paddleFlags struct {
+	portName *string
+	ptyPath  *string
+	mode     *string
+}
+
+func addPaddleFlags(flags *flag.FlagSet) paddleFlags {
+	return paddleFlags{
+		portName: flags.String("paddle", "", "Serial port to read a straight key or paddle from, with dit on CTS and dah on DSR"),
+		ptyPath:  flags.String("paddle-pty", "", "Pseudo-terminal to read key states from for testing, as bytes '0' to '3'"),
+		mode:     flags.String("paddle-mode", "", "Keyer mode for a paddle, a, b or ultimatic, or empty for a straight key"),
+	}
+}
+
+// open opens the configured input, or returns nil if there is none.
+// open exits if the flags are invalid or opening fails.
+func (pf paddleFlags) open() paddle.Source {
+	if *pf.mode != "" {
+		_, err := paddle.ParseMode(*pf.mode)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	switch {
+	case *pf.portName != "" && *pf.ptyPath != "":
+		log.Fatal("only one of -paddle and -paddle-pty can be used")
+	case *pf.portName != "":
+		src, err := paddle.OpenSerial(*pf.portName)
+		if err != nil {
+			log.Fatalf("unable to open paddle: %s", err)
+		}
+		return src
+	case *pf.ptyPath != "":
+		file, err := os.Open(*pf.ptyPath)
+		if err != nil {
+			log.Fatalf("unable to open paddle: %s", err)
+		}
+		return paddle.NewStreamSource(file)
+	}
+	return nil
+}
+
+// resumeKeyer lets the keyer key again after a break in from the
+// paddle when a message is queued, once the keyer's queue is empty and
+// it has finished the character it was sending.
+type resumeKeyer struct {
+	handler.Keyer
+	shared     *paddle.Shared
+	queueEmpty func() bool
+	cfg        *config.Config
+}
+
+func (k resumeKeyer) QueueMessage(message string) error {
+	// Longer than a dah or the gap after an element, with some margin
+	k.shared.Resume(k.queueEmpty, 5*morse.Dit(k.cfg.Speed()))
+	return k.Keyer.QueueMessage(message)
+}
+
+// start keys key from src in the background, as a straight key or with
+// the keyer mode at the configured speed, until stop is closed.  Errors
+// are written to the event view.
+func (pf paddleFlags) start(src paddle.Source, key paddle.Key, cfg *config.Config, events handler.EventWriter, stop <-chan struct{}, breakIn func()) {
+	go func() {
+		var err error
+		if *pf.mode == "" {
+			err = paddle.Straight(src, key, paddle.DefaultPollInterval, stop, breakIn)
+		} else {
+			mode, _ := paddle.ParseMode(*pf.mode)
+			keyer := paddle.NewIambic(mode, cfg.Timing)
+			err = keyer.Run(src, key, paddle.DefaultPollInterval, stop, breakIn)
+		}
+		if err != nil {
+			events.WriteEvent(tui.LevelError, fmt.Sprintf("unable to key from the paddle: %s", err))
+		}
+	}()
+}
@@ -0,0 +1,223 @@
+package paddle
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/scottmcleodjr/rekl/morse"
+)
+
+// Mode is how a keyer makes elements from the paddle contacts.
+type Mode int
+
+const (
+	IambicA   Mode = iota // Alternates while squeezed and stops when released
+	IambicB               // Like IambicA, with one more element if released during an element
+	Ultimatic             // The last paddle pressed repeats while squeezed
+)
+
+var modeNames = []string{"a", "b", "ultimatic"}
+
+func (m Mode) String() string {
+	return modeNames[m]
+}
+
+// ParseMode returns the Mode for a name, "a", "b" or "ultimatic".
+func ParseMode(name string) (Mode, error) {
+	for i, modeName := range modeNames {
+		if strings.EqualFold(name, modeName) {
+			return Mode(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown keyer mode %q", name)
+}
+
+// Key is an interface of the cwkeyer.Key methods used by Run.
+type Key interface {
+	Down() error
+	Up() error
+}
+
+type element int
+
+const (
+	none element = iota
+	dit
+	dah
+)
+
+// Iambic is an electronic keyer making elements from paddle contacts.
+// Iambic is stepped with the contacts and the time since the last step,
+// so it is deterministic for a series of steps.
+type Iambic struct {
+	mode        Mode
+	timing      func() morse.Timing
+	current     element       // Element being sent or followed by the gap
+	last        element       // Last element sent
+	down        bool          // If the key is down for the current element
+	remaining   time.Duration // Left of the current element or gap
+	latched     element       // Element to send next from the paddle memory
+	lastPressed element       // Paddle pressed most recently, for Ultimatic
+	previous    Contacts
+}
+
+// NewIambic returns a new Iambic for a mode, with the speed and weight
+// from timing at the start of each element.
+func NewIambic(mode Mode, timing func() morse.Timing) *Iambic {
+	return &Iambic{mode: mode, timing: timing}
+}
+
+// Idle returns if the keyer has finished the element and gap in progress.
+func (k *Iambic) Idle() bool {
+	return k.current == none
+}
+
+// Step advances the keyer by elapsed with the contacts, and returns if the key is down.
+func (k *Iambic) Step(contacts Contacts, elapsed time.Duration) bool {
+	if contacts.Dit && !k.previous.Dit {
+		k.lastPressed = dit
+	}
+	if contacts.Dah && !k.previous.Dah {
+		k.lastPressed = dah
+	}
+	k.previous = contacts
+
+	// Paddle memory is set during the element and the gap after it
+	if k.current != none {
+		switch {
+		case k.mode == IambicB && k.current == dit && contacts.Dah:
+			k.latched = dah
+		case k.mode == IambicB && k.current == dah && contacts.Dit:
+			k.latched = dit
+		case k.mode == Ultimatic && k.lastPressed != k.current && k.lastPressed == pressed(contacts, k.lastPressed):
+			k.latched = k.lastPressed
+		}
+	}
+
+	k.remaining -= elapsed
+	for k.remaining <= 0 {
+		if k.down {
+			k.down = false
+			k.remaining += k.gap()
+			continue
+		}
+		next := k.next(contacts)
+		k.latched = none
+		k.current = next
+		if next == none {
+			k.remaining = 0
+			break
+		}
+		k.last = next
+		k.down = true
+		k.remaining += k.length(next)
+	}
+	return k.down
+}
+
+// pressed returns e if its contact is closed.
+func pressed(contacts Contacts, e element) element {
+	if (e == dit && contacts.Dit) || (e == dah && contacts.Dah) {
+		return e
+	}
+	return none
+}
+
+// next returns the element to send after the gap.
+func (k *Iambic) next(contacts Contacts) element {
+	switch {
+	case contacts.Dit && contacts.Dah && k.mode == Ultimatic:
+		return k.lastPressed
+	case contacts.Dit && contacts.Dah && k.last == dit:
+		return dah
+	case contacts.Dit && contacts.Dah:
+		return dit
+	case k.latched != none:
+		return k.latched
+	case contacts.Dit:
+		return dit
+	case contacts.Dah:
+		return dah
+	}
+	return none
+}
+
+func (k *Iambic) length(e element) time.Duration {
+	timing := k.timing()
+	units := time.Duration(morse.DitUnits)
+	if e == dah {
+		units = morse.DahUnits
+	}
+	return units*morse.Dit(timing.WPM) + k.extra(timing)
+}
+
+func (k *Iambic) gap() time.Duration {
+	timing := k.timing()
+	return morse.ElementGap*morse.Dit(timing.WPM) - k.extra(timing)
+}
+
+// extra is the time weight moves from each gap to the element before it.
+func (k *Iambic) extra(timing morse.Timing) time.Duration {
+	if timing.Weight == 0 {
+		return 0
+	}
+	return morse.Dit(timing.WPM) * time.Duration(timing.Weight-50) / 50
+}
+
+// Run keys key from the paddle in src, reading it every interval, until
+// stop is closed or reading fails.  Run calls breakIn when a paddle is
+// pressed while the keyer is idle, so queued messages can be stopped.
+func (k *Iambic) Run(src Source, key Key, interval time.Duration, stop <-chan struct{}, breakIn func()) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	down := false
+	for {
+		select {
+		case <-stop:
+			if down {
+				return key.Up()
+			}
+			return nil
+		case <-ticker.C:
+		}
+
+		contacts, err := src.Read()
+		if err != nil {
+			if down {
+				key.Up()
+			}
+			return err
+		}
+		if contacts.Down() && k.Idle() && breakIn != nil {
+			breakIn()
+		}
+		if k.Step(contacts, interval) == down {
+			continue
+		}
+		down = !down
+		if down {
+			err = key.Down()
+		} else {
+			err = key.Up()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Straight keys key from a straight key in src, reading it every interval,
+// until stop is closed or reading fails.  Straight calls breakIn when the
+// key goes down, so queued messages can be stopped.
+func Straight(src Source, key Key, interval time.Duration, stop <-chan struct{}, breakIn func()) error {
+	return Poll(src, interval, stop, func(contacts Contacts, _ time.Time) error {
+		if !contacts.Down() {
+			return key.Up()
+		}
+		if breakIn != nil {
+			breakIn()
+		}
+		return key.Down()
+	})
+}
@@ -0,0 +1,161 @@
+package paddle_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scottmcleodjr/rekl/morse"
+	"github.com/scottmcleodjr/rekl/paddle"
+)
+
+var (
+	up   = paddle.Contacts{}
+	dit  = paddle.Contacts{Dit: true}
+	dah  = paddle.Contacts{Dah: true}
+	both = paddle.Contacts{Dit: true, Dah: true}
+)
+
+// key runs a script through a keyer a millisecond at a time and returns
+// the elements keyed, as dots and dashes.
+func key(keyer *paddle.Iambic, script []paddle.SimStep) string {
+	src := paddle.NewSimSource(script)
+	var sb strings.Builder
+	downFor := 0
+	for {
+		contacts, err := src.Read()
+		if err == io.EOF {
+			contacts = up
+		}
+		if keyer.Step(contacts, time.Millisecond) {
+			downFor++
+			continue
+		}
+		switch {
+		case downFor > 2*int(morse.Dit(20)/time.Millisecond):
+			sb.WriteByte('-')
+		case downFor > 0:
+			sb.WriteByte('.')
+		}
+		downFor = 0
+		if err == io.EOF && keyer.Idle() {
+			return sb.String()
+		}
+	}
+}
+
+func TestIambic(t *testing.T) {
+	timing := func() morse.Timing { return morse.Timing{WPM: 20} } // 60ms dits
+
+	tests := []struct {
+		mode   paddle.Mode
+		script []paddle.SimStep
+		want   string
+	}{
+		{mode: paddle.IambicA, script: []paddle.SimStep{{dit, 200}}, want: ".."},
+		{mode: paddle.IambicA, script: []paddle.SimStep{{dah, 400}}, want: "--"},
+		{mode: paddle.IambicA, script: []paddle.SimStep{{dit, 10}, {up, 200}}, want: "."},
+		{mode: paddle.IambicA, script: []paddle.SimStep{{both, 300}}, want: ".-"},
+		{mode: paddle.IambicB, script: []paddle.SimStep{{both, 300}}, want: ".-."},
+		{mode: paddle.IambicA, script: []paddle.SimStep{{dah, 10}, {both, 300}}, want: "-."},
+		{mode: paddle.IambicB, script: []paddle.SimStep{{dit, 20}, {both, 20}, {up, 100}}, want: ".-"},
+		{mode: paddle.IambicA, script: []paddle.SimStep{{dit, 20}, {both, 20}, {up, 100}}, want: "."},
+		{mode: paddle.IambicA, script: []paddle.SimStep{{dit, 50}, {both, 350}}, want: ".-."},
+		{mode: paddle.Ultimatic, script: []paddle.SimStep{{dit, 50}, {both, 350}}, want: ".--"},
+		{mode: paddle.Ultimatic, script: []paddle.SimStep{{dah, 50}, {both, 350}}, want: "-.."},
+		{mode: paddle.Ultimatic, script: []paddle.SimStep{{dah, 20}, {both, 20}, {dah, 100}}, want: "-."},
+	}
+
+	for _, test := range tests {
+		got := key(paddle.NewIambic(test.mode, timing), test.script)
+		if got != test.want {
+			t.Errorf("got %q, want %q in mode %s for %v", got, test.want, test.mode, test.script)
+		}
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		want        paddle.Mode
+		errorWanted bool
+	}{
+		{name: "a", want: paddle.IambicA, errorWanted: false},
+		{name: "B", want: paddle.IambicB, errorWanted: false},
+		{name: "ultimatic", want: paddle.Ultimatic, errorWanted: false},
+		{name: "c", want: paddle.IambicA, errorWanted: true},
+	}
+
+	for _, test := range tests {
+		got, err := paddle.ParseMode(test.name)
+		if got != test.want {
+			t.Errorf("got %s, want %s for %q", got, test.want, test.name)
+		}
+		if test.errorWanted && (err == nil) {
+			t.Errorf("got nil, want error for %q", test.name)
+		}
+		if !test.errorWanted && (err != nil) {
+			t.Errorf("got error, want nil for %q", test.name)
+		}
+	}
+}
+
+// testKey records the key going down and up.
+type testKey struct {
+	keyed []bool
+}
+
+func (k *testKey) Down() error {
+	k.keyed = append(k.keyed, true)
+	return nil
+}
+
+func (k *testKey) Up() error {
+	k.keyed = append(k.keyed, false)
+	return nil
+}
+
+func TestIambicRun(t *testing.T) {
+	keyer := paddle.NewIambic(paddle.IambicA, func() morse.Timing { return morse.Timing{WPM: 40} })
+	src := paddle.NewSimSource([]paddle.SimStep{{up, 5}, {dit, 70}, {up, 50}})
+	key := &testKey{}
+	breakIns := 0
+
+	err := keyer.Run(src, key, time.Millisecond, nil, func() { breakIns++ })
+	if err != io.EOF {
+		t.Errorf("got %v, want EOF at the end of the script", err)
+	}
+	// A second 30ms dit starts 60ms into the 70ms press
+	want := []bool{true, false, true, false}
+	if len(key.keyed) != len(want) {
+		t.Fatalf("got %v keyed, want %v", key.keyed, want)
+	}
+	for i := range want {
+		if key.keyed[i] != want[i] {
+			t.Errorf("got %v keyed, want %v", key.keyed, want)
+			break
+		}
+	}
+	if breakIns != 1 {
+		t.Errorf("got %d break ins, want 1", breakIns)
+	}
+}
+
+func TestStraight(t *testing.T) {
+	src := paddle.NewSimSource([]paddle.SimStep{{up, 2}, {dah, 5}, {up, 5}, {dit, 5}, {up, 2}})
+	key := &testKey{}
+	breakIns := 0
+
+	err := paddle.Straight(src, key, time.Millisecond, nil, func() { breakIns++ })
+	if err != io.EOF {
+		t.Errorf("got %v, want EOF at the end of the script", err)
+	}
+	want := []bool{true, false, true, false}
+	if len(key.keyed) != len(want) || key.keyed[0] != want[0] || key.keyed[1] != want[1] {
+		t.Errorf("got %v keyed, want %v", key.keyed, want)
+	}
+	if breakIns != 2 {
+		t.Errorf("got %d break ins, want 2", breakIns)
+	}
+}
@@ -0,0 +1,187 @@
+package paddle
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+const DefaultPollInterval = time.Millisecond
+
+// Contacts is the state of the paddle contacts.  A straight key
+// uses either contact.
+type Contacts struct {
+	Dit bool
+	Dah bool
+}
+
+// Down returns if either contact is closed.
+func (c Contacts) Down() bool {
+	return c.Dit || c.Dah
+}
+
+// Source is a paddle or straight key input.
+type Source interface {
+	Read() (Contacts, error) // The current state of the contacts
+	Close() error
+}
+
+// SerialSource reads the contacts from serial port modem status lines,
+// with the dit contact on CTS and the dah contact on DSR.  The contacts
+// can be wired to DTR and RTS, which SerialSource holds high.
+type SerialSource struct {
+	port serial.Port
+}
+
+// OpenSerial opens a serial port for reading contacts.
+func OpenSerial(portName string) (*SerialSource, error) {
+	port, err := serial.Open(portName, &serial.Mode{BaudRate: 9600})
+	if err != nil {
+		return nil, err
+	}
+	err = port.SetDTR(true)
+	if err == nil {
+		err = port.SetRTS(true)
+	}
+	if err != nil {
+		port.Close()
+		return nil, err
+	}
+	return &SerialSource{port: port}, nil
+}
+
+// Read returns the current state of the contacts.
+func (s *SerialSource) Read() (Contacts, error) {
+	bits, err := s.port.GetModemStatusBits()
+	if err != nil {
+		return Contacts{}, err
+	}
+	return Contacts{Dit: bits.CTS, Dah: bits.DSR}, nil
+}
+
+// Close closes the serial port.
+func (s *SerialSource) Close() error {
+	return s.port.Close()
+}
+
+// StreamSource reads the contacts from a stream of bytes, like from a
+// pseudo-terminal for testing.  Each byte '0' to '3' sets the contacts,
+// with the dit contact in the low bit and the dah contact in the high
+// bit, and other bytes are ignored.
+type StreamSource struct {
+	mu       sync.Mutex
+	stream   io.ReadCloser
+	contacts Contacts
+	err      error
+}
+
+// NewStreamSource returns a StreamSource reading from stream in the background.
+func NewStreamSource(stream io.ReadCloser) *StreamSource {
+	s := &StreamSource{stream: stream}
+	go s.run()
+	return s
+}
+
+func (s *StreamSource) run() {
+	buf := make([]byte, 64)
+	for {
+		n, err := s.stream.Read(buf)
+		s.mu.Lock()
+		for _, b := range buf[:n] {
+			if b >= '0' && b <= '3' {
+				s.contacts = Contacts{Dit: (b-'0')&1 != 0, Dah: (b-'0')&2 != 0}
+			}
+		}
+		if err != nil {
+			s.err = err
+		}
+		s.mu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Read returns the last contacts in the stream, or the error that
+// ended the stream.
+func (s *StreamSource) Read() (Contacts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.contacts, s.err
+}
+
+// Close closes the stream.
+func (s *StreamSource) Close() error {
+	return s.stream.Close()
+}
+
+// SimStep is contacts held for a number of reads of a SimSource.
+type SimStep struct {
+	Contacts Contacts
+	Reads    int
+}
+
+// SimSource plays a script of contacts, one read at a time, for
+// deterministic tests.  Read returns io.EOF after the script.
+type SimSource struct {
+	mu     sync.Mutex
+	script []SimStep
+	reads  int // Reads of the current step
+}
+
+// NewSimSource returns a SimSource playing a script.
+func NewSimSource(script []SimStep) *SimSource {
+	return &SimSource{script: script}
+}
+
+// Read returns the contacts for the next read in the script.
+func (s *SimSource) Read() (Contacts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(s.script) > 0 && s.reads >= s.script[0].Reads {
+		s.script = s.script[1:]
+		s.reads = 0
+	}
+	if len(s.script) == 0 {
+		return Contacts{}, io.EOF
+	}
+	s.reads++
+	return s.script[0].Contacts, nil
+}
+
+// Close ends the script.
+func (s *SimSource) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.script = nil
+	return nil
+}
+
+// Poll reads src every interval and calls changed with the contacts and
+// the time whenever they change.  Poll returns when stop is closed or
+// when reading or changed fails.
+func Poll(src Source, interval time.Duration, stop <-chan struct{}, changed func(Contacts, time.Time) error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	last := Contacts{}
+	for {
+		select {
+		case <-stop:
+			return nil
+		case now := <-ticker.C:
+			contacts, err := src.Read()
+			if err != nil {
+				return err
+			}
+			if contacts != last {
+				last = contacts
+				err = changed(contacts, now)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
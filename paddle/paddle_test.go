@@ -0,0 +1,85 @@
+package paddle_test
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/scottmcleodjr/rekl/paddle"
+)
+
+func TestStreamSource(t *testing.T) {
+	tests := []struct {
+		input string
+		want  paddle.Contacts
+	}{
+		{input: "1", want: paddle.Contacts{Dit: true, Dah: false}},
+		{input: "2", want: paddle.Contacts{Dit: false, Dah: true}},
+		{input: "3", want: paddle.Contacts{Dit: true, Dah: true}},
+		{input: "x\n", want: paddle.Contacts{Dit: true, Dah: true}}, // Ignored
+		{input: "0", want: paddle.Contacts{Dit: false, Dah: false}},
+	}
+
+	reader, writer := io.Pipe()
+	source := paddle.NewStreamSource(reader)
+	for _, test := range tests {
+		writer.Write([]byte(test.input))
+		got, err := readEventually(source, test.want)
+		if err != nil {
+			t.Errorf("got error %s, want nil for input %q", err, test.input)
+		}
+		if got != test.want {
+			t.Errorf("got %+v, want %+v for input %q", got, test.want, test.input)
+		}
+	}
+
+	writer.Close()
+	_, err := readEventually(source, paddle.Contacts{Dit: true})
+	if err != io.EOF {
+		t.Errorf("got %v, want EOF after the stream ends", err)
+	}
+}
+
+// readEventually reads a source until it has the wanted contacts or an
+// error, or gives up after a while.
+func readEventually(source paddle.Source, want paddle.Contacts) (paddle.Contacts, error) {
+	var got paddle.Contacts
+	var err error
+	for i := 0; i < 100; i++ {
+		got, err = source.Read()
+		if got == want || err != nil {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return got, err
+}
+
+func TestPoll(t *testing.T) {
+	reader, writer := io.Pipe()
+	source := paddle.NewStreamSource(reader)
+	changes := make(chan paddle.Contacts, 10)
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- paddle.Poll(source, time.Millisecond, stop, func(contacts paddle.Contacts, _ time.Time) error {
+			changes <- contacts
+			return nil
+		})
+	}()
+
+	writer.Write([]byte("1"))
+	if got := <-changes; got != (paddle.Contacts{Dit: true}) {
+		t.Errorf("got %+v, want the dit contact", got)
+	}
+	writer.Write([]byte("0"))
+	if got := <-changes; got != (paddle.Contacts{}) {
+		t.Errorf("got %+v, want no contacts", got)
+	}
+
+	close(stop)
+	if err := <-done; err != nil {
+		t.Errorf("got error %s, want nil after stop", err)
+	}
+	writer.Close()
+}
@@ -0,0 +1,134 @@
+package paddle
+
+import (
+	"sync"
+	"time"
+)
+
+// Shared is a Key shared by the paddle and a keyer sending queued
+// messages, so one never keys over the other.  Breaking in silences the
+// keyer until Resume is called for a new message and the keyer has
+// finished what it was sending, and the paddle only
+// keys once an element the keyer had started has finished.
+type Shared struct {
+	mu         sync.Mutex
+	keyerUp    *sync.Cond // Broadcast when the keyer lets the key up
+	key        Key
+	keyerDown  bool      // If the keyer has the key down
+	paddleDown bool      // If the paddle has the key down
+	silenced   bool      // If the keyer's elements are dropped after a break in
+	keyerAt    time.Time // When the keyer last keyed down or up, even if dropped
+}
+
+// NewShared returns a Shared for key.
+func NewShared(key Key) *Shared {
+	s := &Shared{key: key}
+	s.keyerUp = sync.NewCond(&s.mu)
+	return s
+}
+
+// Keyer returns the Key for the keyer sending queued messages.  Its
+// elements are dropped while the keyer is silenced or the paddle has
+// the key down.
+func (s *Shared) Keyer() Key {
+	return sharedKeyer{s}
+}
+
+// Paddle returns the Key for the paddle.
+func (s *Shared) Paddle() Key {
+	return sharedPaddle{s}
+}
+
+// BreakIn silences the keyer and calls drain to stop the queued
+// messages, then waits for an element the keyer has started to finish.
+// BreakIn is the breakIn function for Run and Straight.
+func (s *Shared) BreakIn(drain func()) {
+	s.mu.Lock()
+	s.silenced = true
+	s.mu.Unlock()
+	drain()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.keyerDown {
+		s.keyerUp.Wait()
+	}
+}
+
+// Resume lets the keyer key again after a break in.  It is called when
+// a new message is queued, and waits for queueEmpty to return true and
+// the keyer to be quiet for longer than quiet, so the characters it was
+// still sending from the drained messages are not keyed in front of the
+// new one.  quiet must be longer than any element or gap in a character.
+func (s *Shared) Resume(queueEmpty func() bool, quiet time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.silenced {
+		// queueEmpty is called unlocked, as the keyer may be keying
+		s.mu.Unlock()
+		empty := queueEmpty()
+		s.mu.Lock()
+		if empty && !s.keyerDown && time.Since(s.keyerAt) > quiet {
+			s.silenced = false
+			return
+		}
+		s.mu.Unlock()
+		time.Sleep(DefaultPollInterval)
+		s.mu.Lock()
+	}
+}
+
+type sharedKeyer struct {
+	s *Shared
+}
+
+func (k sharedKeyer) Down() error {
+	k.s.mu.Lock()
+	defer k.s.mu.Unlock()
+	k.s.keyerAt = time.Now()
+	if k.s.silenced || k.s.paddleDown || k.s.keyerDown {
+		return nil
+	}
+	k.s.keyerDown = true
+	return k.s.key.Down()
+}
+
+func (k sharedKeyer) Up() error {
+	k.s.mu.Lock()
+	defer k.s.mu.Unlock()
+	k.s.keyerAt = time.Now()
+	if !k.s.keyerDown {
+		return nil
+	}
+	k.s.keyerDown = false
+	k.s.keyerUp.Broadcast()
+	return k.s.key.Up()
+}
+
+type sharedPaddle struct {
+	s *Shared
+}
+
+// Down waits for an element the keyer has started to finish, for
+// paddles that key without breaking in first.
+func (p sharedPaddle) Down() error {
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	for p.s.keyerDown {
+		p.s.keyerUp.Wait()
+	}
+	if p.s.paddleDown {
+		return nil
+	}
+	p.s.paddleDown = true
+	return p.s.key.Down()
+}
+
+func (p sharedPaddle) Up() error {
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	if !p.s.paddleDown {
+		return nil
+	}
+	p.s.paddleDown = false
+	return p.s.key.Up()
+}
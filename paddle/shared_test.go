@@ -0,0 +1,178 @@
+package paddle_test
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/scottmcleodjr/rekl/paddle"
+)
+
+// sharedTestKey is a Key that fails if it is keyed down twice or let up
+// twice, as when two senders key over each other.
+type sharedTestKey struct {
+	mu    sync.Mutex
+	down  bool
+	downs int
+}
+
+func (k *sharedTestKey) Down() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.down {
+		return errors.New("key down while down")
+	}
+	k.down = true
+	k.downs++
+	return nil
+}
+
+func (k *sharedTestKey) Up() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if !k.down {
+		return errors.New("key up while up")
+	}
+	k.down = false
+	return nil
+}
+
+func (k *sharedTestKey) state() (bool, int) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.down, k.downs
+}
+
+func TestSharedBreakIn(t *testing.T) {
+	key := &sharedTestKey{}
+	shared := paddle.NewShared(key)
+
+	// The keyer sends a long message that is not stopped by the drain, as
+	// cwkeyer finishes the character it is sending
+	stop := make(chan struct{})
+	keyerErrs := make(chan error, 1)
+	go func() {
+		keyerKey := shared.Keyer()
+		for {
+			select {
+			case <-stop:
+				keyerErrs <- nil
+				return
+			default:
+			}
+			if err := keyerKey.Down(); err != nil {
+				keyerErrs <- err
+				return
+			}
+			time.Sleep(3 * time.Millisecond)
+			if err := keyerKey.Up(); err != nil {
+				keyerErrs <- err
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	src := paddle.NewSimSource([]paddle.SimStep{{up, 20}, {dah, 10}, {up, 5}, {dit, 5}, {up, 5}})
+	var downsBefore int
+	breakIns := 0
+	err := paddle.Straight(src, shared.Paddle(), time.Millisecond, nil, func() {
+		shared.BreakIn(func() {})
+		breakIns++
+		if breakIns == 1 {
+			var down bool
+			down, downsBefore = key.state()
+			if down {
+				t.Error("got key down after breaking in, want the keyer's element finished")
+			}
+		}
+	})
+	if err != io.EOF {
+		t.Errorf("got %v, want EOF at the end of the script", err)
+	}
+
+	_, downs := key.state()
+	if downsBefore == 0 {
+		t.Error("got no elements from the keyer, want the break in partway through its message")
+	}
+	if downs-downsBefore != 2 {
+		t.Errorf("got %d elements after breaking in, want only the 2 from the paddle", downs-downsBefore)
+	}
+
+	// A new message lets the keyer key again
+	shared.Resume(func() bool { return true }, 0)
+	time.Sleep(10 * time.Millisecond)
+	close(stop)
+	if err := <-keyerErrs; err != nil {
+		t.Errorf("got keyer error %s, want none", err)
+	}
+	if _, after := key.state(); after == downs {
+		t.Error("got no elements from the keyer after resuming, want it keying again")
+	}
+}
+
+func TestSharedResume(t *testing.T) {
+	key := &sharedTestKey{}
+	shared := paddle.NewShared(key)
+
+	// The keyer sends characters from a queue like cwkeyer, finishing the
+	// character it is sending after the queue is drained
+	const unit = 2 * time.Millisecond
+	queue := make(chan string, 10)
+	done := make(chan error, 1)
+	go func() {
+		keyerKey := shared.Keyer()
+		for code := range queue {
+			for _, element := range code {
+				length := unit
+				if element == '-' {
+					length = 3 * unit
+				}
+				if err := keyerKey.Down(); err != nil {
+					done <- err
+					return
+				}
+				time.Sleep(length)
+				if err := keyerKey.Up(); err != nil {
+					done <- err
+					return
+				}
+				time.Sleep(unit)
+			}
+			time.Sleep(2 * unit)
+		}
+		done <- nil
+	}()
+	queueEmpty := func() bool { return len(queue) == 0 }
+	drain := func() {
+		for {
+			select {
+			case <-queue:
+			default:
+				return
+			}
+		}
+	}
+
+	queue <- "-----"
+	queue <- "-----"
+	for _, downs := key.state(); downs < 2; _, downs = key.state() {
+		time.Sleep(time.Millisecond)
+	}
+	shared.BreakIn(drain)
+	_, before := key.state()
+
+	// A message queued right after the break in is sent alone
+	shared.Resume(queueEmpty, 5*unit)
+	queue <- "."
+	queue <- "."
+	close(queue)
+	if err := <-done; err != nil {
+		t.Fatalf("got keyer error %s, want none", err)
+	}
+	if _, downs := key.state(); downs-before != 2 {
+		t.Errorf("got %d elements after resuming, want only the 2 of the new message", downs-before)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/scottmcleodjr/rekl/bus"
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/handler"
+	"github.com/scottmcleodjr/rekl/plain"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+// plainUI is the plain UI with its events written through the bus.
+type plainUI struct {
+	*plain.UI
+	events *bus.Bus
+}
+
+func (ui plainUI) WriteEvent(level tui.Level, message string) {
+	ui.Publish(bus.Message(level, message))
+}
+
+func (ui plainUI) Publish(e bus.Event) {
+	ui.events.From("plain").Publish(e)
+}
+
+// runPlain runs the interactive REKL with lines of input from stdin and
+// plain lines of events on stdout, for screen readers.  Hotkeys are run
+// with the press command, and the theme, layout and history are not used.
+func runPlain(flags *flag.FlagSet, configPath string, kf keyFlags, pf paddleFlags, af apiFlags, cf ctlFlags, tf transcriptFlags) {
+	ui := plain.New(os.Stdin, os.Stdout)
+	// Events go through the bus so web clients see them too
+	events := bus.New(ui)
+	eventUI := plainUI{UI: ui, events: events}
+	cfg := kf.newConfig()
+	loadConfigFile(flags, configPath, kf, cfg)
+	cfg.SetPath(configPath)
+	k, closeKeying := startKeying(kf, tf, pf, cfg, events)
+	defer closeKeying()
+	ui.WriteEvent(tui.LevelInfo, config.WelcomeText)
+	ui.WriteEvent(tui.LevelInfo, `Hotkeys are run with "\press KEY", like "\k F1" or "\k Esc".`)
+
+	af.start(k.send, events, cfg)
+	closeSocket := cf.start(k.send, eventUI, cfg)
+	defer closeSocket()
+
+	err := ui.Run(func(line string) {
+		handler.HandleLine(line, k.send, eventUI, cfg)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
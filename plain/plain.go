@@ -0,0 +1,172 @@
+package plain
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+// UI is a user interface of plain lines, for screen readers.  UI reads
+// lines of input and writes each event as a line with a UTC timestamp
+// and no colour.  UI is safe for use from any goroutine.
+type UI struct {
+	in       io.Reader
+	done     chan struct{}
+	stopOnce sync.Once
+
+	mu     sync.Mutex // Guards the fields below and writes to out
+	out    io.Writer
+	input  string
+	events tui.EventLog
+}
+
+// New returns a UI that reads input from in and writes events to out.
+func New(in io.Reader, out io.Writer) *UI {
+	return &UI{in: in, out: out, done: make(chan struct{})}
+}
+
+// Run calls handle with each line of input until the input ends or
+// StopApp is called.  Blank lines are skipped.
+func (ui *UI) Run(handle func(line string)) error {
+	lines := make(chan string)
+	errs := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(ui.in)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ui.done:
+				return
+			}
+		}
+		errs <- scanner.Err()
+	}()
+
+	for {
+		select {
+		case <-ui.done:
+			return nil
+		case err := <-errs:
+			return err
+		case line := <-lines:
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			ui.mu.Lock()
+			ui.input = line
+			ui.mu.Unlock()
+			handle(line)
+		}
+	}
+}
+
+// StopApp makes Run return.
+func (ui *UI) StopApp() {
+	ui.stopOnce.Do(func() { close(ui.done) })
+}
+
+// line returns an event as a line of output, with its UTC time and the
+// word for its level.  Blank lines around the message are left out.
+func line(e tui.Event) string {
+	message := strings.Trim(tui.StripTags(e.Message), "\n")
+	return e.Time.Format("15:04:05 ") + e.Level.Prefix() + message + "\n"
+}
+
+// WriteEvent writes a message as a line of output.
+func (ui *UI) WriteEvent(level tui.Level, message string) {
+	ui.WriteEventKind(level, "", message)
+}
+
+// WriteEventKind writes a message like WriteEvent, with the kind of
+// event it is for filtering, like "sent" or "error".  Events are kept
+// and picked by the event level and kinds as in the event view of the
+// TUI, and only the events picked are written.
+func (ui *UI) WriteEventKind(level tui.Level, kind string, message string) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	e, shown := ui.events.Add(level, kind, message)
+	if shown {
+		io.WriteString(ui.out, line(e))
+	}
+}
+
+// ClearEvents removes the events kept for searches and EventText.
+func (ui *UI) ClearEvents() {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	ui.events.Clear()
+}
+
+// InputText returns the line of input being handled.
+func (ui *UI) InputText() string {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	return ui.input
+}
+
+// ClearInputText clears the line of input being handled.
+func (ui *UI) ClearInputText() {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	ui.input = ""
+}
+
+// SetCurrentCall does nothing, as the commands that change the call
+// write it as an event.
+func (ui *UI) SetCurrentCall(call string) {}
+
+// SetKeyLabels does nothing, as the memories are listed with commands.
+func (ui *UI) SetKeyLabels(bank string, labels []tui.KeyLabel) {}
+
+// EventLevel returns the lowest Level of the events written.
+func (ui *UI) EventLevel() tui.Level {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	return ui.events.Level()
+}
+
+// SetEventLevel sets the lowest Level of the events written.
+func (ui *UI) SetEventLevel(level tui.Level) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	ui.events.SetLevel(level)
+}
+
+// SetEventKinds sets the kinds of events written, or writes every kind
+// if there are none.
+func (ui *UI) SetEventKinds(kinds []string) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	ui.events.SetKinds(kinds)
+}
+
+// SearchEvents writes the events kept again that contain the text,
+// ignoring case, and returns how many there are.  Empty text matches
+// nothing, as there is no search to end.
+func (ui *UI) SearchEvents(text string) int {
+	if text == "" {
+		return 0
+	}
+	text = strings.ToLower(text)
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	var found []string
+	for _, e := range ui.events.Shown() {
+		if strings.Contains(strings.ToLower(tui.StripTags(e.Message)), text) {
+			found = append(found, line(e))
+		}
+	}
+	io.WriteString(ui.out, strings.Join(found, ""))
+	return len(found)
+}
+
+// EventText returns the events kept that are picked by the event level
+// and kinds as plain text, a line for each with its UTC date and time.
+func (ui *UI) EventText() string {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	return ui.events.Text()
+}
@@ -0,0 +1,121 @@
+package plain_test
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/scottmcleodjr/rekl/plain"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+// timestamp matches the UTC time at the start of each line written.
+var timestamp = regexp.MustCompile(`(?m)^\d\d:\d\d:\d\d `)
+
+func TestWriteEvent(t *testing.T) {
+	tests := []struct {
+		level   tui.Level
+		kind    string
+		message string
+		want    string
+	}{
+		{level: tui.LevelInfo, kind: "sent", message: "Sending: CQ TEST", want: "Sending: CQ TEST\n"},
+		{level: tui.LevelError, message: "queue full", want: "Error: queue full\n"},
+		{level: tui.LevelWarn, message: "[::b]Slow[::-] down", want: "Warning: Slow down\n"},
+		{level: tui.LevelInfo, message: "\nCommands:\n\\help\n", want: "Commands:\n\\help\n"},
+		{level: tui.LevelDebug, message: "Key down.", want: ""}, // Below the event level
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		ui := plain.New(strings.NewReader(""), &out)
+		ui.WriteEventKind(test.level, test.kind, test.message)
+		got := timestamp.ReplaceAllString(out.String(), "")
+		if got != test.want {
+			t.Errorf("got %q, want %q for %q", got, test.want, test.message)
+		}
+		if out.Len() > 0 && !timestamp.MatchString(out.String()) {
+			t.Errorf("got %q, want a timestamp for %q", out.String(), test.message)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	var out bytes.Buffer
+	ui := plain.New(strings.NewReader(""), &out)
+	ui.SetEventLevel(tui.LevelDebug)
+	ui.SetEventKinds([]string{"sent"})
+	ui.WriteEventKind(tui.LevelInfo, "sent", "Sending: 5NN")
+	ui.WriteEventKind(tui.LevelDebug, "key", "Key down.")
+	ui.WriteEvent(tui.LevelError, "queue full")
+
+	got := timestamp.ReplaceAllString(out.String(), "")
+	if got != "Sending: 5NN\n" {
+		t.Errorf("got %q, want only the sent event", got)
+	}
+	ui.SetEventKinds(nil)
+	if n := strings.Count(ui.EventText(), "\n"); n != 3 {
+		t.Errorf("got %d events in %q, want 3 with every kind", n, ui.EventText())
+	}
+	ui.ClearEvents()
+	if ui.EventText() != "" {
+		t.Errorf("got %q, want no events after clearing", ui.EventText())
+	}
+}
+
+func TestSearchEvents(t *testing.T) {
+	var out bytes.Buffer
+	ui := plain.New(strings.NewReader(""), &out)
+	ui.WriteEvent(tui.LevelInfo, "Sending: CQ TEST K3GDS")
+	ui.WriteEvent(tui.LevelInfo, "Sending: 5NN 001")
+	ui.WriteEvent(tui.LevelInfo, "Sending: TU k3gds")
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "K3GDS", want: "Sending: CQ TEST K3GDS\nSending: TU k3gds\n"},
+		{text: "W1AW", want: ""},
+		{text: "", want: ""},
+	}
+
+	for _, test := range tests {
+		out.Reset()
+		n := ui.SearchEvents(test.text)
+		got := timestamp.ReplaceAllString(out.String(), "")
+		if got != test.want || n != strings.Count(test.want, "\n") {
+			t.Errorf("got %q and %d, want %q for %q", got, n, test.want, test.text)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	ui := plain.New(strings.NewReader("cq test\n\n  \n\\speed 30\n\\quit\n5nn\n"), &bytes.Buffer{})
+	var lines, inputs []string
+	err := ui.Run(func(line string) {
+		lines = append(lines, line)
+		inputs = append(inputs, ui.InputText())
+		if line == "\\quit" {
+			ui.StopApp()
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "cq test|\\speed 30|\\quit"
+	if got := strings.Join(lines, "|"); got != want {
+		t.Errorf("got lines %q, want %q", got, want)
+	}
+	if got := strings.Join(inputs, "|"); got != want {
+		t.Errorf("got input text %q, want %q", got, want)
+	}
+
+	// The input ending also stops the UI
+	ui = plain.New(strings.NewReader("cq test"), &bytes.Buffer{})
+	err = ui.Run(func(line string) {})
+	if err != nil {
+		t.Errorf("got error %s, want nil at the end of the input", err)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scottmcleodjr/cwkeyer"
+	"github.com/scottmcleodjr/rekl/fist"
+	"github.com/scottmcleodjr/rekl/handler"
+	"github.com/scottmcleodjr/rekl/history"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+const idleInterval = 50 * time.Millisecond // How often to check for the end of a word
+
+// runPractice runs sending practice from a straight key or paddle in the TUI.
+func runPractice(args []string) {
+	flags := flag.NewFlagSet("rekl practice", flag.ExitOnError)
+	keyFlags := addKeyFlags(flags, true)
+	inFlags := addPaddleFlags(flags)
+	flags.Parse(args)
+
+	src := inFlags.open()
+	if src == nil {
+		log.Fatal("a -paddle port or -paddle-pty path is required")
+	}
+	defer src.Close()
+	key := keyFlags.openKey()
+	cfg := keyFlags.newConfig()
+	keyer := cwkeyer.New(cfg, key)
+	ui := tui.New()
+	p := &practice{analyzer: fist.New(cfg), ui: ui}
+
+	commands := p.commands()
+	hist, _ := history.Load("", history.DefaultMax) // Kept in memory, which does not fail
+	editor := handler.NewEditor(ui, hist, cfg)
+	editor.SetCommands(commands)
+	ui.SetInputCapture(editor.Handler(commands.InputHandler(keyer, ui, cfg)))
+	ui.WriteEvent(tui.LevelInfo, "Send with your key.  Each word is shown with its timing against the set speed.")
+	ui.WriteEvent(tui.LevelInfo, `See the timing of everything sent with "\stats", and start over with "\reset".`)
+
+	go func() {
+		for {
+			err := keyer.ProcessSendQueue(false)
+			if err != nil {
+				ui.WriteEvent(tui.LevelError, err.Error())
+			}
+		}
+	}()
+
+	// The key gives the sidetone
+	stop := make(chan struct{})
+	defer close(stop)
+	inFlags.start(src, practiceKey{key: key, practice: p}, cfg, ui, stop, nil)
+	go func() {
+		ticker := time.NewTicker(idleInterval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			p.idle(now)
+		}
+	}()
+
+	err := ui.RunApp()
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// practiceKey is a Key that also feeds the practice analyzer.
+type practiceKey struct {
+	key      cwkeyer.Key
+	practice *practice
+}
+
+func (pk practiceKey) Down() error {
+	pk.practice.key(true, time.Now())
+	return pk.key.Down()
+}
+
+func (pk practiceKey) Up() error {
+	pk.practice.key(false, time.Now())
+	return pk.key.Up()
+}
+
+// practice feeds key input to the analyzer and shows the sent words.
+type practice struct {
+	mu       sync.Mutex
+	analyzer *fist.Analyzer
+	ui       *tui.TUI
+	word     strings.Builder
+}
+
+func (p *practice) key(down bool, at time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.write(p.analyzer.Key(down, at))
+}
+
+func (p *practice) idle(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.write(p.analyzer.Idle(now))
+}
+
+// write adds decoded text to the word in progress, and shows
+// the word and its timing when it ends.
+func (p *practice) write(text string) {
+	for _, r := range text {
+		if r != ' ' {
+			p.word.WriteRune(r)
+			continue
+		}
+		p.ui.WriteEvent(tui.LevelInfo, fmt.Sprintf("Sent %s: %s", p.word.String(), p.analyzer.WordStats()))
+		p.word.Reset()
+	}
+}
+
+// commands returns the built-in commands with the practice commands for
+// the timing stats.
+func (p *practice) commands() *handler.Registry {
+	r := handler.DefaultCommands()
+	commands := []handler.Command{
+		{Name: "stats", Help: "Display the timing of everything sent", Run: func(c handler.Context, _ string) {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			c.UI.WriteEvent(tui.LevelInfo, fmt.Sprintf("Timing of everything sent: %s", p.analyzer.Stats()))
+			c.UI.ClearInputText()
+		}},
+		{Name: "reset", Help: "Clear the timing stats", Run: func(c handler.Context, _ string) {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			p.analyzer.Reset()
+			c.UI.WriteEvent(tui.LevelInfo, "Timing stats cleared.")
+			c.UI.ClearInputText()
+		}},
+	}
+	for _, cmd := range commands {
+		err := r.Register(cmd)
+		if err != nil {
+			panic(err) // The practice commands are fixed, so this is a bug
+		}
+	}
+	return r
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/decode"
+	"github.com/scottmcleodjr/rekl/handler"
+	"github.com/scottmcleodjr/rekl/receive"
+	"github.com/scottmcleodjr/rekl/tui"
+	"github.com/scottmcleodjr/rekl/wav"
+)
+
+// receiveFlags are the flags for decoding received CW into the TUI.
+type receiveFlags struct {
+	path       *string
+	sampleRate *int
+	tone       *int
+	bandwidth  *int
+}
+
+func addReceiveFlags(flags *flag.FlagSet) receiveFlags {
+	return receiveFlags{
+		path:       flags.String("rx", "", "Path of a raw 16 bit mono PCM stream, like a FIFO from arecord, to decode into the received pane"),
+		sampleRate: flags.Int("rx-rate", wav.DefaultSampleRate, "Sample rate of the received PCM stream"),
+		tone:       flags.Int("rx-tone", wav.DefaultTone, "Tone frequency of the received CW in Hz"),
+		bandwidth:  flags.Int("rx-bandwidth", decode.DefaultBandwidth, "Detection bandwidth for the received CW in Hz"),
+	}
+}
+
+// start shows the received pane and decodes the stream into it in the
+// background.  start exits if the flags are invalid, and errors after
+// that are written to events.
+func (rf receiveFlags) start(ui *tui.TUI, events handler.EventWriter) *receive.Receiver {
+	decoder, err := decode.New(*rf.sampleRate, *rf.tone, *rf.bandwidth)
+	if err != nil {
+		log.Fatalf("unable to decode received audio: %s", err)
+	}
+	receiver := receive.New(decoder, ui)
+	ui.ShowReceivePane()
+
+	go func() {
+		// Opening a FIFO blocks until there is a writer
+		file, err := os.Open(*rf.path)
+		if err != nil {
+			events.WriteEvent(tui.LevelError, fmt.Sprintf("unable to open received audio: %s", err))
+			return
+		}
+		defer file.Close()
+		err = receiver.Run(file)
+		if err != nil {
+			events.WriteEvent(tui.LevelError, fmt.Sprintf("unable to read received audio: %s", err))
+			return
+		}
+		events.WriteEvent(tui.LevelInfo, "Received audio stream ended.")
+	}()
+	return receiver
+}
+
+// grabCallsign sets the current call to the last callsign in the received text.
+func grabCallsign(receiver *receive.Receiver, ui handler.UserInterface, cfg *config.Config) {
+	call := receiver.LastCallsign()
+	if call == "" {
+		ui.WriteEvent(tui.LevelError, "no callsign in the received text")
+		return
+	}
+	handler.SetCurrentCall(ui, cfg, call)
+}
@@ -0,0 +1,110 @@
+package receive
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/scottmcleodjr/rekl/decode"
+)
+
+const (
+	blockSamples = 256  // Samples read from the source at a time
+	keepRunes    = 1000 // Received text kept for finding callsigns
+)
+
+var callsignPattern = regexp.MustCompile(`^([A-Z0-9]{1,3}/)?[A-Z0-9]{1,3}[0-9][A-Z0-9]{0,3}[A-Z](/[A-Z0-9]{1,4})?$`)
+
+// Pane is where a Receiver shows decoded text and the estimated speed.
+type Pane interface {
+	WriteReceived(text string)
+	SetReceivedWPM(wpm int)
+}
+
+// Receiver decodes CW from a PCM audio stream into a Pane and keeps
+// the recent text so callsigns can be picked out of it.
+type Receiver struct {
+	decoder *decode.Decoder
+	pane    Pane
+	mu      sync.Mutex
+	text    []rune
+}
+
+// New returns a new Receiver.
+func New(decoder *decode.Decoder, pane Pane) *Receiver {
+	return &Receiver{decoder: decoder, pane: pane}
+}
+
+// Run decodes raw 16 bit little endian mono PCM from src until it ends.
+// Run returns nil at the end of src, or the error if reading fails.
+func (r *Receiver) Run(src io.Reader) error {
+	samples := make([]int16, blockSamples)
+	wpm := 0
+	for {
+		err := binary.Read(src, binary.LittleEndian, samples)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			r.write(r.decoder.Flush())
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		r.write(r.decoder.Write(samples))
+		if newWPM := r.decoder.WPM(); newWPM != wpm {
+			wpm = newWPM
+			r.pane.SetReceivedWPM(wpm)
+		}
+	}
+}
+
+func (r *Receiver) write(chars []decode.Char) {
+	if len(chars) == 0 {
+		return
+	}
+	var sb strings.Builder
+	for _, char := range chars {
+		sb.WriteRune(char.Rune)
+	}
+
+	r.mu.Lock()
+	r.text = append(r.text, []rune(sb.String())...)
+	if len(r.text) > keepRunes {
+		r.text = r.text[len(r.text)-keepRunes:]
+	}
+	r.mu.Unlock()
+
+	r.pane.WriteReceived(sb.String())
+}
+
+// Text returns the recently received text.
+func (r *Receiver) Text() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return string(r.text)
+}
+
+// LastCallsign returns the most recent callsign in the received
+// text, or an empty string if there is none.
+func (r *Receiver) LastCallsign() string {
+	callsigns := Callsigns(r.Text())
+	if len(callsigns) == 0 {
+		return ""
+	}
+	return callsigns[len(callsigns)-1]
+}
+
+// Callsigns returns the words in text that look like callsigns,
+// in the order they appear.
+func Callsigns(text string) []string {
+	var callsigns []string
+	for _, word := range strings.Fields(strings.ToUpper(text)) {
+		if callsignPattern.MatchString(word) {
+			callsigns = append(callsigns, word)
+		}
+	}
+	return callsigns
+}
@@ -0,0 +1,76 @@
+package receive_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scottmcleodjr/rekl/decode"
+	"github.com/scottmcleodjr/rekl/morse"
+	"github.com/scottmcleodjr/rekl/receive"
+	"github.com/scottmcleodjr/rekl/wav"
+)
+
+func TestCallsigns(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{input: "CQ CQ DE K3GDS K", want: []string{"K3GDS"}},
+		{input: "W1AW 5NN 599 TU 73", want: []string{"W1AW"}},
+		{input: "2E0ABC DE VE3/K3GDS/P", want: []string{"2E0ABC", "VE3/K3GDS/P"}},
+		{input: "k1abc de 9a1a", want: []string{"K1ABC", "9A1A"}},
+		{input: "QRZ R5 FD 1A CQ", want: nil},
+	}
+
+	for _, test := range tests {
+		got := receive.Callsigns(test.input)
+		if strings.Join(got, ",") != strings.Join(test.want, ",") {
+			t.Errorf("got %q, want %q for %q", got, test.want, test.input)
+		}
+	}
+}
+
+func TestReceiver(t *testing.T) {
+	settings := wav.DefaultSettings()
+	silence := morse.Element{Down: false, Duration: 500 * time.Millisecond}
+	elements := append([]morse.Element{silence}, morse.Timing{WPM: 24}.Elements("CQ DE K3GDS W1AW K")...)
+	samples := wav.Render(append(elements, silence), settings)
+
+	var pcm bytes.Buffer
+	binary.Write(&pcm, binary.LittleEndian, samples)
+
+	decoder, _ := decode.New(settings.SampleRate, settings.Tone, decode.DefaultBandwidth)
+	pane := &testPane{}
+	receiver := receive.New(decoder, pane)
+	err := receiver.Run(&pcm)
+	if err != nil {
+		t.Fatalf("got error %q, want nil at end of stream", err)
+	}
+
+	if got := strings.TrimSpace(pane.text.String()); got != "CQ DE K3GDS W1AW K" {
+		t.Errorf("got pane text %q, want %q", got, "CQ DE K3GDS W1AW K")
+	}
+	if pane.wpm < 22 || pane.wpm > 26 {
+		t.Errorf("got %d WPM, want 24 +/- 2", pane.wpm)
+	}
+	if got := receiver.LastCallsign(); got != "W1AW" {
+		t.Errorf("got last callsign %q, want %q", got, "W1AW")
+	}
+}
+
+// testPane records what a Receiver shows.
+type testPane struct {
+	text bytes.Buffer
+	wpm  int
+}
+
+func (p *testPane) WriteReceived(text string) {
+	p.text.WriteString(text)
+}
+
+func (p *testPane) SetReceivedWPM(wpm int) {
+	p.wpm = wpm
+}
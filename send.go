@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/scottmcleodjr/cwkeyer"
+	"github.com/scottmcleodjr/rekl/handler"
+)
+
+// runSend sends the text in the arguments, or on stdin if there are no
+// arguments, and exits once the keyer send queue is empty.
+func runSend(args []string) {
+	flags := flag.NewFlagSet("rekl send", flag.ExitOnError)
+	keyFlags := addKeyFlags(flags, false)
+	flags.Parse(args)
+
+	text := strings.Join(flags.Args(), " ")
+	if flags.NArg() == 0 {
+		input, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			log.Fatalf("unable to read stdin: %s", err)
+		}
+		text = string(input)
+	}
+
+	// Newlines from stdin are sent as word spaces
+	message, err := handler.ValidateMessage(strings.Join(strings.Fields(text), " "))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if message == "" {
+		log.Fatal("nothing to send")
+	}
+
+	key := keyFlags.openKey()
+	cfg := keyFlags.newConfig()
+	keyer := cwkeyer.New(cfg, key)
+	err = keyer.QueueMessage(message)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = keyer.ProcessSendQueue(true)
+	if err != nil {
+		log.Fatalf("unable to send: %s", err)
+	}
+}
@@ -0,0 +1,47 @@
+package sim
+
+import (
+	"time"
+
+	"github.com/scottmcleodjr/rekl/morse"
+)
+
+// Queue is an interface of the cwkeyer.Keyer methods used by Keyer.
+type Queue interface {
+	QueueMessage(message string) error
+	DrainSendQueue()
+}
+
+// Keyer passes messages to a keyer and sends them to a Sim, so the
+// callers hear what is sent through the usual REKL input handler.
+type Keyer struct {
+	queue  Queue
+	sim    *Sim
+	timing func() morse.Timing
+}
+
+// NewKeyer returns a new Keyer that sends messages to queue and sim with
+// the timing at the time they are queued.
+func NewKeyer(queue Queue, sim *Sim, timing func() morse.Timing) *Keyer {
+	return &Keyer{queue: queue, sim: sim, timing: timing}
+}
+
+// QueueMessage queues a message in the keyer and sends it to the callers.
+func (k *Keyer) QueueMessage(message string) error {
+	err := k.queue.QueueMessage(message)
+	if err != nil {
+		return err
+	}
+	var duration time.Duration
+	for _, element := range k.timing().Elements(message) {
+		duration += element.Duration
+	}
+	k.sim.Send(message, duration)
+	return nil
+}
+
+// DrainSendQueue stops the keyer and the replies to what it was sending.
+func (k *Keyer) DrainSendQueue() {
+	k.queue.DrainSendQueue()
+	k.sim.Abort()
+}
@@ -0,0 +1,111 @@
+package sim
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scottmcleodjr/rekl/train"
+)
+
+// QSO is a contact completed in the simulation.
+type QSO struct {
+	Call   string
+	Serial int
+}
+
+// Entry is a contact we logged.
+type Entry struct {
+	Call   string
+	Serial int
+}
+
+// Log adds a contact to our log from a callsign and serial number.
+func (s *Sim) Log(call, serial string) (Entry, error) {
+	number, err := strconv.Atoi(serial)
+	if err != nil || number < 1 {
+		return Entry{}, fmt.Errorf("unable to parse serial number %q", serial)
+	}
+	entry := Entry{Call: strings.ToUpper(call), Serial: number}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.log = append(s.log, entry)
+	return entry, nil
+}
+
+// Score is the result of a simulation.
+type Score struct {
+	Completed     int // QSOs completed on the air
+	Logged        int // Entries in our log
+	Correct       int // Entries matching a completed QSO
+	BustedCalls   int // Entries with a wrong callsign
+	BustedSerials int // Entries with the right callsign and a wrong serial number
+	NotInLog      int // Entries with no QSO
+	Unlogged      int // Completed QSOs not in our log
+	Elapsed       time.Duration
+}
+
+// Rate returns the correct QSOs per hour.
+func (sc Score) Rate() int {
+	if sc.Elapsed <= 0 {
+		return 0
+	}
+	return int(float64(sc.Correct) / sc.Elapsed.Hours())
+}
+
+func (sc Score) String() string {
+	return fmt.Sprintf("%d correct of %d logged and %d completed in %s, %d per hour.  "+
+		"Busted calls %d, busted serials %d, not in log %d, unlogged %d.",
+		sc.Correct, sc.Logged, sc.Completed, sc.Elapsed.Round(time.Second), sc.Rate(),
+		sc.BustedCalls, sc.BustedSerials, sc.NotInLog, sc.Unlogged)
+}
+
+// Score checks our log against the completed QSOs.  Each QSO matches
+// at most one entry, so a duplicate entry is not in the log.
+func (s *Sim) Score() Score {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	score := Score{Completed: len(s.completed), Logged: len(s.log), Elapsed: s.duration(s.position)}
+	matched := make([]bool, len(s.completed))
+	match := func(same func(QSO) bool) bool {
+		for i, qso := range s.completed {
+			if !matched[i] && same(qso) {
+				matched[i] = true
+				return true
+			}
+		}
+		return false
+	}
+
+	// Exact matches go first, so a busted entry does not take the QSO
+	// of a correct entry logged after it
+	scored := make([]bool, len(s.log))
+	for i, entry := range s.log {
+		if match(func(qso QSO) bool { return qso == QSO(entry) }) {
+			score.Correct++
+			scored[i] = true
+		}
+	}
+	for i, entry := range s.log {
+		switch {
+		case scored[i]:
+		case match(func(qso QSO) bool { return qso.Call == entry.Call }):
+			score.BustedSerials++
+		case match(func(qso QSO) bool {
+			return len(qso.Call) == len(entry.Call) && train.Compare(qso.Call, entry.Call).Correct == len(qso.Call)-1
+		}):
+			score.BustedCalls++
+		default:
+			score.NotInLog++
+		}
+	}
+	for _, m := range matched {
+		if !m {
+			score.Unlogged++
+		}
+	}
+	return score
+}
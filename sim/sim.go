@@ -0,0 +1,352 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scottmcleodjr/rekl/morse"
+	"github.com/scottmcleodjr/rekl/train"
+	"github.com/scottmcleodjr/rekl/wav"
+)
+
+const (
+	DefaultCallers = 3    // Most stations calling at once
+	DefaultNoise   = 1500 // Noise amplitude, around a quarter of a weak signal
+	DefaultSpread  = 300  // Hz either side of the tone the callers are spread over
+	DefaultMinWPM  = 18
+	DefaultMaxWPM  = 32
+	patience       = 4 // Unanswered calls before a caller gives up
+)
+
+// Settings are the settings for a simulated contest.
+type Settings struct {
+	SampleRate int
+	Tone       int // Center pitch of the callers in Hz
+	Spread     int // Pitch spread either side of the tone in Hz
+	MinWPM     int
+	MaxWPM     int
+	Callers    int // Most stations calling at once
+	Noise      int // Noise amplitude
+}
+
+// DefaultSettings returns the default settings.
+func DefaultSettings() Settings {
+	return Settings{
+		SampleRate: wav.DefaultSampleRate,
+		Tone:       wav.DefaultTone,
+		Spread:     DefaultSpread,
+		MinWPM:     DefaultMinWPM,
+		MaxWPM:     DefaultMaxWPM,
+		Callers:    DefaultCallers,
+		Noise:      DefaultNoise,
+	}
+}
+
+// Validate returns an error if the settings can not be simulated.
+func (s Settings) Validate() error {
+	switch {
+	case s.SampleRate <= 0:
+		return errors.New("sample rate must be positive")
+	case s.Spread < 0 || s.Tone-s.Spread <= 0 || s.Tone+s.Spread >= s.SampleRate/2:
+		return errors.New("tone spread must be positive and below half the sample rate")
+	case s.MinWPM <= 0 || s.MaxWPM < s.MinWPM:
+		return errors.New("speed range must be positive with the minimum at most the maximum")
+	case s.Callers < 1:
+		return errors.New("there must be at least one caller")
+	case s.Noise < 0:
+		return errors.New("noise must not be negative")
+	}
+	return nil
+}
+
+// station is a simulated caller.
+type station struct {
+	call         string
+	serial       int
+	pitch        int
+	wpm          int
+	strength     float64 // Signal level from 0 to 1
+	unanswered   int     // Calls since the station was last answered
+	sentExchange bool    // If the station has sent its exchange to us
+	gotExchange  bool    // If we have sent our exchange to the station
+}
+
+// signal is a station transmission in the audio.
+type signal struct {
+	start   int64 // Sample position the signal starts at
+	samples []int16
+}
+
+// Sim is a simulated contest pileup answering our CQs.  Callers answer
+// a CQ or a TU with their callsigns, send their exchange of 5NN and a
+// serial number when we send their callsign, and repeat themselves when
+// we send a partial call with '?' or AGN.  A QSO is complete when we send
+// TU after both exchanges.  Sim is safe for concurrent use.
+type Sim struct {
+	mu        sync.Mutex
+	settings  Settings
+	calls     *train.CallGenerator
+	rng       *rand.Rand
+	position  int64 // Samples rendered
+	busyUntil int64 // Sample position our transmissions end at
+	callers   []*station
+	current   *station // Caller we are working
+	signals   []signal
+	completed []QSO
+	log       []Entry
+}
+
+// New returns a new Sim with callers from calls.
+func New(settings Settings, calls *train.CallGenerator, rng *rand.Rand) (*Sim, error) {
+	err := settings.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return &Sim{settings: settings, calls: calls, rng: rng}, nil
+}
+
+// Elapsed returns the time simulated so far.
+func (s *Sim) Elapsed() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.duration(s.position)
+}
+
+// Callers returns the callsigns of the stations calling.
+func (s *Sim) Callers() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	calls := make([]string, 0, len(s.callers))
+	for _, caller := range s.callers {
+		calls = append(calls, caller.call)
+	}
+	return calls
+}
+
+// Render fills samples with the next audio of the simulation, which is
+// the noise and all the callers mixed together.
+func (s *Sim) Render(samples []int16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	end := s.position + int64(len(samples))
+	mixed := make([]float64, len(samples))
+	if s.settings.Noise > 0 {
+		for i := range mixed {
+			mixed[i] = s.rng.NormFloat64() * float64(s.settings.Noise)
+		}
+	}
+	remaining := s.signals[:0]
+	for _, sig := range s.signals {
+		for i := range mixed {
+			j := s.position + int64(i) - sig.start
+			if j >= 0 && j < int64(len(sig.samples)) {
+				mixed[i] += float64(sig.samples[j])
+			}
+		}
+		if sig.start+int64(len(sig.samples)) > end {
+			remaining = append(remaining, sig)
+		}
+	}
+	s.signals = remaining
+
+	for i, sample := range mixed {
+		samples[i] = int16(math.Max(math.MinInt16, math.Min(math.MaxInt16, sample)))
+	}
+	s.position = end
+}
+
+// Send sends our text, which takes duration to key, to the callers.
+// Send is called when the text is queued, so the text starts after any
+// of ours still sending, and the replies start after it ends.
+func (s *Sim) Send(text string, duration time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := s.busyUntil
+	if start < s.position {
+		start = s.position
+	}
+	end := start + s.samples(duration)
+	s.busyUntil = end
+
+	words := strings.Fields(strings.ToUpper(text))
+	hasCQ, hasTU, hasExchange, hasQuery := false, false, false, false
+	var partials []string
+	for _, word := range words {
+		_, err := strconv.Atoi(word)
+		switch {
+		case word == "CQ":
+			hasCQ = true
+		case word == "TU" || word == "TNX":
+			hasTU = true
+		case word == "5NN" || word == "599" || err == nil:
+			hasExchange = true
+		case word == "AGN" || word == "?":
+			hasQuery = true
+		case strings.Contains(word, "?"):
+			hasQuery = true
+			partials = append(partials, strings.ReplaceAll(word, "?", ""))
+		}
+	}
+
+	// TU finishes with the station we are working first, so the
+	// same message can go on to the next station
+	if hasTU && s.current != nil {
+		if !s.current.gotExchange {
+			s.reply(s.current, "NR?", end)
+			return
+		}
+		s.completed = append(s.completed, QSO{Call: s.current.call, Serial: s.current.serial})
+		s.remove(s.current)
+		s.current = nil
+	}
+
+	if addressed := s.find(words); addressed != nil {
+		s.current = addressed
+		addressed.unanswered = 0
+		addressed.gotExchange = addressed.gotExchange || hasExchange
+		addressed.sentExchange = true
+		s.reply(addressed, fmt.Sprintf("5NN %d", addressed.serial), end)
+		return
+	}
+	if busted := s.findBusted(words); busted != nil {
+		s.reply(busted, busted.call+" "+busted.call, end)
+		return
+	}
+
+	switch {
+	case len(partials) > 0:
+		for _, caller := range s.callers {
+			for _, partial := range partials {
+				if partial != "" && strings.Contains(caller.call, partial) {
+					s.reply(caller, caller.call, end)
+					break
+				}
+			}
+		}
+	case s.current != nil && hasQuery && s.current.sentExchange:
+		s.reply(s.current, fmt.Sprintf("5NN %d", s.current.serial), end)
+	case s.current != nil && hasExchange:
+		s.current.gotExchange = true
+	case hasCQ || hasTU || hasQuery:
+		s.call(hasCQ, end)
+	}
+}
+
+// Abort stops our transmissions, and the replies to them that have
+// not started are not sent.
+func (s *Sim) Abort() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	remaining := s.signals[:0]
+	for _, sig := range s.signals {
+		if sig.start < s.position {
+			remaining = append(remaining, sig)
+		}
+	}
+	s.signals = remaining
+	s.busyUntil = s.position
+}
+
+// call has the callers call us after from, with new callers joining and
+// callers that have waited too long leaving.  A CQ always gets a caller.
+func (s *Sim) call(cq bool, from int64) {
+	waiting := s.callers[:0]
+	for _, caller := range s.callers {
+		if caller.unanswered < patience {
+			waiting = append(waiting, caller)
+		}
+	}
+	s.callers = waiting
+
+	joining := s.rng.Intn(s.settings.Callers - len(s.callers) + 1)
+	if cq && joining == 0 && len(s.callers) == 0 {
+		joining = 1
+	}
+	for i := 0; i < joining; i++ {
+		s.callers = append(s.callers, s.newStation())
+	}
+
+	for _, caller := range s.callers {
+		caller.unanswered++
+		s.reply(caller, caller.call, from)
+	}
+}
+
+func (s *Sim) newStation() *station {
+	call := s.calls.Next()
+	for s.find([]string{call}) != nil {
+		call = s.calls.Next()
+	}
+	return &station{
+		call:     call,
+		serial:   1 + s.rng.Intn(500),
+		pitch:    s.settings.Tone - s.settings.Spread + s.rng.Intn(2*s.settings.Spread+1),
+		wpm:      s.settings.MinWPM + s.rng.Intn(s.settings.MaxWPM-s.settings.MinWPM+1),
+		strength: 0.3 + 0.7*s.rng.Float64(),
+	}
+}
+
+// reply adds a station transmission starting a short reaction time after from.
+func (s *Sim) reply(st *station, text string, from int64) {
+	delay := 200*time.Millisecond + time.Duration(s.rng.Intn(500))*time.Millisecond
+	elements := morse.Timing{WPM: st.wpm}.Elements(text)
+	samples := wav.Render(elements, wav.Settings{Tone: st.pitch, SampleRate: s.settings.SampleRate, Rise: wav.DefaultRise})
+	for i := range samples {
+		samples[i] = int16(float64(samples[i]) * st.strength)
+	}
+	s.signals = append(s.signals, signal{start: from + s.samples(delay), samples: samples})
+}
+
+// find returns the caller with a callsign in words, or nil if there is none.
+func (s *Sim) find(words []string) *station {
+	for _, word := range words {
+		for _, caller := range s.callers {
+			if word == caller.call {
+				return caller
+			}
+		}
+	}
+	return nil
+}
+
+// findBusted returns a caller with a callsign one character different
+// from a word, or nil if there is none.  Partial calls are not busted.
+func (s *Sim) findBusted(words []string) *station {
+	for _, word := range words {
+		if strings.Contains(word, "?") {
+			continue
+		}
+		for _, caller := range s.callers {
+			if len(word) == len(caller.call) && train.Compare(caller.call, word).Correct == len(word)-1 {
+				return caller
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Sim) remove(st *station) {
+	for i, caller := range s.callers {
+		if caller == st {
+			s.callers = append(s.callers[:i], s.callers[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *Sim) samples(d time.Duration) int64 {
+	return int64(d.Seconds() * float64(s.settings.SampleRate))
+}
+
+func (s *Sim) duration(samples int64) time.Duration {
+	return time.Duration(samples) * time.Second / time.Duration(s.settings.SampleRate)
+}
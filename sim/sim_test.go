@@ -0,0 +1,202 @@
+package sim_test
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scottmcleodjr/rekl/decode"
+	"github.com/scottmcleodjr/rekl/morse"
+	"github.com/scottmcleodjr/rekl/sim"
+	"github.com/scottmcleodjr/rekl/train"
+	"github.com/scottmcleodjr/rekl/wav"
+)
+
+// listener decodes the sim audio, which is easy with one caller at a
+// fixed pitch and no noise.
+type listener struct {
+	t       *testing.T
+	sim     *sim.Sim
+	decoder *decode.Decoder
+}
+
+func newListener(t *testing.T, seed int64) *listener {
+	settings := sim.DefaultSettings()
+	settings.Spread = 0
+	settings.Callers = 1
+	settings.Noise = 0
+	settings.MinWPM, settings.MaxWPM = 25, 25
+	rng := rand.New(rand.NewSource(seed))
+	s, err := sim.New(settings, train.NewCallGenerator(nil, rng), rng)
+	if err != nil {
+		t.Fatalf("got error %s, want nil", err)
+	}
+	decoder, _ := decode.New(settings.SampleRate, settings.Tone, decode.DefaultBandwidth)
+	return &listener{t: t, sim: s, decoder: decoder}
+}
+
+// listen returns the text decoded from the next d of audio.
+func (l *listener) listen(d time.Duration) string {
+	samples := make([]int16, wav.SampleCount(d, wav.DefaultSampleRate))
+	l.sim.Render(samples)
+	chars := append(l.decoder.Write(samples), l.decoder.Flush()...)
+	return decode.Text(chars)
+}
+
+func TestSimQSO(t *testing.T) {
+	l := newListener(t, 1)
+
+	l.sim.Send("CQ TEST", time.Second)
+	heard := l.listen(6 * time.Second)
+	callers := l.sim.Callers()
+	if len(callers) != 1 || heard != callers[0] {
+		t.Fatalf("got %q heard, want the caller in %v", heard, callers)
+	}
+	call := callers[0]
+
+	l.sim.Send(call+" 5NN 1", 2*time.Second)
+	heard = l.listen(6 * time.Second)
+	fields := strings.Fields(heard)
+	if len(fields) != 2 || fields[0] != "5NN" {
+		t.Fatalf("got %q heard, want 5NN and a serial number", heard)
+	}
+	serial := fields[1]
+
+	l.sim.Send("TU", 300*time.Millisecond)
+	l.listen(4 * time.Second)
+	for _, caller := range l.sim.Callers() {
+		if caller == call {
+			t.Errorf("got %s still calling, want it gone after TU", call)
+		}
+	}
+
+	for _, entry := range [][2]string{{call, serial}, {call, serial}, {"ZZ9ZZZ", "1"}} {
+		_, err := l.sim.Log(entry[0], entry[1])
+		if err != nil {
+			t.Errorf("got error %s, want nil logging %v", err, entry)
+		}
+	}
+	score := l.sim.Score()
+	want := sim.Score{Completed: 1, Logged: 3, Correct: 1, NotInLog: 2, Elapsed: 16 * time.Second}
+	if score != want {
+		t.Errorf("got %+v, want %+v", score, want)
+	}
+}
+
+func TestSimNoExchange(t *testing.T) {
+	l := newListener(t, 2)
+
+	l.sim.Send("CQ", 500*time.Millisecond)
+	l.listen(5 * time.Second)
+	call := l.sim.Callers()[0]
+
+	// The caller needs our exchange before TU
+	l.sim.Send(call, time.Second)
+	l.listen(6 * time.Second)
+	l.sim.Send("TU", 300*time.Millisecond)
+	if heard := l.listen(3 * time.Second); heard != "NR?" {
+		t.Errorf("got %q heard, want %q", heard, "NR?")
+	}
+	if score := l.sim.Score(); score.Completed != 0 {
+		t.Errorf("got %d completed, want 0", score.Completed)
+	}
+}
+
+func TestSimRepeats(t *testing.T) {
+	l := newListener(t, 3)
+
+	l.sim.Send("CQ", 500*time.Millisecond)
+	l.listen(5 * time.Second)
+	call := l.sim.Callers()[0]
+
+	tests := []struct {
+		send string
+		want string
+	}{
+		{send: call[:2] + "?", want: call},                                 // Partial call
+		{send: "AGN", want: call},                                          // Nobody worked yet
+		{send: call[:len(call)-1] + "Q" + " 5NN", want: call + " " + call}, // Busted call
+	}
+
+	for _, test := range tests {
+		if strings.HasSuffix(call, "Q") {
+			t.Skip("busted call test needs a call not ending in Q")
+		}
+		l.sim.Send(test.send, 500*time.Millisecond)
+		if heard := l.listen(8 * time.Second); heard != test.want {
+			t.Errorf("got %q heard, want %q after %q", heard, test.want, test.send)
+		}
+	}
+}
+
+func TestSimAbort(t *testing.T) {
+	l := newListener(t, 4)
+
+	l.sim.Send("CQ", 500*time.Millisecond)
+	l.sim.Abort()
+	if heard := l.listen(3 * time.Second); heard != "" {
+		t.Errorf("got %q heard, want nothing after abort", heard)
+	}
+}
+
+func TestLog(t *testing.T) {
+	l := newListener(t, 5)
+
+	tests := []struct {
+		call        string
+		serial      string
+		want        sim.Entry
+		errorWanted bool
+	}{
+		{call: "k3lr", serial: "12", want: sim.Entry{Call: "K3LR", Serial: 12}, errorWanted: false},
+		{call: "K3LR", serial: "1X", want: sim.Entry{}, errorWanted: true},
+		{call: "K3LR", serial: "0", want: sim.Entry{}, errorWanted: true},
+	}
+
+	for _, test := range tests {
+		got, err := l.sim.Log(test.call, test.serial)
+		if got != test.want {
+			t.Errorf("got %+v, want %+v for %s %s", got, test.want, test.call, test.serial)
+		}
+		if test.errorWanted && (err == nil) {
+			t.Errorf("got nil, want error for %s %s", test.call, test.serial)
+		}
+		if !test.errorWanted && (err != nil) {
+			t.Errorf("got error, want nil for %s %s", test.call, test.serial)
+		}
+	}
+}
+
+func TestKeyer(t *testing.T) {
+	l := newListener(t, 6)
+	queue := &testQueue{}
+	keyer := sim.NewKeyer(queue, l.sim, func() morse.Timing { return morse.Timing{WPM: 20} })
+
+	keyer.QueueMessage("CQ")
+	if len(queue.messages) != 1 || len(l.sim.Callers()) != 1 {
+		t.Errorf("got %v queued and %v calling, want CQ queued and a caller", queue.messages, l.sim.Callers())
+	}
+	keyer.DrainSendQueue()
+	if !queue.drained {
+		t.Error("got queue not drained, want drained")
+	}
+	if heard := l.listen(3 * time.Second); heard != "" {
+		t.Errorf("got %q heard, want nothing after the queue is drained", heard)
+	}
+}
+
+// testQueue records the messages queued.
+type testQueue struct {
+	messages []string
+	drained  bool
+}
+
+func (q *testQueue) QueueMessage(message string) error {
+	q.messages = append(q.messages, message)
+	return nil
+}
+
+func (q *testQueue) DrainSendQueue() {
+	q.drained = true
+}
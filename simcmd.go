@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/scottmcleodjr/cwkeyer"
+	"github.com/scottmcleodjr/rekl/bus"
+	"github.com/scottmcleodjr/rekl/handler"
+	"github.com/scottmcleodjr/rekl/history"
+	"github.com/scottmcleodjr/rekl/sim"
+	"github.com/scottmcleodjr/rekl/train"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+const simBlock = 20 * time.Millisecond // Length of audio rendered at a time
+
+// runSim runs a simulated contest pileup in the TUI.
+func runSim(args []string) {
+	defaults := sim.DefaultSettings()
+	flags := flag.NewFlagSet("rekl sim", flag.ExitOnError)
+	keyFlags := addKeyFlags(flags, true)
+	outPath := flags.String("out", "", "Path to write the callers as raw 16 bit mono PCM, like a FIFO to aplay (required)")
+	sampleRate := flags.Int("rate", defaults.SampleRate, "Sample rate of the PCM output")
+	tone := flags.Int("tone", defaults.Tone, "Center pitch of the callers in Hz")
+	spread := flags.Int("spread", defaults.Spread, "Pitch spread of the callers either side of the tone in Hz")
+	minWPM := flags.Int("min-speed", defaults.MinWPM, "Slowest caller speed in WPM")
+	maxWPM := flags.Int("max-speed", defaults.MaxWPM, "Fastest caller speed in WPM")
+	callers := flags.Int("callers", defaults.Callers, "Most stations calling at once")
+	noise := flags.Int("noise", defaults.Noise, "Noise amplitude, 0 for none")
+	scpPath := flags.String("scp", "", "Path of a MASTER.SCP file to pick callers from, instead of generating them")
+	flags.Parse(args)
+
+	if *outPath == "" {
+		log.Fatal("an -out path is required")
+	}
+	var calls []string
+	if *scpPath != "" {
+		calls = loadSCPFile(*scpPath)
+	}
+	settings := sim.Settings{
+		SampleRate: *sampleRate,
+		Tone:       *tone,
+		Spread:     *spread,
+		MinWPM:     *minWPM,
+		MaxWPM:     *maxWPM,
+		Callers:    *callers,
+		Noise:      *noise,
+	}
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	contest, err := sim.New(settings, train.NewCallGenerator(calls, rng), rng)
+	if err != nil {
+		log.Fatalf("unable to start the sim: %s", err)
+	}
+
+	key := keyFlags.openKey()
+	cfg := keyFlags.newConfig()
+	keyer := cwkeyer.New(cfg, key)
+	ui := tui.New()
+	eventUI := busUI{TUI: ui, events: bus.New(ui)}
+	commands := simCommands(contest)
+	hist, _ := history.Load("", history.DefaultMax) // Kept in memory, which does not fail
+	editor := handler.NewEditor(eventUI, hist, cfg)
+	editor.SetCommands(commands)
+	ui.SetInputCapture(editor.Handler(commands.InputHandler(sim.NewKeyer(keyer, contest, cfg.Timing), eventUI, cfg)))
+	ui.WriteEvent(tui.LevelInfo, "Send CQ to start the pileup.  Send a caller's call and exchange, then TU to finish the QSO.")
+	ui.WriteEvent(tui.LevelInfo, `Log each QSO with "\log CALL NR", and see the score with "\score".`)
+
+	go func() {
+		for {
+			err := keyer.ProcessSendQueue(false)
+			if err != nil {
+				ui.WriteEvent(tui.LevelError, err.Error())
+			}
+		}
+	}()
+
+	go func() {
+		// Opening a FIFO blocks until there is a reader
+		file, err := os.OpenFile(*outPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+		if err != nil {
+			ui.WriteEvent(tui.LevelError, fmt.Sprintf("unable to open sim audio: %s", err))
+			return
+		}
+		defer file.Close()
+		err = writeSimAudio(contest, file, *sampleRate)
+		ui.WriteEvent(tui.LevelError, fmt.Sprintf("unable to write sim audio: %s", err))
+	}()
+
+	err = ui.RunApp()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(contest.Score())
+}
+
+// simCommands returns the built-in commands with the sim's commands
+// for logging and scoring QSOs.
+func simCommands(contest *sim.Sim) *handler.Registry {
+	r := handler.DefaultCommands()
+	commands := []handler.Command{
+		{Name: "log", Args: "CALL NR", Help: "Log a QSO with the call and serial number copied", Run: func(c handler.Context, arg string) {
+			fields := strings.Fields(arg)
+			if len(fields) != 2 {
+				c.UI.WriteEvent(tui.LevelError, "log needs a call and a serial number")
+				return
+			}
+			entry, err := contest.Log(fields[0], fields[1])
+			if err != nil {
+				c.UI.WriteEvent(tui.LevelError, err.Error())
+				return
+			}
+			bus.Publish(c.UI, bus.Event{
+				Kind:    bus.KindQSOLogged,
+				Level:   tui.LevelInfo,
+				Message: fmt.Sprintf("Logged %s %d.", entry.Call, entry.Serial),
+				Call:    entry.Call,
+				Serial:  entry.Serial,
+			})
+			c.UI.ClearInputText()
+		}},
+		{Name: "score", Help: "Display the correct, busted and missing QSOs", Run: func(c handler.Context, _ string) {
+			c.UI.WriteEvent(tui.LevelInfo, contest.Score().String())
+			c.UI.ClearInputText()
+		}},
+	}
+	for _, cmd := range commands {
+		err := r.Register(cmd)
+		if err != nil {
+			panic(err) // The sim commands are fixed, so this is a bug
+		}
+	}
+	return r
+}
+
+// writeSimAudio writes the sim audio to file as it happens, until
+// writing fails.
+func writeSimAudio(contest *sim.Sim, file *os.File, sampleRate int) error {
+	samples := make([]int16, int(simBlock.Seconds()*float64(sampleRate)))
+	ticker := time.NewTicker(simBlock)
+	defer ticker.Stop()
+	for range ticker.C {
+		contest.Render(samples)
+		err := binary.Write(file, binary.LittleEndian, samples)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
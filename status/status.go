@@ -0,0 +1,96 @@
+package status
+
+import (
+	"sync"
+	"time"
+
+	"github.com/scottmcleodjr/cwkeyer"
+	"github.com/scottmcleodjr/rekl/bus"
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+// Key is a cwkeyer.Key that keeps its state for the status bar.  Key
+// is safe for use from any goroutine.
+type Key struct {
+	key     cwkeyer.Key
+	name    string
+	events  bus.Writer
+	changed chan struct{}
+	mu      sync.Mutex
+	down    bool
+	err     error
+}
+
+// NewKey returns a Key wrapping key.  The name is shown in the status
+// bar, like "Beep" or "DTR /dev/ttyUSB0".  Key state events are
+// published to events, unless it is nil.  They have no message, so they
+// only go to subscribers and not to the event view.
+func NewKey(key cwkeyer.Key, name string, events bus.Writer) *Key {
+	return &Key{key: key, name: name, events: events, changed: make(chan struct{}, 1)}
+}
+
+// Down puts the wrapped key down.
+func (k *Key) Down() error {
+	return k.set(true, k.key.Down())
+}
+
+// Up lets the wrapped key up.
+func (k *Key) Up() error {
+	return k.set(false, k.key.Up())
+}
+
+func (k *Key) set(down bool, err error) error {
+	k.mu.Lock()
+	moved := k.down != down
+	changed := moved || (k.err == nil) != (err == nil)
+	k.down = down
+	k.err = err
+	k.mu.Unlock()
+	if moved && k.events != nil {
+		bus.Publish(k.events, bus.Event{Kind: bus.KindKeyState, Source: "key", Level: tui.LevelDebug, Down: down})
+	}
+	if changed {
+		select {
+		case k.changed <- struct{}{}:
+		default: // A change is already waiting to be shown
+		}
+	}
+	return err
+}
+
+// State returns if the key is down and the error from the last time it
+// went down or up.
+func (k *Key) State() (bool, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.down, k.err
+}
+
+// Changed returns a channel that receives when the key goes down or up,
+// or its error changes.  Changes that come before the last is received
+// are combined.
+func (k *Key) Changed() <-chan struct{} {
+	return k.changed
+}
+
+// Queue is the cwkeyer.Keyer method used by Read.
+type Queue interface {
+	SendQueueIsEmpty() bool
+}
+
+// Read returns the status for the status bar at a time.
+func Read(cfg *config.Config, key *Key, queue Queue, now time.Time) tui.Status {
+	down, err := key.State()
+	return tui.Status{
+		WPM:        cfg.Speed(),
+		Farnsworth: cfg.Farnsworth(),
+		Key:        key.name,
+		KeyOK:      err == nil,
+		Sending:    !queue.SendQueueIsEmpty(),
+		TX:         down,
+		Bank:       cfg.Bank(),
+		Call:       cfg.Call(),
+		Time:       now.UTC(),
+	}
+}
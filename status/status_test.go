@@ -0,0 +1,126 @@
+package status_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/scottmcleodjr/rekl/bus"
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/status"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+// testKey is a cwkeyer.Key that fails when err is set.
+type testKey struct {
+	err error
+}
+
+func (k *testKey) Down() error {
+	return k.err
+}
+
+func (k *testKey) Up() error {
+	return k.err
+}
+
+// testQueue is a Queue that is empty when empty is set.
+type testQueue struct {
+	empty bool
+}
+
+func (q testQueue) SendQueueIsEmpty() bool {
+	return q.empty
+}
+
+func TestKey(t *testing.T) {
+	wrapped := &testKey{}
+	events := bus.New(nil)
+	states, unsubscribe := events.Subscribe(10, bus.KindKeyState)
+	defer unsubscribe()
+	key := status.NewKey(wrapped, "Beep", events)
+
+	tests := []struct {
+		f           func() error
+		err         error
+		wantDown    bool
+		wantChanged bool
+	}{
+		{f: key.Down, wantDown: true, wantChanged: true},
+		{f: key.Down, wantDown: true, wantChanged: false},
+		{f: key.Up, wantDown: false, wantChanged: true},
+		{f: key.Up, err: errors.New("port closed"), wantDown: false, wantChanged: true},
+		{f: key.Up, err: errors.New("port closed"), wantDown: false, wantChanged: false},
+		{f: key.Up, wantDown: false, wantChanged: true},
+	}
+
+	for i, test := range tests {
+		wrapped.err = test.err
+		err := test.f()
+		if err != test.err {
+			t.Errorf("got error %v, want %v for step %d", err, test.err, i)
+		}
+		down, err := key.State()
+		if down != test.wantDown || err != test.err {
+			t.Errorf("got state %t and %v, want %t and %v for step %d", down, err, test.wantDown, test.err, i)
+		}
+		changed := false
+		select {
+		case <-key.Changed():
+			changed = true
+		default:
+		}
+		if changed != test.wantChanged {
+			t.Errorf("got changed %t, want %t for step %d", changed, test.wantChanged, i)
+		}
+	}
+
+	// Only going down or up is published, not the errors
+	for _, want := range []bool{true, false} {
+		select {
+		case e := <-states:
+			if e.Down != want {
+				t.Errorf("got key state event down %t, want %t", e.Down, want)
+			}
+			if e.Message != "" {
+				t.Errorf("got message %q, want none so the event view skips key state", e.Message)
+			}
+		default:
+			t.Errorf("got no key state event, want down %t", want)
+		}
+	}
+	select {
+	case e := <-states:
+		t.Errorf("got extra key state event %+v", e)
+	default:
+	}
+}
+
+func TestRead(t *testing.T) {
+	cfg := config.New()
+	cfg.SetSpeed(25)
+	cfg.SetFarnsworth(15)
+	cfg.SetCall("w1aw")
+	wrapped := &testKey{}
+	key := status.NewKey(wrapped, "DTR /dev/ttyUSB0", nil)
+	key.Down()
+	wrapped.err = errors.New("port closed")
+	key.Up()
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("EST", -5*60*60))
+
+	got := status.Read(cfg, key, testQueue{empty: false}, now)
+	want := tui.Status{
+		WPM:        25,
+		Farnsworth: 15,
+		Key:        "DTR /dev/ttyUSB0",
+		KeyOK:      false,
+		Sending:    true,
+		TX:         false,
+		Bank:       "Run",
+		Call:       "W1AW",
+		Time:       now.UTC(),
+	}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
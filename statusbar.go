@@ -0,0 +1,39 @@
+package main
+
+import (
+	"time"
+
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/status"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+// statusInterval is how often the status bar is redrawn for the send
+// queue and the clock, which do not report their changes.
+const statusInterval = 250 * time.Millisecond
+
+// showStatus shows the status bar and keeps it up to date.  showStatus
+// must be called from outside the event loop, and does not return.
+func showStatus(ui *tui.TUI, cfg *config.Config, key *status.Key, queue status.Queue) {
+	ticker := time.NewTicker(statusInterval)
+	defer ticker.Stop()
+	changes, cancel := cfg.Subscribe(1)
+	defer cancel()
+	for {
+		select {
+		case <-ticker.C:
+		case <-key.Changed():
+		case <-changes:
+		}
+		s := status.Read(cfg, key, queue, time.Now())
+		ui.QueueUpdate(func() { ui.SetStatus(s) })
+	}
+}
+
+// name returns the name of the configured Key for the status bar.
+func (kf keyFlags) name() string {
+	if *kf.beep {
+		return "Beep"
+	}
+	return "DTR " + *kf.portName
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"log"
+	"sort"
+
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+// applyTheme sets the theme and layout of the TUI from the config
+// file, or exits if they are invalid.
+func applyTheme(ui *tui.TUI, cfg *config.Config) {
+	settings := cfg.Theme()
+	theme, err := tui.Preset(settings.Preset)
+	if err != nil {
+		log.Fatalf("unable to load config: %s", err)
+	}
+	// Sorted so errors are the same every time
+	parts := make([]string, 0, len(settings.Colors))
+	for part := range settings.Colors {
+		parts = append(parts, part)
+	}
+	sort.Strings(parts)
+	for _, part := range parts {
+		c := settings.Colors[part]
+		err = theme.SetStyle(part, tui.Style{Foreground: c.Foreground, Background: c.Background, Attributes: c.Attributes})
+		if err != nil {
+			log.Fatalf("unable to load config: %s", err)
+		}
+	}
+	ui.SetTheme(theme)
+
+	layout := tui.DefaultLayout()
+	sizes := cfg.Layout()
+	if sizes.Input != 0 {
+		layout.Input = sizes.Input
+	}
+	if sizes.Events != 0 {
+		layout.Events = sizes.Events
+	}
+	if sizes.Receive != 0 {
+		layout.Receive = sizes.Receive
+	}
+	err = ui.SetLayout(layout)
+	if err != nil {
+		log.Fatalf("unable to load config: %s", err)
+	}
+}
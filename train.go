@@ -0,0 +1,120 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/scottmcleodjr/cwkeyer"
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/handler"
+	"github.com/scottmcleodjr/rekl/train"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+const trainUsage = `Usage: rekl train [mode] [flags]
+
+Modes:
+    koch      Koch method character training
+    calls     Callsign copying at adaptive speed
+
+Run "rekl train [mode] -h" for the flags of a mode.
+`
+
+// runTrain runs a training mode in the TUI.
+func runTrain(args []string) {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, trainUsage)
+		os.Exit(2)
+	}
+
+	mode := args[0]
+	flags := flag.NewFlagSet("rekl train "+mode, flag.ExitOnError)
+	keyFlags := addKeyFlags(flags, true)
+	farnsworth := flags.Int("farnsworth", 0, "Farnsworth speed in WPM, 0 for off")
+
+	// Each mode adds its flags and builds its trainer once they are parsed
+	var newTrainer func(cfg *config.Config) handler.Trainer
+	switch mode {
+	case "koch":
+		progressPath := flags.String("progress", userConfigPath("koch.json"), "Path of the saved Koch progress")
+		groupLen := flags.Int("group", train.DefaultGroupLen, "Characters in each group")
+		newTrainer = func(cfg *config.Config) handler.Trainer {
+			progress, err := train.LoadProgress(*progressPath)
+			if err != nil {
+				log.Fatalf("unable to load progress: %s", err)
+			}
+			rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+			return train.NewKoch(progress, *progressPath, *groupLen, rng)
+		}
+	case "calls":
+		statsPath := flags.String("stats", userConfigPath("calls.json"), "Path of the saved callsign stats")
+		scpPath := flags.String("scp", "", "Path of a MASTER.SCP file to pick calls from, instead of generating them")
+		newTrainer = func(cfg *config.Config) handler.Trainer {
+			var calls []string
+			if *scpPath != "" {
+				calls = loadSCPFile(*scpPath)
+			}
+			stats, err := train.LoadCallStats(*statsPath)
+			if err != nil {
+				log.Fatalf("unable to load stats: %s", err)
+			}
+			rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+			return train.NewCalls(train.NewCallGenerator(calls, rng), stats, *statsPath, cfg)
+		}
+	default:
+		fmt.Fprint(os.Stderr, trainUsage)
+		log.Fatalf("unknown training mode %q", mode)
+	}
+	flags.Parse(args[1:])
+
+	cfg := keyFlags.newConfig()
+	err := cfg.SetFarnsworth(*farnsworth)
+	if err != nil {
+		log.Fatalf("unable to set Farnsworth speed: %s", err)
+	}
+	trainer := newTrainer(cfg)
+	key := keyFlags.openKey()
+	keyer := cwkeyer.New(cfg, key)
+	player := train.NewKeyerPlayer(keyer, cfg.Timing)
+
+	ui := tui.New()
+	ui.SetInputCapture(handler.TrainerHandler(trainer, player, ui, cfg))
+	ui.WriteEvent(tui.LevelInfo, "Type what you copy and press Enter to check it.  Press Enter with no input to hear it again.")
+	ui.WriteEvent(tui.LevelInfo, "Press Enter to start.")
+
+	go func() {
+		for {
+			err := keyer.ProcessSendQueue(false)
+			if err != nil {
+				ui.WriteEvent(tui.LevelError, err.Error())
+			}
+		}
+	}()
+
+	err = ui.RunApp()
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// loadSCPFile returns the callsigns in a MASTER.SCP file or exits
+// if there are none.
+func loadSCPFile(path string) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("unable to open SCP file: %s", err)
+	}
+	defer file.Close()
+	calls, err := train.LoadSCP(file)
+	if err != nil {
+		log.Fatalf("unable to read SCP file: %s", err)
+	}
+	if len(calls) == 0 {
+		log.Fatalf("no callsigns in SCP file %s", path)
+	}
+	return calls
+}
@@ -0,0 +1,254 @@
+package train
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/morse"
+)
+
+const (
+	CallSpeedUp   = 1 // WPM added after a call is copied
+	CallSpeedDown = 2 // WPM removed after a call is missed
+)
+
+// prefixRule is a callsign prefix and how often it is heard, roughly
+// following contest logs.  A '#' in the prefix is a random digit.
+type prefixRule struct {
+	prefix string
+	weight int
+}
+
+var prefixRules = []prefixRule{
+	{"K#", 12}, {"W#", 12}, {"N#", 8}, {"AA#", 2}, {"AB#", 1}, {"AC#", 1}, {"AD#", 1},
+	{"AE#", 1}, {"AF#", 1}, {"AG#", 1}, {"AI#", 1}, {"AJ#", 1}, {"AK#", 1}, {"KB#", 1},
+	{"KC#", 1}, {"KD#", 1}, {"KE#", 1}, {"KF#", 1}, {"KG#", 1}, {"KI#", 1}, {"KJ#", 1},
+	{"KK#", 1}, {"KN#", 1}, {"WA#", 1}, {"WB#", 1}, {"VE#", 4}, {"VA#", 2}, {"KP4", 1},
+	{"KH6", 1}, {"KL7", 1}, {"XE#", 1}, {"G#", 4}, {"M#", 3}, {"2E#", 1}, {"GM#", 1},
+	{"GW#", 1}, {"EI#", 1}, {"DL#", 6}, {"DK#", 2}, {"DJ#", 2}, {"F#", 3}, {"ON#", 2},
+	{"PA#", 2}, {"I#", 2}, {"IK#", 2}, {"EA#", 3}, {"CT#", 1}, {"OE#", 1}, {"HB9", 2},
+	{"OH#", 2}, {"SM#", 2}, {"LA#", 1}, {"OZ#", 1}, {"ES#", 1}, {"YL#", 1}, {"LY#", 1},
+	{"SP#", 3}, {"OK#", 2}, {"OM#", 1}, {"HA#", 2}, {"S5#", 1}, {"9A#", 1}, {"E7#", 1},
+	{"YU#", 1}, {"YO#", 2}, {"LZ#", 2}, {"SV#", 1}, {"TA#", 1}, {"UA#", 4}, {"R#", 3},
+	{"UR#", 2}, {"EU#", 1}, {"4X#", 1}, {"A6#", 1}, {"JA#", 4}, {"JH#", 1}, {"HL#", 1},
+	{"BY#", 1}, {"BV#", 1}, {"DU#", 1}, {"YB#", 1}, {"VK#", 2}, {"ZL#", 1}, {"ZS#", 1},
+	{"PY#", 2}, {"LU#", 1}, {"CE#", 1}, {"CO#", 1},
+}
+
+// CallGenerator makes plausible callsigns to send.
+type CallGenerator struct {
+	calls []string
+	rng   *rand.Rand
+}
+
+// NewCallGenerator returns a CallGenerator that picks from calls, or
+// builds calls from common prefixes if calls is empty.
+func NewCallGenerator(calls []string, rng *rand.Rand) *CallGenerator {
+	return &CallGenerator{calls: calls, rng: rng}
+}
+
+// Next returns a random callsign.
+func (g *CallGenerator) Next() string {
+	if len(g.calls) > 0 {
+		return g.calls[g.rng.Intn(len(g.calls))]
+	}
+
+	total := 0
+	for _, rule := range prefixRules {
+		total += rule.weight
+	}
+	pick := g.rng.Intn(total)
+	rule := prefixRules[0]
+	for _, rule = range prefixRules {
+		pick -= rule.weight
+		if pick < 0 {
+			break
+		}
+	}
+
+	var sb strings.Builder
+	for _, r := range rule.prefix {
+		if r == '#' {
+			r = rune('0' + g.rng.Intn(10))
+		}
+		sb.WriteRune(r)
+	}
+
+	// Mostly two and three letter suffixes, with a few
+	// one letter suffixes and portable stations
+	suffixLen := 3
+	switch n := g.rng.Intn(10); {
+	case n == 0:
+		suffixLen = 1
+	case n < 5:
+		suffixLen = 2
+	}
+	for i := 0; i < suffixLen; i++ {
+		sb.WriteByte(byte('A' + g.rng.Intn(26)))
+	}
+	if g.rng.Intn(30) == 0 {
+		sb.WriteString("/P")
+	}
+	return sb.String()
+}
+
+// LoadSCP reads callsigns from a MASTER.SCP style file, which has one
+// callsign per line and comment lines starting with '#'.  Lines with
+// characters that cannot be sent are skipped.
+func LoadSCP(r io.Reader) ([]string, error) {
+	var calls []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		call := strings.ToUpper(strings.TrimSpace(scanner.Text()))
+		if call == "" || strings.HasPrefix(call, "#") || !sendable(call) {
+			continue
+		}
+		calls = append(calls, call)
+	}
+	return calls, scanner.Err()
+}
+
+func sendable(text string) bool {
+	for _, r := range text {
+		if _, ok := morse.Code(r); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// CallStats is a trainee's saved callsign training record.
+type CallStats struct {
+	Calls     int            `json:"calls"`
+	Correct   int            `json:"correct"`
+	BestSpeed int            `json:"bestSpeed"` // Fastest speed a call was copied at
+	Confused  map[string]int `json:"confused"`  // Sent and copied character pairs, like "5H"
+}
+
+// LoadCallStats reads callsign stats from a JSON file.  A missing file
+// is new stats.
+func LoadCallStats(path string) (*CallStats, error) {
+	stats := &CallStats{}
+	err := loadJSON(path, stats)
+	if err != nil {
+		return nil, err
+	}
+	if stats.Confused == nil {
+		stats.Confused = map[string]int{}
+	}
+	return stats, nil
+}
+
+// Save writes callsign stats to a JSON file, creating its directory if needed.
+func (s *CallStats) Save(path string) error {
+	return saveJSON(path, s)
+}
+
+// Confusions returns the confused character pairs, most frequent first.
+func (s *CallStats) Confusions() []string {
+	pairs := make([]string, 0, len(s.Confused))
+	for pair := range s.Confused {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if s.Confused[pairs[i]] != s.Confused[pairs[j]] {
+			return s.Confused[pairs[i]] > s.Confused[pairs[j]]
+		}
+		return pairs[i] < pairs[j]
+	})
+	return pairs
+}
+
+// SpeedSetter is the speed setting the trainer adapts.
+type SpeedSetter interface {
+	Speed() int
+	SetSpeed(speed int) error
+}
+
+// Calls is a callsign copy trainer.  Calls sends one callsign at a time,
+// raises the speed by CallSpeedUp after each copied call and lowers it by
+// CallSpeedDown after each missed call.
+type Calls struct {
+	generator *CallGenerator
+	stats     *CallStats
+	path      string
+	speed     SpeedSetter
+}
+
+// NewCalls returns a new callsign trainer that records to stats and
+// saves them to path after each call.  An empty path is not saved.
+func NewCalls(generator *CallGenerator, stats *CallStats, path string, speed SpeedSetter) *Calls {
+	return &Calls{generator: generator, stats: stats, path: path, speed: speed}
+}
+
+// Next returns a callsign to send.
+func (c *Calls) Next() string {
+	return c.generator.Next()
+}
+
+// Check scores a copied callsign, adapts the speed, records and saves
+// the stats, and returns a line of feedback for the user.
+func (c *Calls) Check(sent, copied string) (string, error) {
+	score := Compare(sent, strings.ToUpper(strings.Join(strings.Fields(copied), "")))
+	c.stats.Calls++
+	for _, confusion := range score.Confusion {
+		c.stats.Confused[string(confusion.Sent)+string(confusion.Copied)]++
+	}
+
+	speed := c.speed.Speed()
+	feedback := ""
+	if score.Copied == sent {
+		c.stats.Correct++
+		if speed > c.stats.BestSpeed {
+			c.stats.BestSpeed = speed
+		}
+		feedback = fmt.Sprintf("Copied %s at %d WPM.", sent, speed)
+		speed += CallSpeedUp
+	} else {
+		feedback = fmt.Sprintf("Sent %s, copied %s at %d WPM.", sent, score.Copied, speed)
+		for _, confusion := range score.Confusion {
+			feedback = fmt.Sprintf("%s %c as %c.", feedback, confusion.Sent, confusion.Copied)
+		}
+		speed -= CallSpeedDown
+	}
+
+	if speed < config.MinSpeed {
+		speed = config.MinSpeed
+	}
+	if speed > config.MaxSpeed {
+		speed = config.MaxSpeed
+	}
+	err := c.speed.SetSpeed(speed)
+	if err != nil {
+		return feedback, err
+	}
+
+	if c.path == "" {
+		return feedback, nil
+	}
+	return feedback, c.stats.Save(c.path)
+}
+
+// Summary returns the copy record and the most confused characters.
+func (c *Calls) Summary() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Copied %d of %d calls, best %d WPM, now %d WPM.",
+		c.stats.Correct, c.stats.Calls, c.stats.BestSpeed, c.speed.Speed()))
+	confusions := c.stats.Confusions()
+	if len(confusions) > 5 {
+		confusions = confusions[:5]
+	}
+	if len(confusions) > 0 {
+		sb.WriteString("  Most confused:")
+		for _, pair := range confusions {
+			sent, copied := []rune(pair)[0], []rune(pair)[1]
+			sb.WriteString(fmt.Sprintf(" %c as %c %d times,", sent, copied, c.stats.Confused[pair]))
+		}
+	}
+	return strings.TrimSuffix(sb.String(), ",")
+}
@@ -0,0 +1,116 @@
+package train_test
+
+import (
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/receive"
+	"github.com/scottmcleodjr/rekl/train"
+)
+
+func TestCallGenerator(t *testing.T) {
+	generator := train.NewCallGenerator(nil, rand.New(rand.NewSource(1)))
+	for i := 0; i < 500; i++ {
+		call := generator.Next()
+		if got := receive.Callsigns(call); len(got) != 1 {
+			t.Errorf("got %q, want a valid callsign", call)
+		}
+	}
+
+	calls := []string{"K3LR", "DL1ABC"}
+	generator = train.NewCallGenerator(calls, rand.New(rand.NewSource(1)))
+	for i := 0; i < 20; i++ {
+		call := generator.Next()
+		if call != calls[0] && call != calls[1] {
+			t.Errorf("got %q, want a call from %v", call, calls)
+		}
+	}
+}
+
+func TestLoadSCP(t *testing.T) {
+	scp := "# Comment line\nk3lr\n\nDL1ABC\nBAD_CALL\n  W1AW  \n"
+	got, err := train.LoadSCP(strings.NewReader(scp))
+	if err != nil {
+		t.Fatalf("got error %s, want nil", err)
+	}
+	want := []string{"K3LR", "DL1ABC", "W1AW"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestCallsCheck(t *testing.T) {
+	cfg := config.New()
+	cfg.SetSpeed(20)
+	stats, _ := train.LoadCallStats(filepath.Join(t.TempDir(), "missing.json"))
+	calls := train.NewCalls(train.NewCallGenerator(nil, rand.New(rand.NewSource(1))), stats, "", cfg)
+
+	tests := []struct {
+		sent      string
+		copied    string
+		wantSpeed int
+	}{
+		{sent: "K5ABC", copied: "k5abc", wantSpeed: 20 + train.CallSpeedUp},
+		{sent: "K5ABC", copied: "KHABC", wantSpeed: 20 + train.CallSpeedUp - train.CallSpeedDown},
+		{sent: "N5XY", copied: "NHXY", wantSpeed: 20 + train.CallSpeedUp - 2*train.CallSpeedDown},
+		{sent: "W1B", copied: "W1D", wantSpeed: 20 + train.CallSpeedUp - 3*train.CallSpeedDown},
+	}
+
+	for _, test := range tests {
+		calls.Check(test.sent, test.copied)
+		if cfg.Speed() != test.wantSpeed {
+			t.Errorf("got speed %d, want %d after %q copied as %q", cfg.Speed(), test.wantSpeed, test.sent, test.copied)
+		}
+	}
+
+	if stats.Calls != 4 || stats.Correct != 1 {
+		t.Errorf("got %d of %d correct, want 1 of 4", stats.Correct, stats.Calls)
+	}
+	if stats.BestSpeed != 20 {
+		t.Errorf("got best speed %d, want 20", stats.BestSpeed)
+	}
+	confusions := stats.Confusions()
+	if len(confusions) != 2 || confusions[0] != "5H" || stats.Confused["5H"] != 2 {
+		t.Errorf("got confusions %v, want 5H twice then BD", stats.Confused)
+	}
+	if summary := calls.Summary(); !strings.Contains(summary, "5 as H 2 times") {
+		t.Errorf("got summary %q, want it to list 5 as H", summary)
+	}
+}
+
+func TestCallsSpeedLimits(t *testing.T) {
+	cfg := config.New()
+	cfg.SetSpeed(config.MinSpeed)
+	stats, _ := train.LoadCallStats(filepath.Join(t.TempDir(), "missing.json"))
+	calls := train.NewCalls(train.NewCallGenerator(nil, rand.New(rand.NewSource(1))), stats, "", cfg)
+
+	_, err := calls.Check("K5ABC", "")
+	if err != nil {
+		t.Errorf("got error %s, want nil at the minimum speed", err)
+	}
+	if cfg.Speed() != config.MinSpeed {
+		t.Errorf("got speed %d, want %d", cfg.Speed(), config.MinSpeed)
+	}
+}
+
+func TestCallStatsSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rekl", "calls.json")
+	stats, _ := train.LoadCallStats(path)
+	stats.Calls = 3
+	stats.Confused["BV"] = 1
+	err := stats.Save(path)
+	if err != nil {
+		t.Fatalf("got error %s, want nil", err)
+	}
+
+	loaded, err := train.LoadCallStats(path)
+	if err != nil {
+		t.Fatalf("got error %s, want nil", err)
+	}
+	if loaded.Calls != 3 || loaded.Confused["BV"] != 1 {
+		t.Errorf("got %+v, want %+v", loaded, stats)
+	}
+}
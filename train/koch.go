@@ -0,0 +1,94 @@
+package train
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+const (
+	KochOrder       = "KMURESNAPTLWI.JZ=FOY,VG5/Q92H38B?47C1D60X" // Order from LCWO
+	KochStartLevel  = 2                                           // Characters unlocked at the start
+	UnlockAccuracy  = 90                                          // Percent correct to unlock the next character
+	UnlockWindow    = 50                                          // Characters the accuracy is measured over
+	DefaultGroupLen = 5
+)
+
+// Koch is a Koch method trainer.  Koch sends random groups of the unlocked
+// characters and unlocks the next character in KochOrder once the recent
+// copy accuracy, and the accuracy of the newest character, reach
+// UnlockAccuracy.
+type Koch struct {
+	progress *Progress
+	path     string
+	groupLen int
+	rng      *rand.Rand
+}
+
+// NewKoch returns a new Koch trainer that records to progress and
+// saves it to path after each group.  An empty path is not saved.
+func NewKoch(progress *Progress, path string, groupLen int, rng *rand.Rand) *Koch {
+	if progress.Level < KochStartLevel {
+		progress.Level = KochStartLevel
+	}
+	if groupLen < 1 {
+		groupLen = DefaultGroupLen
+	}
+	return &Koch{progress: progress, path: path, groupLen: groupLen, rng: rng}
+}
+
+// Unlocked returns the characters in training.
+func (k *Koch) Unlocked() string {
+	return KochOrder[:k.progress.Level]
+}
+
+// Next returns a random group of unlocked characters to send.
+func (k *Koch) Next() string {
+	unlocked := k.Unlocked()
+	var sb strings.Builder
+	for i := 0; i < k.groupLen; i++ {
+		sb.WriteByte(unlocked[k.rng.Intn(len(unlocked))])
+	}
+	return sb.String()
+}
+
+// Check scores copied text against a sent group, records and saves it
+// in the progress, and returns a line of feedback for the user.
+func (k *Koch) Check(sent, copied string) (string, error) {
+	score := Compare(sent, strings.ToUpper(strings.Join(strings.Fields(copied), "")))
+	k.progress.Record(score)
+
+	feedback := fmt.Sprintf("Sent %s, copied %s: %d/%d.", sent, score.Copied, score.Correct, score.Total())
+	recent, count := k.progress.RecentAccuracy()
+	// The newest character is the one being learned, and is only counted
+	// since it was unlocked, so a few groups of the others can't hide it
+	newest := rune(KochOrder[k.progress.Level-1])
+	newestAccuracy := k.progress.Accuracy(newest)
+	switch {
+	case count < UnlockWindow || recent < UnlockAccuracy || k.progress.Level == len(KochOrder):
+		feedback = fmt.Sprintf("%s %d%% over the last %d characters.", feedback, recent, count)
+	case newestAccuracy < UnlockAccuracy:
+		feedback = fmt.Sprintf("%s %d%% over the last %d characters, but %c is %d%%.",
+			feedback, recent, count, newest, newestAccuracy)
+	default:
+		k.progress.Level++
+		k.progress.ResetRecent()
+		feedback = fmt.Sprintf("%s %d%% over the last %d characters, unlocked %c!",
+			feedback, recent, count, KochOrder[k.progress.Level-1])
+	}
+
+	if k.path == "" {
+		return feedback, nil
+	}
+	return feedback, k.progress.Save(k.path)
+}
+
+// Summary returns the unlocked characters and the accuracy of each.
+func (k *Koch) Summary() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Koch level %d of %d:", k.progress.Level, len(KochOrder)))
+	for _, r := range k.Unlocked() {
+		sb.WriteString(fmt.Sprintf(" %c %d%%", r, k.progress.Accuracy(r)))
+	}
+	return sb.String()
+}
@@ -0,0 +1,114 @@
+package train_test
+
+import (
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scottmcleodjr/rekl/train"
+)
+
+func TestKochNext(t *testing.T) {
+	progress, _ := train.LoadProgress(filepath.Join(t.TempDir(), "missing.json"))
+	koch := train.NewKoch(progress, "", 5, rand.New(rand.NewSource(1)))
+
+	if koch.Unlocked() != "KM" {
+		t.Errorf("got %q, want %q unlocked at the start", koch.Unlocked(), "KM")
+	}
+	for i := 0; i < 20; i++ {
+		group := koch.Next()
+		if len(group) != 5 {
+			t.Errorf("got group %q, want 5 characters", group)
+		}
+		if strings.Trim(group, "KM") != "" {
+			t.Errorf("got group %q, want only unlocked characters", group)
+		}
+	}
+}
+
+func TestKochUnlock(t *testing.T) {
+	progress, _ := train.LoadProgress(filepath.Join(t.TempDir(), "missing.json"))
+	koch := train.NewKoch(progress, "", 5, rand.New(rand.NewSource(1)))
+
+	// Copying 80% does not unlock
+	for i := 0; i < train.UnlockWindow/5; i++ {
+		koch.Check("KMKMK", "KMKMM")
+	}
+	if progress.Level != train.KochStartLevel {
+		t.Errorf("got level %d, want %d after 80%% copy", progress.Level, train.KochStartLevel)
+	}
+
+	// Perfect copy brings the accuracy over the window to 90%
+	announced := false
+	for i := 0; i < train.UnlockWindow/5; i++ {
+		feedback, _ := koch.Check("KMKMK", "kmkmk")
+		announced = announced || strings.Contains(feedback, "unlocked U")
+	}
+	if progress.Level != train.KochStartLevel+1 {
+		t.Errorf("got level %d, want %d after perfect copy", progress.Level, train.KochStartLevel+1)
+	}
+	if !announced {
+		t.Error("got no feedback announcing U, want it announced once unlocked")
+	}
+
+	// Only the last K was missed in the 80% groups
+	if accuracy := progress.Accuracy('M'); accuracy != 100 {
+		t.Errorf("got %d%%, want 100%% for M", accuracy)
+	}
+	if accuracy := progress.Accuracy('K'); accuracy >= 100 {
+		t.Errorf("got %d%%, want less than 100%% for K", accuracy)
+	}
+}
+
+func TestKochUnlockNewest(t *testing.T) {
+	progress, _ := train.LoadProgress(filepath.Join(t.TempDir(), "missing.json"))
+	koch := train.NewKoch(progress, "", 10, rand.New(rand.NewSource(1)))
+
+	// Missing every M is 90% overall, but M is the newest character
+	var feedback string
+	for i := 0; i < train.UnlockWindow/10; i++ {
+		feedback, _ = koch.Check("KKKKKKKKKM", "KKKKKKKKK")
+	}
+	if recent, _ := progress.RecentAccuracy(); recent < train.UnlockAccuracy {
+		t.Fatalf("got %d%%, want at least %d%% overall", recent, train.UnlockAccuracy)
+	}
+	if progress.Level != train.KochStartLevel {
+		t.Errorf("got level %d, want %d with M at %d%%", progress.Level, train.KochStartLevel, progress.Accuracy('M'))
+	}
+	if !strings.Contains(feedback, "M is 0%") {
+		t.Errorf("got feedback %q, want it to say M is 0%%", feedback)
+	}
+
+	// Copying M brings it up to 90% and unlocks the next character
+	for i := 0; i < 9*train.UnlockWindow/10 && progress.Level == train.KochStartLevel; i++ {
+		koch.Check("KKKKKKKKKM", "KKKKKKKKKM")
+	}
+	if progress.Level != train.KochStartLevel+1 {
+		t.Errorf("got level %d, want %d with M at %d%%", progress.Level, train.KochStartLevel+1, progress.Accuracy('M'))
+	}
+	if accuracy := progress.Accuracy('M'); accuracy < train.UnlockAccuracy {
+		t.Errorf("got M at %d%% when unlocked, want at least %d%%", accuracy, train.UnlockAccuracy)
+	}
+}
+
+func TestProgressSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rekl", "koch.json")
+	progress, _ := train.LoadProgress(path)
+	koch := train.NewKoch(progress, path, 5, rand.New(rand.NewSource(1)))
+	_, err := koch.Check("KMKMK", "KMKMK")
+	if err != nil {
+		t.Fatalf("got error %q saving progress", err)
+	}
+
+	loaded, err := train.LoadProgress(path)
+	if err != nil {
+		t.Fatalf("got error %q loading progress", err)
+	}
+	if loaded.Level != train.KochStartLevel || loaded.Chars["K"].Sent != 3 || loaded.Chars["M"].Correct != 2 {
+		t.Errorf("got %+v, want saved progress", loaded)
+	}
+	if _, count := loaded.RecentAccuracy(); count != 5 {
+		t.Errorf("got %d recent characters, want 5", count)
+	}
+}
@@ -0,0 +1,78 @@
+package train
+
+import (
+	"sync"
+	"time"
+
+	"github.com/scottmcleodjr/cwkeyer"
+	"github.com/scottmcleodjr/rekl/morse"
+)
+
+// KeyerPlayer plays training text through a cwkeyer.Keyer whose send
+// queue is being processed.  The keyer sends each character at the
+// character speed, and KeyerPlayer waits out any extra Farnsworth
+// spacing before queuing the next character.
+type KeyerPlayer struct {
+	keyer  *cwkeyer.Keyer
+	timing func() morse.Timing
+	mu     sync.Mutex
+	stop   chan struct{}
+}
+
+// NewKeyerPlayer returns a new KeyerPlayer.  The timing function is
+// called for each text played, so speed changes apply to the next text.
+func NewKeyerPlayer(keyer *cwkeyer.Keyer, timing func() morse.Timing) *KeyerPlayer {
+	return &KeyerPlayer{keyer: keyer, timing: timing}
+}
+
+// Play stops any text playing and starts playing text in the background.
+func (p *KeyerPlayer) Play(text string) {
+	p.Stop()
+	stop := make(chan struct{})
+	p.mu.Lock()
+	p.stop = stop
+	p.mu.Unlock()
+	go p.play(text, p.timing(), stop)
+}
+
+// Stop stops the text playing and drains the keyer send queue.
+func (p *KeyerPlayer) Stop() {
+	p.mu.Lock()
+	if p.stop != nil {
+		close(p.stop)
+		p.stop = nil
+	}
+	p.mu.Unlock()
+	p.keyer.DrainSendQueue()
+}
+
+func (p *KeyerPlayer) play(text string, timing morse.Timing, stop chan struct{}) {
+	charGap, wordGap := timing.Gaps()
+	extraChar := charGap - morse.CharGap*morse.Dit(timing.WPM)
+	extraWord := wordGap - morse.WordGap*morse.Dit(timing.WPM)
+	if extraChar <= 0 && extraWord <= 0 {
+		// Standard spacing, the keyer can send it all at once
+		p.keyer.QueueMessage(text)
+		return
+	}
+
+	runes := []rune(text)
+	for i, r := range runes {
+		// Trainer text only has sendable characters
+		_ = p.keyer.QueueMessage(string(r))
+		if i == len(runes)-1 {
+			return
+		}
+
+		// The character before a space already waited out a character gap
+		wait := morse.Duration(string(r), timing.WPM) + morse.CharGap*morse.Dit(timing.WPM) + extraChar
+		if r == ' ' {
+			wait = (morse.WordGap-morse.CharGap)*morse.Dit(timing.WPM) + extraWord - extraChar
+		}
+		select {
+		case <-time.After(wait):
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package train
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// CharStats counts how often a character was sent and copied.
+type CharStats struct {
+	Sent    int `json:"sent"`
+	Correct int `json:"correct"`
+}
+
+// Progress is a trainee's saved training state.
+type Progress struct {
+	Level  int                  `json:"level"`
+	Chars  map[string]CharStats `json:"chars"`
+	Recent []bool               `json:"recent"` // Most recent characters, true if copied
+}
+
+// LoadProgress reads progress from a JSON file.  A missing file
+// is new progress.
+func LoadProgress(path string) (*Progress, error) {
+	progress := &Progress{}
+	err := loadJSON(path, progress)
+	if err != nil {
+		return nil, err
+	}
+	if progress.Chars == nil {
+		progress.Chars = map[string]CharStats{}
+	}
+	return progress, nil
+}
+
+// Save writes progress to a JSON file, creating its directory if needed.
+func (p *Progress) Save(path string) error {
+	return saveJSON(path, p)
+}
+
+// loadJSON reads a JSON file into v, leaving v as it is if the file
+// is missing.
+func loadJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// saveJSON writes v to a JSON file, creating its directory if needed.
+func saveJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Record adds a score to the per character and recent counts.
+func (p *Progress) Record(score Score) {
+	missed := map[rune]int{}
+	for _, r := range score.Missed {
+		missed[r]++
+	}
+	for _, r := range score.Sent {
+		stats := p.Chars[string(r)]
+		stats.Sent++
+		copied := missed[r] == 0
+		if copied {
+			stats.Correct++
+		} else {
+			missed[r]--
+		}
+		p.Chars[string(r)] = stats
+		p.Recent = append(p.Recent, copied)
+	}
+	if len(p.Recent) > UnlockWindow {
+		p.Recent = p.Recent[len(p.Recent)-UnlockWindow:]
+	}
+}
+
+// RecentAccuracy returns the percent of recent characters copied
+// and how many recent characters there are.
+func (p *Progress) RecentAccuracy() (int, int) {
+	if len(p.Recent) == 0 {
+		return 0, 0
+	}
+	correct := 0
+	for _, copied := range p.Recent {
+		if copied {
+			correct++
+		}
+	}
+	return 100 * correct / len(p.Recent), len(p.Recent)
+}
+
+// ResetRecent clears the recent characters, so a new character
+// is measured from scratch.
+func (p *Progress) ResetRecent() {
+	p.Recent = nil
+}
+
+// Accuracy returns the percent of a character copied over all training.
+func (p *Progress) Accuracy(r rune) int {
+	stats := p.Chars[string(r)]
+	if stats.Sent == 0 {
+		return 0
+	}
+	return 100 * stats.Correct / stats.Sent
+}
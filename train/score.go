@@ -0,0 +1,77 @@
+package train
+
+// Score is the result of comparing sent and copied text.
+type Score struct {
+	Sent      string
+	Copied    string
+	Correct   int         // Sent characters that were copied
+	Missed    []rune      // Sent characters that were not copied
+	Confusion []Confusion // Sent characters copied as another character
+}
+
+// Confusion is a sent character that was copied as another character.
+type Confusion struct {
+	Sent   rune
+	Copied rune
+}
+
+// Total returns the number of characters sent.
+func (s Score) Total() int {
+	return len([]rune(s.Sent))
+}
+
+// Compare scores copied text against sent text.  Characters are matched
+// in order along the longest common subsequence, so a dropped or extra
+// character only costs the characters it affects.
+func Compare(sent, copied string) Score {
+	a, b := []rune(sent), []rune(copied)
+	// lcs[i][j] is the common subsequence length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	// Runs of missed and extra characters of the same length
+	// between matches are characters copied as the wrong one
+	score := Score{Sent: sent, Copied: copied}
+	var missed, extra []rune
+	confuse := func() {
+		if len(missed) == len(extra) {
+			for k := range missed {
+				score.Confusion = append(score.Confusion, Confusion{Sent: missed[k], Copied: extra[k]})
+			}
+		}
+		missed, extra = nil, nil
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			confuse()
+			score.Correct++
+			i++
+			j++
+		case i == len(a) || (j < len(b) && lcs[i][j+1] >= lcs[i+1][j]):
+			extra = append(extra, b[j])
+			j++
+		default:
+			score.Missed = append(score.Missed, a[i])
+			missed = append(missed, a[i])
+			i++
+		}
+	}
+	confuse()
+	return score
+}
@@ -0,0 +1,62 @@
+package train_test
+
+import (
+	"testing"
+
+	"github.com/scottmcleodjr/rekl/train"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		sent        string
+		copied      string
+		wantCorrect int
+		wantMissed  string
+	}{
+		{sent: "KMRSU", copied: "KMRSU", wantCorrect: 5, wantMissed: ""},
+		{sent: "KMRSU", copied: "KMRSA", wantCorrect: 4, wantMissed: "U"},
+		{sent: "KMRSU", copied: "MRSU", wantCorrect: 4, wantMissed: "K"},  // Dropped first character
+		{sent: "KMRSU", copied: "KMXRSU", wantCorrect: 5, wantMissed: ""}, // Extra character
+		{sent: "K3GDS", copied: "K3GHS", wantCorrect: 4, wantMissed: "D"}, // Wrong character
+		{sent: "KMRSU", copied: "", wantCorrect: 0, wantMissed: "KMRSU"},
+	}
+
+	for _, test := range tests {
+		score := train.Compare(test.sent, test.copied)
+		if score.Correct != test.wantCorrect {
+			t.Errorf("got %d, want %d correct for %q copied as %q", score.Correct, test.wantCorrect, test.sent, test.copied)
+		}
+		if string(score.Missed) != test.wantMissed {
+			t.Errorf("got %q, want %q missed for %q copied as %q", string(score.Missed), test.wantMissed, test.sent, test.copied)
+		}
+		if score.Total() != len(test.sent) {
+			t.Errorf("got %d, want %d total for %q", score.Total(), len(test.sent), test.sent)
+		}
+	}
+}
+
+func TestCompareConfusion(t *testing.T) {
+	tests := []struct {
+		sent   string
+		copied string
+		want   string // Pairs of sent and copied runes
+	}{
+		{sent: "K5ABC", copied: "KHABC", want: "5H"},
+		{sent: "K3GDS", copied: "K3GDS", want: ""},
+		{sent: "W1AW", copied: "W1W", want: ""},        // Dropped, not confused
+		{sent: "VE3ABC", copied: "VE3ABD", want: "CD"}, // At the end
+		{sent: "DL1ABC", copied: "BL1AVC", want: "DBBV"},
+		{sent: "N2XYZ", copied: "N2XY", want: ""},
+	}
+
+	for _, test := range tests {
+		score := train.Compare(test.sent, test.copied)
+		got := ""
+		for _, confusion := range score.Confusion {
+			got += string(confusion.Sent) + string(confusion.Copied)
+		}
+		if got != test.want {
+			t.Errorf("got %q, want %q confused for %q copied as %q", got, test.want, test.sent, test.copied)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/scottmcleodjr/rekl/bus"
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/transcript"
+)
+
+// transcriptFlags are the flags for the transcript of everything sent.
+type transcriptFlags struct {
+	path   *string
+	format *string
+}
+
+// addTranscriptFlags adds the transcript flags to a flag set.
+func addTranscriptFlags(flags *flag.FlagSet) transcriptFlags {
+	return transcriptFlags{
+		path:   flags.String("transcript", "", "Path of a transcript to append everything sent to, instead of the one in the config file"),
+		format: flags.String("transcript-format", "", `Format of the transcript, "text" or "jsonl"`),
+	}
+}
+
+// open opens the transcript from the flags, or else from the config
+// file, or exits if that fails.  open returns a Recorder writing errors
+// to events, or nil if there is no transcript, and a function to close
+// the transcript.
+func (tf transcriptFlags) open(cfg *config.Config, key string, events bus.Writer) (*transcript.Recorder, func()) {
+	settings := cfg.Transcript()
+	if *tf.path != "" {
+		settings = config.Transcript{Path: *tf.path}
+	}
+	if *tf.format != "" {
+		settings.Format = *tf.format
+	}
+	if settings.Path == "" {
+		return nil, func() {}
+	}
+	format, err := transcript.ParseFormat(settings.Format)
+	if err != nil {
+		log.Fatal(err)
+	}
+	file, err := os.OpenFile(settings.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		log.Fatalf("unable to open transcript: %s", err)
+	}
+	return transcript.New(file, format, key, cfg, events), func() { file.Close() }
+}
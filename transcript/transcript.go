@@ -0,0 +1,284 @@
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scottmcleodjr/cwkeyer"
+	"github.com/scottmcleodjr/rekl/bus"
+	"github.com/scottmcleodjr/rekl/handler"
+	"github.com/scottmcleodjr/rekl/morse"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+// Format is how a transcript is written.
+type Format int
+
+const (
+	FormatText  Format = iota // FormatText is a line of plain text for each message
+	FormatJSONL               // FormatJSONL is a JSON object on each line
+)
+
+// ParseFormat returns the Format for "text" or "jsonl".  An empty name
+// is FormatText.
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "", "text":
+		return FormatText, nil
+	case "jsonl":
+		return FormatJSONL, nil
+	}
+	return FormatText, fmt.Errorf("unknown transcript format %q, want text or jsonl", name)
+}
+
+// Entry is one message sent.
+type Entry struct {
+	Start   time.Time `json:"start"` // When the key first went down
+	End     time.Time `json:"end"`   // When the key last went up, or the message was stopped
+	Speed   int       `json:"speed"` // WPM when the message started
+	Key     string    `json:"key"`   // The key, like "Beep" or "DTR /dev/ttyUSB0"
+	Text    string    `json:"text"`
+	Aborted bool      `json:"aborted"` // If the message was stopped partway
+}
+
+// String returns the Entry as a line of the text format, without the
+// newline.
+func (e Entry) String() string {
+	line := fmt.Sprintf("%s to %s, %d WPM, %s: %s", e.Start.Format(time.RFC3339), e.End.Format(time.RFC3339), e.Speed, e.Key, e.Text)
+	if e.Aborted {
+		line += " (aborted)"
+	}
+	return line
+}
+
+// SpeedProvider is the config.Config method used by Recorder.
+type SpeedProvider interface {
+	Speed() int
+}
+
+// idleUnits is how long the key is up with nothing queued, in dits,
+// before the keyer is taken to be idle.  It is longer than any gap the
+// keyer leaves within a message.
+const idleUnits = 2 * morse.WordGap
+
+// Recorder writes an entry to a transcript for each message sent.  It
+// follows the messages through a Keyer and the key they are sent on,
+// from Keyer and Key, counting the times the key goes down to know when
+// each message starts and ends.  If the count goes wrong, because the
+// keyer failed or keyed a character differently, the messages pending
+// are ended when the keyer is idle, so later messages are recorded
+// right.  Recorder is safe for use from any goroutine.
+type Recorder struct {
+	w      io.Writer
+	format Format
+	key    string
+	speed  SpeedProvider
+	events bus.Writer
+
+	queueMu    sync.Mutex  // Held while a message is queued, so the keyer is not taken to be idle then
+	queueEmpty func() bool // Reports if the keyer's send queue is empty, if it can
+
+	mu      sync.Mutex
+	pending []*sending // Messages queued, oldest first
+	keyDown bool       // If the key is down
+	ups     int        // Times the key has gone up, to find the last idle check
+}
+
+// sending is a message queued, and how far it has been sent.
+type sending struct {
+	entry    Entry
+	elements int // Dits and dahs in the message
+	downs    int // Times the key has gone down for it
+}
+
+// New returns a Recorder writing to w.  The key is the name of the key
+// for the entries, and write errors are written to events.
+func New(w io.Writer, format Format, key string, speed SpeedProvider, events bus.Writer) *Recorder {
+	return &Recorder{w: w, format: format, key: key, speed: speed, events: events}
+}
+
+// Keyer returns keyer with the messages it queues and stops recorded.
+// If keyer has a SendQueueIsEmpty method, like cwkeyer.Keyer, it is
+// used to find when the keyer is idle.
+func (r *Recorder) Keyer(keyer handler.Keyer) handler.Keyer {
+	if q, ok := keyer.(interface{ SendQueueIsEmpty() bool }); ok {
+		r.queueMu.Lock()
+		r.queueEmpty = q.SendQueueIsEmpty
+		r.queueMu.Unlock()
+	}
+	return recordedKeyer{recorder: r, keyer: keyer}
+}
+
+// Key returns key with the times it goes down and up recorded.
+func (r *Recorder) Key(key cwkeyer.Key) cwkeyer.Key {
+	return recordedKey{recorder: r, key: key}
+}
+
+type recordedKeyer struct {
+	recorder *Recorder
+	keyer    handler.Keyer
+}
+
+func (k recordedKeyer) QueueMessage(message string) error {
+	k.recorder.queueMu.Lock()
+	defer k.recorder.queueMu.Unlock()
+	s := k.recorder.queue(message)
+	err := k.keyer.QueueMessage(message)
+	if err != nil && s != nil {
+		k.recorder.remove(s)
+	}
+	return err
+}
+
+func (k recordedKeyer) DrainSendQueue() {
+	k.keyer.DrainSendQueue()
+	k.recorder.Abort()
+}
+
+type recordedKey struct {
+	recorder *Recorder
+	key      cwkeyer.Key
+}
+
+func (k recordedKey) Down() error {
+	err := k.key.Down()
+	k.recorder.down()
+	return err
+}
+
+func (k recordedKey) Up() error {
+	err := k.key.Up()
+	k.recorder.up()
+	return err
+}
+
+// queue adds a message to the pending messages before it is queued on
+// the keyer, so it is there when the key first goes down for it.  A
+// message with nothing to key is not recorded.
+func (r *Recorder) queue(message string) *sending {
+	message = strings.ToUpper(message)
+	elements := 0
+	for _, c := range message {
+		code, _ := morse.Code(c)
+		elements += len(code)
+	}
+	if elements == 0 {
+		return nil
+	}
+	s := &sending{entry: Entry{Key: r.key, Text: message}, elements: elements}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending = append(r.pending, s)
+	return s
+}
+
+// remove removes a message the keyer did not queue.
+func (r *Recorder) remove(s *sending) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, p := range r.pending {
+		if p == s {
+			r.pending = append(r.pending[:i], r.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// Abort records the message being sent as aborted and forgets the ones
+// that were not started, since nothing of them was sent.  Abort is
+// called when the queue is drained, and when the keyer fails, as the
+// key can no longer be counted for the messages pending.
+func (r *Recorder) Abort() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.abort()
+}
+
+// abort is Abort with r.mu held.
+func (r *Recorder) abort() {
+	if len(r.pending) != 0 && r.pending[0].downs != 0 {
+		s := r.pending[0]
+		s.entry.End = time.Now().UTC()
+		s.entry.Aborted = true
+		r.write(s.entry)
+	}
+	r.pending = nil
+}
+
+// down counts the key going down for the oldest message.  The key going
+// down with nothing queued, like from the paddle, is not recorded.
+func (r *Recorder) down() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keyDown = true
+	if len(r.pending) == 0 {
+		return
+	}
+	s := r.pending[0]
+	if s.downs == 0 {
+		s.entry.Start = time.Now().UTC()
+		s.entry.Speed = r.speed.Speed()
+	}
+	s.downs++
+}
+
+// up writes the oldest message when the key goes up after its last
+// element, and checks later if the keyer has gone idle.
+func (r *Recorder) up() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keyDown = false
+	r.ups++
+	if len(r.pending) != 0 && r.pending[0].downs >= r.pending[0].elements {
+		s := r.pending[0]
+		s.entry.End = time.Now().UTC()
+		r.write(s.entry)
+		r.pending = r.pending[1:]
+	}
+	if len(r.pending) != 0 {
+		ups := r.ups
+		time.AfterFunc(idleUnits*morse.Dit(r.speed.Speed()), func() { r.idle(ups) })
+	}
+}
+
+// idle aborts the messages pending if the key has stayed up since the
+// up it was checked for, with nothing left in the send queue, since the
+// keyer has then sent everything it will.
+func (r *Recorder) idle(ups int) {
+	r.queueMu.Lock()
+	defer r.queueMu.Unlock()
+	if r.queueEmpty == nil || !r.queueEmpty() {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.keyDown || r.ups != ups {
+		return
+	}
+	r.abort()
+}
+
+// write writes an entry as one line.  It is called with r.mu held, so
+// entries are written in order.
+func (r *Recorder) write(e Entry) {
+	var line []byte
+	switch r.format {
+	case FormatJSONL:
+		data, err := json.Marshal(e)
+		if err != nil {
+			r.events.WriteEvent(tui.LevelError, fmt.Sprintf("unable to write transcript: %s", err))
+			return
+		}
+		line = append(data, '\n')
+	default:
+		line = []byte(e.String() + "\n")
+	}
+	_, err := r.w.Write(line)
+	if err != nil {
+		r.events.WriteEvent(tui.LevelError, fmt.Sprintf("unable to write transcript: %s", err))
+	}
+}
@@ -0,0 +1,220 @@
+package transcript_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+
+	"github.com/scottmcleodjr/cwkeyer"
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/morse"
+	"github.com/scottmcleodjr/rekl/transcript"
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+// testKeyer is a handler.Keyer that fails to queue when err is set.
+type testKeyer struct {
+	err error
+}
+
+func (k *testKeyer) QueueMessage(message string) error {
+	return k.err
+}
+
+func (k *testKeyer) DrainSendQueue() {}
+
+// idleKeyer is a testKeyer that reports its send queue as empty, as a
+// keyer does once it has taken every character.
+type idleKeyer struct {
+	testKeyer
+}
+
+func (k *idleKeyer) SendQueueIsEmpty() bool {
+	return true
+}
+
+type testKey struct{}
+
+func (testKey) Down() error {
+	return nil
+}
+
+func (testKey) Up() error {
+	return nil
+}
+
+type testWriter struct {
+	messages []string
+}
+
+func (w *testWriter) WriteEvent(level tui.Level, message string) {
+	w.messages = append(w.messages, message)
+}
+
+// keyElements puts the key down and up n times.
+func keyElements(t *testing.T, key interface {
+	Down() error
+	Up() error
+}, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := key.Down(); err != nil {
+			t.Fatal(err)
+		}
+		if err := key.Up(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	var out bytes.Buffer
+	cfg := config.New()
+	cfg.SetSpeed(25)
+	events := &testWriter{}
+	r := transcript.New(&out, transcript.FormatText, "Beep", cfg, events)
+	inner := &testKeyer{}
+	keyer := r.Keyer(inner)
+	key := r.Key(testKey{})
+
+	keyElements(t, key, 2) // Keying with nothing queued, like from the paddle
+	keyer.QueueMessage("cq")
+	keyer.QueueMessage("test")
+	keyElements(t, key, 8) // CQ
+	cfg.SetSpeed(30)
+	keyElements(t, key, 2) // TE of TEST
+	keyer.DrainSendQueue()
+	inner.err = errors.New("queue full")
+	keyer.QueueMessage("QRL?")
+	inner.err = nil
+	keyer.QueueMessage("k")
+	keyer.QueueMessage("e")
+	keyer.DrainSendQueue() // Before the key went down for them
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	wantSuffixes := []string{", 25 WPM, Beep: CQ", ", 30 WPM, Beep: TEST (aborted)"}
+	if len(lines) != len(wantSuffixes) {
+		t.Fatalf("got transcript %q, want %d lines", out.String(), len(wantSuffixes))
+	}
+	for i, want := range wantSuffixes {
+		if !strings.HasSuffix(lines[i], want) {
+			t.Errorf("got line %q, want it to end with %q", lines[i], want)
+		}
+	}
+	if len(events.messages) != 0 {
+		t.Errorf("got events %v, want none", events.messages)
+	}
+}
+
+// checkLines checks that the text transcript has lines with the suffixes.
+func checkLines(t *testing.T, out string, wantSuffixes []string) {
+	t.Helper()
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != len(wantSuffixes) {
+		t.Fatalf("got transcript %q, want %d lines", out, len(wantSuffixes))
+	}
+	for i, want := range wantSuffixes {
+		if !strings.HasSuffix(lines[i], want) {
+			t.Errorf("got line %q, want it to end with %q", lines[i], want)
+		}
+	}
+}
+
+func TestRecorderIdle(t *testing.T) {
+	var out bytes.Buffer
+	cfg := config.New()
+	cfg.SetSpeed(config.MaxSpeed)
+	r := transcript.New(&out, transcript.FormatText, "Beep", cfg, &testWriter{})
+	keyer := r.Keyer(&idleKeyer{})
+	key := r.Key(testKey{})
+
+	// The keyer sends CQ with fewer elements than counted, then goes idle
+	keyer.QueueMessage("cq")
+	keyElements(t, key, 3)
+	time.Sleep(3 * morse.WordGap * morse.Dit(config.MaxSpeed)) // Longer than the idle time
+	keyer.QueueMessage("e")
+	keyElements(t, key, 1)
+
+	checkLines(t, out.String(), []string{"Beep: CQ (aborted)", "Beep: E"})
+}
+
+func TestRecorderAbort(t *testing.T) {
+	var out bytes.Buffer
+	r := transcript.New(&out, transcript.FormatText, "Beep", config.New(), &testWriter{})
+	keyer := r.Keyer(&testKeyer{})
+	key := r.Key(testKey{})
+
+	// The keyer fails partway through CQ, and skips the rest of the queue
+	keyer.QueueMessage("cq")
+	keyer.QueueMessage("test")
+	keyElements(t, key, 3)
+	r.Abort()
+	keyer.QueueMessage("k")
+	keyElements(t, key, 3)
+
+	checkLines(t, out.String(), []string{"Beep: CQ (aborted)", "Beep: K"})
+}
+
+// TestCodesMatchKeyer checks that every rune the keyer sends has a code,
+// so the Recorder counts its elements.
+func TestCodesMatchKeyer(t *testing.T) {
+	for r := rune(0); r <= unicode.MaxRune; r++ {
+		if r == ' ' || !cwkeyer.IsKeyable(r) {
+			continue
+		}
+		if _, ok := morse.Code(unicode.ToUpper(r)); !ok {
+			t.Errorf("got no code for %q, want one as the keyer sends it", r)
+		}
+	}
+}
+
+func TestRecorderJSONL(t *testing.T) {
+	var out bytes.Buffer
+	cfg := config.New()
+	r := transcript.New(&out, transcript.FormatJSONL, "DTR /dev/ttyUSB0", cfg, &testWriter{})
+	keyer := r.Keyer(&testKeyer{})
+	key := r.Key(testKey{})
+
+	before := time.Now().UTC()
+	keyer.QueueMessage("5NN")
+	keyElements(t, key, 9)
+	after := time.Now().UTC()
+
+	var entry transcript.Entry
+	err := json.Unmarshal(out.Bytes(), &entry)
+	if err != nil {
+		t.Fatalf("got error %q for transcript %q", err, out.String())
+	}
+	if entry.Start.Before(before) || entry.End.Before(entry.Start) || entry.End.After(after) {
+		t.Errorf("got start %s and end %s, want them in order between %s and %s", entry.Start, entry.End, before, after)
+	}
+	entry.Start, entry.End = time.Time{}, time.Time{}
+	want := transcript.Entry{Speed: config.InitSpeed, Key: "DTR /dev/ttyUSB0", Text: "5NN"}
+	if entry != want {
+		t.Errorf("got %+v, want %+v", entry, want)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    transcript.Format
+		wantErr bool
+	}{
+		{name: "", want: transcript.FormatText},
+		{name: "text", want: transcript.FormatText},
+		{name: "JSONL", want: transcript.FormatJSONL},
+		{name: "csv", want: transcript.FormatText, wantErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := transcript.ParseFormat(test.name)
+		if got != test.want || (err != nil) != test.wantErr {
+			t.Errorf("got %d and error %v, want %d and error %t for %q", got, err, test.want, test.wantErr, test.name)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package tui
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+const Scrollback = 1000 // Most events of each level kept in the event view
+
+// Event is an event written to a user interface.
+type Event struct {
+	Time    time.Time
+	Level   Level
+	Kind    string // Like "sent" or "error", for filtering
+	Message string
+}
+
+// EventLog is the events written to a user interface, with the level
+// and kinds of events shown.  The TUI and the plain UI keep their events
+// in an EventLog, so they are kept and picked the same way.  EventLog is
+// not safe for concurrent use.
+type EventLog struct {
+	events []Event // The scrollback, oldest first
+	counts map[Level]int
+	level  Level // The lowest Level shown
+	kinds  map[string]bool
+}
+
+// Add keeps an event written now, and returns it and if it is shown.
+// Only the last Scrollback events of each level are kept, so a flood of
+// debug events can't push out the errors, and events that are not
+// picked by the level and kinds are kept but not shown.
+func (l *EventLog) Add(level Level, kind string, message string) (Event, bool) {
+	e := Event{Time: time.Now().UTC(), Level: level, Kind: kind, Message: message}
+	if l.counts == nil {
+		l.counts = map[Level]int{}
+	}
+	l.events = append(l.events, e)
+	l.counts[level]++
+	if l.counts[level] > Scrollback {
+		for i := range l.events {
+			if l.events[i].Level == level {
+				l.events = append(l.events[:i], l.events[i+1:]...)
+				break
+			}
+		}
+		l.counts[level]--
+	}
+	return e, l.Shows(e)
+}
+
+// Shows returns if an event is picked by the level and kinds.  Events
+// with no kind are only shown when no kinds are picked.
+func (l *EventLog) Shows(e Event) bool {
+	return e.Level >= l.level && (len(l.kinds) == 0 || l.kinds[e.Kind])
+}
+
+// Shown returns the events kept that are shown, oldest first.
+func (l *EventLog) Shown() []Event {
+	var shown []Event
+	for _, e := range l.events {
+		if l.Shows(e) {
+			shown = append(shown, e)
+		}
+	}
+	return shown
+}
+
+// Clear removes all events.
+func (l *EventLog) Clear() {
+	l.events = nil
+	l.counts = nil
+}
+
+// Level returns the lowest Level of the events shown.
+func (l *EventLog) Level() Level {
+	return l.level
+}
+
+// SetLevel sets the lowest Level of the events shown.
+func (l *EventLog) SetLevel(level Level) {
+	l.level = level
+}
+
+// Kinds returns the kinds of events shown in order, or none if every
+// kind is shown.
+func (l *EventLog) Kinds() []string {
+	kinds := make([]string, 0, len(l.kinds))
+	for kind := range l.kinds {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// SetKinds sets the kinds of events shown, or shows every kind if there
+// are none.
+func (l *EventLog) SetKinds(kinds []string) {
+	l.kinds = map[string]bool{}
+	for _, kind := range kinds {
+		l.kinds[kind] = true
+	}
+}
+
+// Text returns the events shown as plain text, a line for each with its
+// UTC date and time.
+func (l *EventLog) Text() string {
+	var sb strings.Builder
+	for _, e := range l.Shown() {
+		sb.WriteString(e.Time.Format("2006-01-02 15:04:05 ") + e.Level.Prefix() + e.Message + "\n")
+	}
+	return StripTags(sb.String())
+}
@@ -0,0 +1,202 @@
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// line returns an event as a line of the event view, with a UTC
+// timestamp and a short prefix indicating the level of the message,
+// and the message in the style for its kind.  It is called with
+// t.eventsMu held.
+func (t *TUI) line(e Event, message string) string {
+	style, prefix := t.theme.LevelStyle(e.Level)
+	if kindStyle := t.theme.KindStyle(e.Kind); kindStyle != (Style{}) {
+		message = kindStyle.tag() + message + resetTag
+	}
+	return fmt.Sprintf("%02d:%02d %s%s%s %s\n", e.Time.Hour(), e.Time.Minute(), style.tag(), prefix, resetTag, message)
+}
+
+// WriteEvent writes messages to the event view.
+// WriteEvent prepends a UTC timestamp and a short line
+// prefix indicating the level of the message.
+func (t *TUI) WriteEvent(level Level, message string) {
+	t.WriteEventKind(level, "", message)
+}
+
+// WriteEventKind writes a message to the event view like WriteEvent,
+// with the kind of event it is for filtering, like "sent" or "error".
+// Only the last Scrollback events of each level are kept, and events
+// that are not picked by the event level and kinds are kept but not
+// shown.
+func (t *TUI) WriteEventKind(level Level, kind string, message string) {
+	t.eventsMu.Lock()
+	defer t.eventsMu.Unlock()
+	e, shown := t.events.Add(level, kind, message)
+	if shown {
+		t.eventView.Write([]byte(t.line(e, message)))
+	}
+}
+
+// render writes the events shown to the event view again, with each
+// match of the search in a region, and shows the filter and search in
+// the title.  It is called with t.eventsMu held.
+func (t *TUI) render() {
+	var search *regexp.Regexp
+	if t.search != "" {
+		search = regexp.MustCompile("(?i)" + regexp.QuoteMeta(t.search))
+	}
+	var sb strings.Builder
+	t.matches = 0
+	for _, e := range t.events.Shown() {
+		message := e.Message
+		if search != nil {
+			message = highlight(message, search, &t.matches)
+		}
+		sb.WriteString(t.line(e, message))
+	}
+	t.eventView.Clear()
+	t.eventView.Write([]byte(sb.String()))
+
+	var title []string
+	if t.events.Level() != LevelInfo {
+		title = append(title, fmt.Sprintf("Level: %s", t.events.Level()))
+	}
+	if kinds := t.events.Kinds(); len(kinds) != 0 {
+		title = append(title, fmt.Sprintf("Showing: %s", strings.Join(kinds, ", ")))
+	}
+	if t.search != "" {
+		title = append(title, fmt.Sprintf("Search: %s (%d)", t.search, t.matches))
+	}
+	if len(title) == 0 {
+		t.eventView.SetTitle("")
+		return
+	}
+	t.eventView.SetTitle(" " + tview.Escape(strings.Join(title, "  ")) + " ")
+}
+
+// highlight returns a message with each match of the search in the text
+// it shows in a region, numbered on from matches.  A message with a
+// match loses its own tags, so matches are never put inside a tag.
+func highlight(message string, search *regexp.Regexp, matches *int) string {
+	plain := StripTags(message)
+	found := search.FindAllStringIndex(plain, -1)
+	if len(found) == 0 {
+		return message
+	}
+	var sb strings.Builder
+	last := 0
+	for _, match := range found {
+		sb.WriteString(tview.Escape(plain[last:match[0]]))
+		sb.WriteString(fmt.Sprintf(`["%d"]%s[""]`, *matches, tview.Escape(plain[match[0]:match[1]])))
+		*matches++
+		last = match[1]
+	}
+	sb.WriteString(tview.Escape(plain[last:]))
+	return sb.String()
+}
+
+// EventLevel returns the lowest Level of the events shown in the event view.
+func (t *TUI) EventLevel() Level {
+	t.eventsMu.Lock()
+	defer t.eventsMu.Unlock()
+	return t.events.Level()
+}
+
+// SetEventLevel sets the lowest Level of the events shown in the event
+// view, and shows the events kept again.  SetEventLevel ends a search,
+// and must be called from the event loop.
+func (t *TUI) SetEventLevel(level Level) {
+	t.eventsMu.Lock()
+	defer t.eventsMu.Unlock()
+	t.events.SetLevel(level)
+	t.endSearch()
+	t.render()
+}
+
+// SetEventKinds sets the kinds of events shown in the event view, or
+// shows every kind if there are none, and shows the events kept again.
+// SetEventKinds ends a search, and must be called from the event loop.
+func (t *TUI) SetEventKinds(kinds []string) {
+	t.eventsMu.Lock()
+	defer t.eventsMu.Unlock()
+	t.events.SetKinds(kinds)
+	t.endSearch()
+	t.render()
+}
+
+// SearchEvents highlights the text in the events shown, ignoring case,
+// and scrolls back to the matches.  SearchEvents returns the number of
+// matches, and empty text ends the search.  SearchEvents must be called
+// from the event loop.
+func (t *TUI) SearchEvents(text string) int {
+	t.eventsMu.Lock()
+	defer t.eventsMu.Unlock()
+	t.search = text
+	t.render()
+	regions := make([]string, t.matches)
+	for i := range regions {
+		regions[i] = fmt.Sprint(i)
+	}
+	t.eventView.Highlight(regions...)
+	if t.matches == 0 {
+		t.following = true
+		return 0
+	}
+	t.eventView.ScrollToHighlight()
+	t.following = false
+	t.pageTarget = 0
+	return t.matches
+}
+
+// endSearch removes the highlights of a search and follows new events
+// again.  It is called with t.eventsMu held.
+func (t *TUI) endSearch() {
+	t.search = ""
+	t.eventView.Highlight()
+	t.following = true
+}
+
+// EventText returns the events shown in the event view as plain text,
+// a line for each with its UTC date and time.
+func (t *TUI) EventText() string {
+	t.eventsMu.Lock()
+	defer t.eventsMu.Unlock()
+	return t.events.Text()
+}
+
+// StripTags returns text without the colour tags of the event view,
+// as it shows the text.
+func StripTags(text string) string {
+	plain := tview.NewTextView().SetDynamicColors(true)
+	plain.SetText(text)
+	return plain.GetText(true)
+}
+
+// ClearEvents removes all events from the event view and ends a search.
+// ClearEvents must be called from the event loop.
+func (t *TUI) ClearEvents() {
+	t.eventsMu.Lock()
+	defer t.eventsMu.Unlock()
+	t.events.Clear()
+	t.endSearch()
+	t.render()
+}
+
+// pageEvents scrolls the event view back a page if direction is
+// negative, and forward a page otherwise.  Paging forward past the end
+// follows new events again.
+func (t *TUI) pageEvents(direction int) {
+	_, _, _, height := t.eventView.GetInnerRect()
+	row, _ := t.eventView.GetScrollOffset()
+	row += direction * height
+	if row < 0 {
+		row = 0
+	}
+	t.eventView.ScrollTo(row, 0)
+	t.following = false
+	t.pageTarget = row
+}
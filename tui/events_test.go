@@ -0,0 +1,158 @@
+package tui_test
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+// timestamp matches the UTC date and time at the start of each line of
+// the event text.
+var timestamp = regexp.MustCompile(`(?m)^\d{4}-\d\d-\d\d \d\d:\d\d:\d\d `)
+
+func TestScrollback(t *testing.T) {
+	ui := tui.New()
+	for i := 0; i < tui.Scrollback+5; i++ {
+		ui.WriteEvent(tui.LevelInfo, fmt.Sprintf("Event %d", i))
+	}
+
+	lines := strings.Split(strings.TrimSuffix(ui.EventText(), "\n"), "\n")
+	if len(lines) != tui.Scrollback {
+		t.Fatalf("got %d events, want the last %d", len(lines), tui.Scrollback)
+	}
+	first := timestamp.ReplaceAllString(lines[0], "")
+	last := timestamp.ReplaceAllString(lines[len(lines)-1], "")
+	if first != "Event 5" || last != fmt.Sprintf("Event %d", tui.Scrollback+4) {
+		t.Errorf("got events %q to %q, want Event 5 to Event %d", first, last, tui.Scrollback+4)
+	}
+}
+
+func TestScrollbackByLevel(t *testing.T) {
+	ui := tui.New()
+	ui.WriteEvent(tui.LevelError, "key port closed")
+	ui.WriteEvent(tui.LevelInfo, "Sending: CQ TEST")
+	for i := 0; i < 2*tui.Scrollback; i++ {
+		ui.WriteEvent(tui.LevelDebug, fmt.Sprintf("Debug %d", i))
+	}
+
+	// The debug events only push out older debug events
+	ui.SetEventLevel(tui.LevelError)
+	if got := timestamp.ReplaceAllString(ui.EventText(), ""); got != "Error: key port closed\n" {
+		t.Errorf("got %q, want the error kept after a flood of debug events", got)
+	}
+	ui.SetEventLevel(tui.LevelDebug)
+	lines := strings.Split(strings.TrimSuffix(ui.EventText(), "\n"), "\n")
+	if len(lines) != tui.Scrollback+2 {
+		t.Errorf("got %d events, want %d debug events and the others", len(lines), tui.Scrollback)
+	}
+	if got := timestamp.ReplaceAllString(lines[2], ""); got != fmt.Sprintf("Debug: Debug %d", tui.Scrollback) {
+		t.Errorf("got %q, want the oldest debug event kept after the others", got)
+	}
+}
+
+func TestSearchEvents(t *testing.T) {
+	ui := tui.New()
+	ui.WriteEventKind(tui.LevelInfo, "sent", "Sending: CQ TEST K3GDS")
+	ui.WriteEventKind(tui.LevelInfo, "sent", "Sending: K3GDS k3gds 5NN")
+	ui.WriteEventKind(tui.LevelError, "error", "unknown call K3GDS")
+	ui.WriteEventKind(tui.LevelDebug, "key", "K3GDS key down")
+
+	tests := []struct {
+		text string
+		want int
+	}{
+		{text: "K3GDS", want: 4}, // Ignores case, and not the hidden debug event
+		{text: "5nn", want: 1},
+		{text: "W1AW", want: 0},
+		{text: "", want: 0}, // Ends the search
+	}
+
+	for _, test := range tests {
+		got := ui.SearchEvents(test.text)
+		if got != test.want {
+			t.Errorf("got %d matches, want %d for %q", got, test.want, test.text)
+		}
+	}
+
+	// Only the events shown are searched
+	ui.SetEventKinds([]string{"error"})
+	if got := ui.SearchEvents("K3GDS"); got != 1 {
+		t.Errorf("got %d matches, want 1 in the error events", got)
+	}
+}
+
+func TestSearchEventsTags(t *testing.T) {
+	ui := tui.New()
+	ui.WriteEvent(tui.LevelInfo, "[::b]Welcome[::-] to the REKL")
+	ui.WriteEvent(tui.LevelWarn, "[red]Bad[-] [yellow::u]key[-::-] [x[]")
+
+	tests := []struct {
+		text string
+		want int
+	}{
+		{text: "b", want: 1}, // Only in "Bad", not in the tags
+		{text: "::", want: 0},
+		{text: "red", want: 0},
+		{text: "bad key", want: 1},
+		{text: "[x]", want: 1}, // Escaped text is searched as it is shown
+	}
+
+	for _, test := range tests {
+		got := ui.SearchEvents(test.text)
+		if got != test.want {
+			t.Errorf("got %d matches, want %d for %q", got, test.want, test.text)
+		}
+	}
+	want := "Welcome to the REKL\nWarning: Bad key [x]\n"
+	if got := timestamp.ReplaceAllString(ui.EventText(), ""); got != want {
+		t.Errorf("got %q, want %q after searching", got, want)
+	}
+}
+
+func TestEventText(t *testing.T) {
+	ui := tui.New()
+	ui.WriteEventKind(tui.LevelInfo, "sent", "Sending: [::b]5NN[::-] 001")
+	ui.WriteEvent(tui.LevelWarn, "Speed [red]limited[-]")
+	ui.WriteEvent(tui.LevelDebug, "Key down.") // Below the event level
+
+	got := ui.EventText()
+	if n := len(timestamp.FindAllString(got, -1)); n != 2 {
+		t.Errorf("got %d timestamps in %q, want one for each event shown", n, got)
+	}
+	want := "Sending: 5NN 001\nWarning: Speed limited\n"
+	if got := timestamp.ReplaceAllString(got, ""); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	ui.SetEventLevel(tui.LevelDebug)
+	if got := timestamp.ReplaceAllString(ui.EventText(), ""); got != want+"Debug: Key down.\n" {
+		t.Errorf("got %q, want the debug event after lowering the level", got)
+	}
+	ui.ClearEvents()
+	if got := ui.EventText(); got != "" {
+		t.Errorf("got %q, want no events after clearing", got)
+	}
+}
+
+func TestStripTags(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "Sending: CQ TEST", want: "Sending: CQ TEST"},
+		{text: "[red]Error[-] queue full", want: "Error queue full"},
+		{text: "[::b]5NN[::-] [yellow:black:u]001[-:-:-]", want: "5NN 001"},
+		{text: `["0"]K3GDS[""] again`, want: "K3GDS again"},
+		{text: "", want: ""},
+	}
+
+	for _, test := range tests {
+		got := tui.StripTags(test.text)
+		if got != test.want {
+			t.Errorf("got %q, want %q for %q", got, test.want, test.text)
+		}
+	}
+}
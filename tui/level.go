@@ -0,0 +1,72 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Level is how important an event is.  Levels are in order, so the
+// event view can show only the events at or above a level.
+type Level int
+
+const (
+	LevelDebug Level = iota - 1 // LevelDebug is for details that the event view hides by default
+	LevelInfo                   // LevelInfo is for information messages written to the event view
+	LevelWarn                   // LevelWarn is for problems that did not stop anything
+	LevelError                  // LevelError is for error messages written to the event view
+)
+
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+// String returns the name of the Level, like "info".
+func (l Level) String() string {
+	name, ok := levelNames[l]
+	if !ok {
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+	return name
+}
+
+// Prefix returns the word that marks events of the Level in plain
+// text, like "Error: ", or nothing for LevelInfo.
+func (l Level) Prefix() string {
+	switch l {
+	case LevelDebug:
+		return "Debug: "
+	case LevelWarn:
+		return "Warning: "
+	case LevelError:
+		return "Error: "
+	}
+	return ""
+}
+
+// ParseLevel returns the Level with a name, like "info" or "WARN".
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown level %q, want debug, info, warn or error", name)
+}
+
+// MarshalText returns the name of the Level, so it is a string in JSON.
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText sets the Level from its name.
+func (l *Level) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
@@ -0,0 +1,266 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Style is the colours and attributes of a part of the TUI.  Colours
+// are names like "red" or "#ff8800", or "default" for the terminal's
+// own, and attributes are tview style letters like "b" for bold or "r"
+// for reverse.  Empty colours are left as they are.
+type Style struct {
+	Foreground string
+	Background string
+	Attributes string
+}
+
+// tag returns the tview style tag for the Style.
+func (s Style) tag() string {
+	return fmt.Sprintf("[%s:%s:%s]", s.Foreground, s.Background, s.Attributes)
+}
+
+// resetTag ends the text in a Style.
+const resetTag = "[-:-:-]"
+
+// check returns an error if a colour or attribute is not known.
+func (s Style) check() error {
+	for _, name := range []string{s.Foreground, s.Background} {
+		if _, ok := parseColor(name); !ok && name != "" {
+			return fmt.Errorf("unknown colour %q", name)
+		}
+	}
+	if strings.Trim(s.Attributes, "lbidrus") != "" {
+		return fmt.Errorf("unknown attributes %q, want letters from lbidrus", s.Attributes)
+	}
+	return nil
+}
+
+// parseColor returns the colour for a name, or false if it is empty or
+// not known.
+func parseColor(name string) (tcell.Color, bool) {
+	name = strings.ToLower(name)
+	if name == "default" {
+		return tcell.ColorDefault, true
+	}
+	if c, ok := tcell.ColorNames[name]; ok {
+		return c, true
+	}
+	if len(name) == 7 && name[0] == '#' {
+		if _, err := strconv.ParseUint(name[1:], 16, 32); err == nil {
+			return tcell.GetColor(name), true
+		}
+	}
+	return tcell.ColorDefault, false
+}
+
+// Theme is the look of the TUI.  Attributes are only used for the TX
+// light, the event levels and the event kinds.
+type Theme struct {
+	Text    Style // The event view and received text
+	Border  Style // Borders and titles, only the foreground is used
+	Input   Style // The input field
+	Status  Style // The status bar
+	Labels  Style // The label bar
+	TX      Style // The TX light in the status bar
+	Debug   Style // The prefixes of events by level
+	Info    Style
+	Warn    Style
+	Error   Style
+	Sent    Style // The messages of events by kind, other kinds use Text
+	Aborted Style
+	Speed   Style
+	QSO     Style
+	Symbols bool // If events are marked with a symbol for their level, for themes without colour
+}
+
+// Presets are the names of the built-in themes.
+var Presets = []string{"default", "high-contrast", "monochrome"}
+
+// Preset returns a built-in theme by name.  An empty name is the
+// default theme.
+func Preset(name string) (Theme, error) {
+	switch strings.ToLower(name) {
+	case "", "default":
+		return Theme{
+			TX:      Style{Foreground: "white", Background: "red", Attributes: "b"},
+			Debug:   Style{Foreground: "gray", Attributes: "b"},
+			Info:    Style{Foreground: "green", Attributes: "b"},
+			Warn:    Style{Foreground: "yellow", Attributes: "b"},
+			Error:   Style{Foreground: "red", Attributes: "b"},
+			Sent:    Style{Foreground: "aqua"},
+			Aborted: Style{Foreground: "gray"},
+			QSO:     Style{Foreground: "green"},
+		}, nil
+	case "high-contrast":
+		return Theme{
+			Text:    Style{Foreground: "white", Background: "black"},
+			Border:  Style{Foreground: "yellow"},
+			Input:   Style{Foreground: "black", Background: "white"},
+			Status:  Style{Foreground: "black", Background: "white"},
+			Labels:  Style{Foreground: "black", Background: "white"},
+			TX:      Style{Foreground: "black", Background: "yellow", Attributes: "b"},
+			Debug:   Style{Foreground: "silver"},
+			Info:    Style{Foreground: "white", Attributes: "b"},
+			Warn:    Style{Foreground: "yellow", Attributes: "b"},
+			Error:   Style{Foreground: "black", Background: "yellow", Attributes: "b"},
+			Sent:    Style{Foreground: "aqua", Attributes: "b"},
+			Aborted: Style{Foreground: "silver", Attributes: "s"},
+			QSO:     Style{Foreground: "yellow"},
+			Symbols: true,
+		}, nil
+	case "monochrome":
+		return Theme{
+			Text:    Style{Foreground: "default", Background: "default"},
+			Border:  Style{Foreground: "default"},
+			Input:   Style{Foreground: "default", Background: "default"},
+			Status:  Style{Foreground: "default", Background: "default"},
+			Labels:  Style{Foreground: "default", Background: "default"},
+			TX:      Style{Attributes: "rb"},
+			Debug:   Style{Attributes: "d"},
+			Info:    Style{Attributes: "b"},
+			Warn:    Style{Attributes: "b"},
+			Error:   Style{Attributes: "rb"},
+			Sent:    Style{Attributes: "b"},
+			Aborted: Style{Attributes: "s"},
+			Symbols: true,
+		}, nil
+	}
+	return Theme{}, fmt.Errorf("unknown theme %q, want %s", name, strings.Join(Presets, ", "))
+}
+
+// SetStyle sets the Style of a part of the Theme by name, like "error",
+// "status" or the "sent" kind of event.  SetStyle returns an error if the part, a colour or an
+// attribute is not known.
+func (th *Theme) SetStyle(part string, s Style) error {
+	styles := map[string]*Style{
+		"text":    &th.Text,
+		"border":  &th.Border,
+		"input":   &th.Input,
+		"status":  &th.Status,
+		"labels":  &th.Labels,
+		"tx":      &th.TX,
+		"debug":   &th.Debug,
+		"info":    &th.Info,
+		"warn":    &th.Warn,
+		"error":   &th.Error,
+		"sent":    &th.Sent,
+		"aborted": &th.Aborted,
+		"speed":   &th.Speed,
+		"qso":     &th.QSO,
+	}
+	style, ok := styles[strings.ToLower(part)]
+	if !ok {
+		return fmt.Errorf("unknown theme part %q", part)
+	}
+	err := s.check()
+	if err != nil {
+		return fmt.Errorf("theme part %s: %w", part, err)
+	}
+	*style = s
+	return nil
+}
+
+// LevelStyle returns the Style and prefix for events of a Level.  The
+// prefix starts with a symbol for the level if the Theme uses them.
+func (th Theme) LevelStyle(level Level) (Style, string) {
+	symbol := ">"
+	switch level {
+	case LevelDebug:
+		if th.Symbols {
+			symbol = "-"
+		}
+		return th.Debug, symbol + " Debug:"
+	case LevelWarn:
+		if th.Symbols {
+			symbol = "!"
+		}
+		return th.Warn, symbol + " Warning:"
+	case LevelError:
+		if th.Symbols {
+			symbol = "X"
+		}
+		return th.Error, symbol + " Error:"
+	}
+	return th.Info, symbol
+}
+
+// KindStyle returns the Style for the messages of events of a kind, like
+// "sent".  KindStyle returns an empty Style, which leaves the text as
+// it is, for other kinds.
+func (th Theme) KindStyle(kind string) Style {
+	switch kind {
+	case "sent":
+		return th.Sent
+	case "aborted":
+		return th.Aborted
+	case "speed":
+		return th.Speed
+	case "qso":
+		return th.QSO
+	}
+	return Style{}
+}
+
+// setColor calls set with the colour for a name, unless it is empty.
+func setColor(name string, set func(c tcell.Color)) {
+	if c, ok := parseColor(name); ok {
+		set(c)
+	}
+}
+
+// SetTheme sets the look of the TUI and shows the events again with it.
+// SetTheme must be called before RunApp or from the event loop.
+func (t *TUI) SetTheme(th Theme) {
+	for _, view := range []*tview.TextView{t.eventView, t.receiveView} {
+		setColor(th.Text.Foreground, func(c tcell.Color) { view.SetTextColor(c) })
+		setColor(th.Text.Background, func(c tcell.Color) { view.SetBackgroundColor(c) })
+		setColor(th.Border.Foreground, func(c tcell.Color) { view.SetBorderColor(c).SetTitleColor(c) })
+	}
+	setColor(th.Text.Foreground, func(c tcell.Color) { t.inputForm.SetLabelColor(c) })
+	setColor(th.Text.Background, func(c tcell.Color) { t.inputForm.SetBackgroundColor(c) })
+	setColor(th.Border.Foreground, func(c tcell.Color) { t.inputForm.SetBorderColor(c).SetTitleColor(c) })
+	setColor(th.Input.Foreground, func(c tcell.Color) { t.inputForm.SetFieldTextColor(c) })
+	setColor(th.Input.Background, func(c tcell.Color) { t.inputForm.SetFieldBackgroundColor(c) })
+	setColor(th.Status.Foreground, func(c tcell.Color) { t.statusBar.SetTextColor(c) })
+	setColor(th.Status.Background, func(c tcell.Color) { t.statusBar.SetBackgroundColor(c) })
+	setColor(th.Labels.Foreground, func(c tcell.Color) { t.labelBar.SetTextColor(c) })
+	setColor(th.Labels.Background, func(c tcell.Color) { t.labelBar.SetBackgroundColor(c) })
+
+	t.eventsMu.Lock()
+	defer t.eventsMu.Unlock()
+	t.theme = th
+	t.render()
+}
+
+// Layout is the sizes of the panes of the TUI.
+type Layout struct {
+	Input   int // Rows of the input form, with its border
+	Events  int // Share of the rows left for the event view
+	Receive int // Share of the rows left for the received text pane
+}
+
+// DefaultLayout returns the usual Layout.
+func DefaultLayout() Layout {
+	return Layout{Input: 5, Events: 2, Receive: 1}
+}
+
+// SetLayout sets the sizes of the panes.  SetLayout returns an error if
+// the input form is too small to show the input field or a share is not
+// positive.  SetLayout must be called before RunApp or from the event
+// loop.
+func (t *TUI) SetLayout(l Layout) error {
+	if l.Input < 3 {
+		return fmt.Errorf("input form of %d rows is too small, want at least 3", l.Input)
+	}
+	if l.Events < 1 || l.Receive < 1 {
+		return fmt.Errorf("pane shares %d and %d must be at least 1", l.Events, l.Receive)
+	}
+	t.sizes = l
+	t.layout()
+	return nil
+}
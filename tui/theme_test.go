@@ -0,0 +1,136 @@
+package tui_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/scottmcleodjr/rekl/tui"
+)
+
+func TestPreset(t *testing.T) {
+	for _, name := range tui.Presets {
+		if _, err := tui.Preset(name); err != nil {
+			t.Errorf("got error %q, want preset %q", err, name)
+		}
+	}
+
+	defaultTheme, _ := tui.Preset("default")
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: ""},
+		{name: "High-Contrast"},
+		{name: "MONOCHROME"},
+		{name: "solarized", wantErr: true},
+	}
+
+	for _, test := range tests {
+		theme, err := tui.Preset(test.name)
+		if test.wantErr != (err != nil) {
+			t.Errorf("got error %v, want error %t for %q", err, test.wantErr, test.name)
+		}
+		if test.name == "" && theme != defaultTheme {
+			t.Errorf("got %+v, want the default theme for an empty name", theme)
+		}
+	}
+}
+
+func TestSetStyle(t *testing.T) {
+	tests := []struct {
+		part    string
+		style   tui.Style
+		wantErr string
+	}{
+		{part: "status", style: tui.Style{Foreground: "black", Background: "white"}},
+		{part: "Error", style: tui.Style{Foreground: "#ff8800", Attributes: "rb"}},
+		{part: "sent", style: tui.Style{Foreground: "default", Attributes: "u"}},
+		{part: "aborted", style: tui.Style{Attributes: "s"}},
+		{part: "tx", style: tui.Style{Foreground: "blurple"}, wantErr: `unknown colour "blurple"`},
+		{part: "info", style: tui.Style{Background: "#ff88"}, wantErr: `unknown colour "#ff88"`},
+		{part: "qso", style: tui.Style{Background: "#gg8800"}, wantErr: `unknown colour "#gg8800"`},
+		{part: "warn", style: tui.Style{Attributes: "bx"}, wantErr: `unknown attributes "bx"`},
+		{part: "title", style: tui.Style{Foreground: "red"}, wantErr: `unknown theme part "title"`},
+	}
+
+	for _, test := range tests {
+		theme, _ := tui.Preset("default")
+		before := theme
+		err := theme.SetStyle(test.part, test.style)
+		if test.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("got error %v, want %q for %s", err, test.wantErr, test.part)
+			}
+			if theme != before {
+				t.Errorf("got theme changed by a bad style for %s", test.part)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("got error %q, want nil for %s", err, test.part)
+		}
+		if theme == before {
+			t.Errorf("got theme unchanged, want the style set for %s", test.part)
+		}
+	}
+
+	// Kinds of events get their styles
+	theme, _ := tui.Preset("default")
+	sent := tui.Style{Foreground: "navy"}
+	theme.SetStyle("sent", sent)
+	if got := theme.KindStyle("sent"); got != sent {
+		t.Errorf("got %+v, want %+v for sent events", got, sent)
+	}
+	if got := theme.KindStyle("message"); got != (tui.Style{}) {
+		t.Errorf("got %+v, want no style for message events", got)
+	}
+}
+
+func TestLevelStyle(t *testing.T) {
+	tests := []struct {
+		preset string
+		level  tui.Level
+		want   string
+	}{
+		{preset: "default", level: tui.LevelDebug, want: "> Debug:"},
+		{preset: "default", level: tui.LevelInfo, want: ">"},
+		{preset: "default", level: tui.LevelWarn, want: "> Warning:"},
+		{preset: "default", level: tui.LevelError, want: "> Error:"},
+		{preset: "monochrome", level: tui.LevelDebug, want: "- Debug:"},
+		{preset: "monochrome", level: tui.LevelInfo, want: ">"},
+		{preset: "monochrome", level: tui.LevelWarn, want: "! Warning:"},
+		{preset: "monochrome", level: tui.LevelError, want: "X Error:"},
+		{preset: "high-contrast", level: tui.LevelError, want: "X Error:"},
+	}
+
+	for _, test := range tests {
+		theme, _ := tui.Preset(test.preset)
+		style, got := theme.LevelStyle(test.level)
+		if got != test.want {
+			t.Errorf("got %q, want %q for %s in %s", got, test.want, test.level, test.preset)
+		}
+		if test.level == tui.LevelError && style != theme.Error {
+			t.Errorf("got %+v, want the error style for %s in %s", style, test.level, test.preset)
+		}
+	}
+}
+
+func TestSetLayout(t *testing.T) {
+	tests := []struct {
+		layout  tui.Layout
+		wantErr bool
+	}{
+		{layout: tui.DefaultLayout()},
+		{layout: tui.Layout{Input: 3, Events: 1, Receive: 1}},
+		{layout: tui.Layout{Input: 2, Events: 2, Receive: 1}, wantErr: true},
+		{layout: tui.Layout{Input: 5, Events: 0, Receive: 1}, wantErr: true},
+		{layout: tui.Layout{Input: 5, Events: 2, Receive: -1}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		err := tui.New().SetLayout(test.layout)
+		if test.wantErr != (err != nil) {
+			t.Errorf("got error %v, want error %t for %+v", err, test.wantErr, test.layout)
+		}
+	}
+}
@@ -2,72 +2,206 @@ package tui
 
 import (
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
 
-type Level int
-
-const (
-	LevelInfo  Level = iota // LevelInfo is for information messages written to the event view
-	LevelError              // LevelError is for error messages written to the event view
-)
-
 // TUI is the application's tview terminal UI.
 type TUI struct {
-	eventView  *tview.TextView
-	inputField *tview.InputField
-	app        *tview.Application
+	eventView   *tview.TextView
+	receiveView *tview.TextView
+	inputField  *tview.InputField
+	inputForm   *tview.Form
+	labelBar    *tview.TextView
+	statusBar   *tview.TextView
+	flex        *tview.Flex
+	app         *tview.Application
+	showReceive bool
+	showStatus  bool
+	sizes       Layout
+	theme       Theme // Guarded by eventsMu, as events are written with it
+	following   bool  // If the event view follows new events, used in the event loop
+	pageTarget  int   // The row the event view was paged to, used in the event loop
+	eventsMu    sync.Mutex
+	events      EventLog
+	search      string // The text highlighted in the event view
+	matches     int    // The number of matches of the search
 }
 
 // New returns a new tui.
 func New() *TUI {
 	eventView := tview.NewTextView()
-	eventView.SetDynamicColors(true).SetBorder(true)
+	eventView.SetDynamicColors(true).SetRegions(true).SetMaxLines(Scrollback).SetBorder(true)
 
 	inputField := tview.NewInputField().SetLabel("Input:")
+
+	inputForm := tview.NewForm().AddFormItem(inputField)
+	inputForm.SetBorder(true)
+
+	labelBar := tview.NewTextView()
+	labelBar.SetDynamicColors(true)
+
+	statusBar := tview.NewTextView()
+	statusBar.SetDynamicColors(true)
+
+	flex := tview.NewFlex().SetDirection(tview.FlexRow)
+	app := tview.NewApplication().SetRoot(flex, true)
+
+	receiveView := tview.NewTextView()
+	receiveView.SetWordWrap(true).SetBorder(true).SetTitle(" Received ")
+
+	t := &TUI{
+		eventView:   eventView,
+		receiveView: receiveView,
+		inputField:  inputField,
+		inputForm:   inputForm,
+		labelBar:    labelBar,
+		statusBar:   statusBar,
+		flex:        flex,
+		app:         app,
+		following:   true,
+		sizes:       DefaultLayout(),
+	}
+	t.theme, _ = Preset("default")
 	inputField.SetDrawFunc(func(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
+		// The event view is drawn first, so paging past the end has
+		// stopped at the end and it can follow new events again
+		if !t.following {
+			row, _ := eventView.GetScrollOffset()
+			t.following = row < t.pageTarget
+		}
 		// This helps keep things lined up correctly if you resize the window
-		eventView.ScrollToEnd()
+		if t.following {
+			eventView.ScrollToEnd()
+		}
 		return x, y, width, height
 	})
+	t.layout()
+	return t
+}
 
-	inputForm := tview.NewForm().AddFormItem(inputField)
-	inputForm.SetBorder(true)
+// layout adds the shown views to the flex, from top to bottom.
+func (t *TUI) layout() {
+	t.flex.Clear()
+	if t.showStatus {
+		t.flex.AddItem(t.statusBar, 1, 0, false)
+	}
+	if t.showReceive {
+		t.flex.AddItem(t.eventView, 0, t.sizes.Events, false).
+			AddItem(t.receiveView, 0, t.sizes.Receive, false)
+	} else {
+		t.flex.AddItem(t.eventView, 0, 1, false)
+	}
+	t.flex.AddItem(t.inputForm, t.sizes.Input, 0, true).
+		AddItem(t.labelBar, 1, 0, false)
+}
 
-	flex := tview.NewFlex().SetDirection(tview.FlexRow).
-		AddItem(eventView, 0, 1, false).
-		AddItem(inputForm, 5, 0, true)
-	app := tview.NewApplication().SetRoot(flex, true)
+// ShowReceivePane adds the pane for received text between
+// the event view and the input field.
+func (t *TUI) ShowReceivePane() {
+	t.showReceive = true
+	t.layout()
+}
+
+// ShowStatusBar adds the status bar above the event view.
+func (t *TUI) ShowStatusBar() {
+	t.showStatus = true
+	t.layout()
+}
+
+// Status is what the status bar shows.
+type Status struct {
+	WPM        int
+	Farnsworth int    // Zero if Farnsworth spacing is off
+	Key        string // The key backend and port
+	KeyOK      bool   // If the key worked the last time it was used
+	Sending    bool   // If there are queued messages
+	TX         bool   // If the key is down
+	Bank       string // The active memory bank
+	Call       string // The current call
+	Time       time.Time
+}
 
-	return &TUI{
-		eventView:  eventView,
-		inputField: inputField,
-		app:        app,
+// SetStatus shows the status in the status bar.  SetStatus must be
+// called from the event loop.
+func (t *TUI) SetStatus(s Status) {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("[::b]%d WPM[::-]", s.WPM))
+	if s.Farnsworth != 0 {
+		sb.WriteString(fmt.Sprintf("  FW %d", s.Farnsworth))
+	}
+	sb.WriteString(fmt.Sprintf("  %s ", tview.Escape(s.Key)))
+	if s.KeyOK {
+		sb.WriteString(t.theme.Info.tag() + "OK" + resetTag)
+	} else {
+		sb.WriteString(t.theme.Error.tag() + "ERROR" + resetTag)
+	}
+	if s.TX {
+		sb.WriteString("  " + t.theme.TX.tag() + " TX " + resetTag)
+	} else {
+		sb.WriteString("      ")
+	}
+	if s.Sending {
+		sb.WriteString("  Sending")
+	} else {
+		sb.WriteString("  Idle   ")
 	}
+	sb.WriteString(fmt.Sprintf("  Bank: %s", tview.Escape(s.Bank)))
+	if s.Call != "" {
+		sb.WriteString(fmt.Sprintf("  Call: %s", s.Call))
+	}
+	sb.WriteString(fmt.Sprintf("  %s UTC", s.Time.Format("15:04:05")))
+	t.statusBar.SetText(sb.String())
 }
 
-// WriteEvent writes messages to the event view.
-// WriteEvent prepends a UTC timestamp and a short line
-// prefix indicating if the message is information or an error.
-func (t *TUI) WriteEvent(level Level, message string) {
-	utcTime := time.Now().UTC()
-	var linePrefix string
-	switch level {
-	case LevelInfo:
-		linePrefix = fmt.Sprintf("%02d:%02d [green::b]>[-::-]", utcTime.Hour(), utcTime.Minute())
-	case LevelError:
-		linePrefix = fmt.Sprintf("%02d:%02d [red::b]> Error:[-::-]", utcTime.Hour(), utcTime.Minute())
+// WriteReceived appends decoded text to the received text pane.
+// WriteReceived must be called from outside the event loop, and
+// blocks until the application is running.
+func (t *TUI) WriteReceived(text string) {
+	t.app.QueueUpdateDraw(func() {
+		t.receiveView.Write([]byte(text))
+		t.receiveView.ScrollToEnd()
+	})
+}
+
+// SetReceivedWPM shows the estimated speed of the received text.
+// SetReceivedWPM must be called from outside the event loop.
+func (t *TUI) SetReceivedWPM(wpm int) {
+	t.app.QueueUpdateDraw(func() {
+		t.receiveView.SetTitle(fmt.Sprintf(" Received %d WPM ", wpm))
+	})
+}
+
+// SetCurrentCall shows the current call in the input form title.
+// SetCurrentCall must be called from the event loop.
+func (t *TUI) SetCurrentCall(call string) {
+	if call == "" {
+		t.inputForm.SetTitle("")
+		return
 	}
-	line := fmt.Sprintf("%s %s\n", linePrefix, message)
-	t.eventView.Write([]byte(line))
+	t.inputForm.SetTitle(fmt.Sprintf(" Call: %s ", call))
 }
 
-// ClearEvents removes all text from the event view.
-func (t *TUI) ClearEvents() {
-	t.eventView.Clear()
+// KeyLabel is a key and the label of what it does, for the label bar.
+type KeyLabel struct {
+	Key   string
+	Label string
+}
+
+// SetKeyLabels shows the active memory bank and the labels in the bar
+// under the input field, like the function key labels of a contest
+// logger.  SetKeyLabels must be called from the event loop.
+func (t *TUI) SetKeyLabels(bank string, labels []KeyLabel) {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("[::b]%s[::-]  ", tview.Escape(bank)))
+	for _, label := range labels {
+		sb.WriteString(fmt.Sprintf("[::r]%s[::-] %s  ", label.Key, tview.Escape(label.Label)))
+	}
+	t.labelBar.SetText(sb.String())
 }
 
 // InputText returns the current content of the input field.
@@ -75,14 +209,37 @@ func (t *TUI) InputText() string {
 	return t.inputField.GetText()
 }
 
+// SetInputText replaces the content of the input field.
+func (t *TUI) SetInputText(text string) {
+	t.inputField.SetText(text)
+}
+
 // ClearInputText clears the current content of the input field.
 func (t *TUI) ClearInputText() {
 	t.inputField.SetText("")
 }
 
-// SetInputCapture sets the capture function for key events in the input field.
+// SetInputCapture sets the capture function for key events in the input
+// field.  Page Up and Page Down page through the event view instead.
 func (t *TUI) SetInputCapture(captureFunc func(capture *tcell.EventKey) *tcell.EventKey) {
-	t.inputField.SetInputCapture(captureFunc)
+	t.inputField.SetInputCapture(func(capture *tcell.EventKey) *tcell.EventKey {
+		switch capture.Key() {
+		case tcell.KeyPgUp:
+			t.pageEvents(-1)
+			return nil
+		case tcell.KeyPgDn:
+			t.pageEvents(1)
+			return nil
+		}
+		return captureFunc(capture)
+	})
+}
+
+// QueueUpdate runs f on the event loop and redraws the screen, for the
+// methods that must be called from the event loop.  QueueUpdate must
+// not be called from the event loop.
+func (t *TUI) QueueUpdate(f func()) {
+	t.app.QueueUpdateDraw(f)
 }
 
 // RunApp starts the application and the main event loop.
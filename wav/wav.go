@@ -0,0 +1,236 @@
+package wav
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"github.com/scottmcleodjr/rekl/morse"
+)
+
+const (
+	DefaultTone       = 700                    // Same tone as the Beep Key
+	DefaultSampleRate = 8000                   // Plenty for a CW tone
+	DefaultRise       = 5 * time.Millisecond   // Avoids key clicks
+	padding           = 250 * time.Millisecond // Silence around a message in a file
+	amplitude         = 0.8 * math.MaxInt16
+	streamSize        = math.MaxUint32 // Data chunk size of a WAV written to a stream, read to the end
+)
+
+// Settings are the audio settings for rendering CW.
+type Settings struct {
+	Tone       int           // Tone frequency in Hz
+	SampleRate int           // Samples per second
+	Rise       time.Duration // Rise and fall time of each element
+}
+
+// DefaultSettings returns the default audio settings.
+func DefaultSettings() Settings {
+	return Settings{Tone: DefaultTone, SampleRate: DefaultSampleRate, Rise: DefaultRise}
+}
+
+// Validate returns an error if the settings can not be rendered.
+func (s Settings) Validate() error {
+	if s.SampleRate <= 0 {
+		return errors.New("sample rate must be positive")
+	}
+	if s.Tone <= 0 || s.Tone >= s.SampleRate/2 {
+		return errors.New("tone must be positive and below half the sample rate")
+	}
+	if s.Rise < 0 {
+		return errors.New("rise time must not be negative")
+	}
+	return nil
+}
+
+// Render returns 16 bit samples of the keyed tone for a list of elements.
+// Element boundaries are rounded to the nearest sample from the start, so
+// rounding does not build up over a long message.  Each key down element
+// is shaped with a raised cosine rise and fall inside its own length.
+func Render(elements []morse.Element, s Settings) []int16 {
+	var total time.Duration
+	for _, element := range elements {
+		total += element.Duration
+	}
+	samples := make([]int16, sampleIndex(total, s.SampleRate))
+
+	var elapsed time.Duration
+	for _, element := range elements {
+		start := sampleIndex(elapsed, s.SampleRate)
+		elapsed += element.Duration
+		end := sampleIndex(elapsed, s.SampleRate)
+		if element.Down {
+			renderTone(samples[start:end], start, s)
+		}
+	}
+	return samples
+}
+
+// SampleCount returns the number of samples for a duration.
+func SampleCount(d time.Duration, sampleRate int) int {
+	return sampleIndex(d, sampleRate)
+}
+
+func sampleIndex(d time.Duration, sampleRate int) int {
+	return int(math.Round(d.Seconds() * float64(sampleRate)))
+}
+
+func renderTone(samples []int16, offset int, s Settings) {
+	ramp := sampleIndex(s.Rise, s.SampleRate)
+	if ramp > len(samples)/2 {
+		ramp = len(samples) / 2
+	}
+	step := 2 * math.Pi * float64(s.Tone) / float64(s.SampleRate)
+	for i := range samples {
+		gain := 1.0
+		switch {
+		case i < ramp:
+			gain = 0.5 - 0.5*math.Cos(math.Pi*float64(i)/float64(ramp))
+		case i >= len(samples)-ramp:
+			gain = 0.5 - 0.5*math.Cos(math.Pi*float64(len(samples)-1-i)/float64(ramp))
+		}
+		samples[i] = int16(amplitude * gain * math.Sin(step*float64(offset+i)))
+	}
+}
+
+// Write writes samples to w as a mono 16 bit PCM WAV file.
+func Write(w io.Writer, samples []int16, sampleRate int) error {
+	dataSize := uint32(2 * len(samples))
+	header := struct {
+		RIFF          [4]byte
+		ChunkSize     uint32
+		WAVE          [4]byte
+		Fmt           [4]byte
+		FmtSize       uint32
+		AudioFormat   uint16
+		Channels      uint16
+		SampleRate    uint32
+		ByteRate      uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+		Data          [4]byte
+		DataSize      uint32
+	}{
+		RIFF:          [4]byte{'R', 'I', 'F', 'F'},
+		ChunkSize:     36 + dataSize,
+		WAVE:          [4]byte{'W', 'A', 'V', 'E'},
+		Fmt:           [4]byte{'f', 'm', 't', ' '},
+		FmtSize:       16,
+		AudioFormat:   1, // PCM
+		Channels:      1,
+		SampleRate:    uint32(sampleRate),
+		ByteRate:      uint32(2 * sampleRate),
+		BlockAlign:    2,
+		BitsPerSample: 16,
+		Data:          [4]byte{'d', 'a', 't', 'a'},
+		DataSize:      dataSize,
+	}
+
+	err := binary.Write(w, binary.LittleEndian, header)
+	if err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, samples)
+}
+
+// WriteFile renders a message with the timing and settings and writes it
+// to a WAV file at path.  The message has a short silence before and after
+// it, so players and decoders can settle before the first element.
+func WriteFile(path string, message string, timing morse.Timing, s Settings) error {
+	err := s.Validate()
+	if err != nil {
+		return err
+	}
+	silence := morse.Element{Down: false, Duration: padding}
+	elements := append([]morse.Element{silence}, timing.Elements(message)...)
+	samples := Render(append(elements, silence), s)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = Write(file, samples, s.SampleRate)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Read reads a 16 bit PCM WAV file and returns the samples of the first
+// channel and the sample rate.  The data is read as it comes rather than
+// trusting the size in the file, and a WAV written to a stream, with the
+// largest size, is read to the end.
+func Read(r io.Reader) ([]int16, int, error) {
+	var riff struct {
+		RIFF      [4]byte
+		ChunkSize uint32
+		WAVE      [4]byte
+	}
+	err := binary.Read(r, binary.LittleEndian, &riff)
+	if err != nil {
+		return nil, 0, err
+	}
+	if string(riff.RIFF[:]) != "RIFF" || string(riff.WAVE[:]) != "WAVE" {
+		return nil, 0, errors.New("not a WAV file")
+	}
+
+	var format struct {
+		AudioFormat   uint16
+		Channels      uint16
+		SampleRate    uint32
+		ByteRate      uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+	}
+	haveFormat := false
+	for {
+		var chunk struct {
+			ID   [4]byte
+			Size uint32
+		}
+		err := binary.Read(r, binary.LittleEndian, &chunk)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		switch string(chunk.ID[:]) {
+		case "fmt ":
+			err = binary.Read(r, binary.LittleEndian, &format)
+			if err != nil {
+				return nil, 0, err
+			}
+			if format.AudioFormat != 1 || format.BitsPerSample != 16 || format.Channels == 0 {
+				return nil, 0, errors.New("only 16 bit PCM WAV files are supported")
+			}
+			haveFormat = true
+			_, err = io.CopyN(io.Discard, r, int64(chunk.Size)-16+int64(chunk.Size%2))
+		case "data":
+			if !haveFormat {
+				return nil, 0, errors.New("WAV data before format")
+			}
+			data, err := io.ReadAll(io.LimitReader(r, int64(chunk.Size)))
+			if err != nil {
+				return nil, 0, err
+			}
+			if int64(len(data)) < int64(chunk.Size) && chunk.Size != streamSize {
+				return nil, 0, fmt.Errorf("WAV data is %d bytes, want %d", len(data), chunk.Size)
+			}
+			channels := int(format.Channels)
+			samples := make([]int16, len(data)/2/channels)
+			for i := range samples {
+				samples[i] = int16(binary.LittleEndian.Uint16(data[2*i*channels:]))
+			}
+			return samples, int(format.SampleRate), nil
+		default:
+			_, err = io.CopyN(io.Discard, r, int64(chunk.Size)+int64(chunk.Size%2))
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package wav_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/scottmcleodjr/rekl/morse"
+	"github.com/scottmcleodjr/rekl/wav"
+)
+
+func TestRenderSampleCount(t *testing.T) {
+	tests := []struct {
+		message    string
+		wpm        int
+		sampleRate int
+		wantUnits  int
+	}{
+		{message: "PARIS", wpm: 20, sampleRate: 8000, wantUnits: morse.ParisUnits - morse.WordGap},
+		{message: "PARIS PARIS", wpm: 20, sampleRate: 8000, wantUnits: 2*morse.ParisUnits - morse.WordGap},
+		{message: "PARIS", wpm: 12, sampleRate: 44100, wantUnits: morse.ParisUnits - morse.WordGap},
+		{message: "E", wpm: 30, sampleRate: 48000, wantUnits: 1},
+	}
+
+	for _, test := range tests {
+		settings := wav.Settings{Tone: 600, SampleRate: test.sampleRate, Rise: 4 * time.Millisecond}
+		samples := wav.Render(morse.Timing{WPM: test.wpm, Weight: 50}.Elements(test.message), settings)
+		// One PARIS unit is 1.2 seconds / WPM
+		want := test.wantUnits * test.sampleRate * 1200 / test.wpm / 1000
+		if len(samples) != want {
+			t.Errorf("got %d, want %d samples for %q at %d WPM and %d Hz",
+				len(samples), want, test.message, test.wpm, test.sampleRate)
+		}
+	}
+}
+
+func TestRenderSilentGaps(t *testing.T) {
+	settings := wav.DefaultSettings()
+	samples := wav.Render(morse.Timing{WPM: 20}.Elements("E E"), settings)
+	unit := wav.SampleCount(morse.Dit(20), settings.SampleRate)
+
+	for i, sample := range samples[unit : len(samples)-unit] {
+		if sample != 0 {
+			t.Fatalf("got sample %d at %d, want silence in the word gap", sample, unit+i)
+		}
+	}
+
+	// Rise shaping starts each element at zero
+	if samples[0] != 0 {
+		t.Errorf("got first sample %d, want 0 with rise shaping", samples[0])
+	}
+	loud := false
+	for _, sample := range samples[:unit] {
+		if sample > 10000 {
+			loud = true
+		}
+	}
+	if !loud {
+		t.Error("got no loud samples, want a tone for the first element")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	samples := []int16{0, 100, -100, 0}
+	var buf bytes.Buffer
+	err := wav.Write(&buf, samples, 8000)
+	if err != nil {
+		t.Fatalf("got error %q writing WAV", err)
+	}
+
+	data := buf.Bytes()
+	if len(data) != 44+2*len(samples) {
+		t.Fatalf("got %d bytes, want %d", len(data), 44+2*len(samples))
+	}
+	if string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" || string(data[36:40]) != "data" {
+		t.Errorf("got header %q, want RIFF WAVE header", data[:44])
+	}
+	if rate := binary.LittleEndian.Uint32(data[24:28]); rate != 8000 {
+		t.Errorf("got sample rate %d, want 8000", rate)
+	}
+	if sample := int16(binary.LittleEndian.Uint16(data[46:48])); sample != 100 {
+		t.Errorf("got second sample %d, want 100", sample)
+	}
+}
+
+func TestSettingsValidate(t *testing.T) {
+	tests := []struct {
+		settings    wav.Settings
+		errorWanted bool
+	}{
+		{settings: wav.DefaultSettings(), errorWanted: false},
+		{settings: wav.Settings{Tone: 700, SampleRate: 0}, errorWanted: true},
+		{settings: wav.Settings{Tone: 5000, SampleRate: 8000}, errorWanted: true}, // Above Nyquist
+		{settings: wav.Settings{Tone: 700, SampleRate: 8000, Rise: -time.Millisecond}, errorWanted: true},
+	}
+
+	for _, test := range tests {
+		err := test.settings.Validate()
+		if test.errorWanted && (err == nil) {
+			t.Errorf("got nil, want error for settings %+v", test.settings)
+		}
+		if !test.errorWanted && (err != nil) {
+			t.Errorf("got error, want nil for settings %+v", test.settings)
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	samples := wav.Render(morse.Timing{WPM: 20}.Elements("CQ"), wav.DefaultSettings())
+	var buf bytes.Buffer
+	err := wav.Write(&buf, samples, wav.DefaultSampleRate)
+	if err != nil {
+		t.Fatalf("got error %q writing WAV", err)
+	}
+
+	got, sampleRate, err := wav.Read(&buf)
+	if err != nil {
+		t.Fatalf("got error %q reading WAV", err)
+	}
+	if sampleRate != wav.DefaultSampleRate {
+		t.Errorf("got sample rate %d, want %d", sampleRate, wav.DefaultSampleRate)
+	}
+	if len(got) != len(samples) {
+		t.Fatalf("got %d samples, want %d", len(got), len(samples))
+	}
+	for i := range got {
+		if got[i] != samples[i] {
+			t.Fatalf("got sample %d at %d, want %d", got[i], i, samples[i])
+		}
+	}
+
+	_, _, err = wav.Read(bytes.NewReader([]byte("not a wav file at all, just text")))
+	if err == nil {
+		t.Error("got nil, want error reading text as WAV")
+	}
+}
+
+func TestReadDataSize(t *testing.T) {
+	samples := wav.Render(morse.Timing{WPM: 20}.Elements("E"), wav.DefaultSettings())
+	var buf bytes.Buffer
+	err := wav.Write(&buf, samples, wav.DefaultSampleRate)
+	if err != nil {
+		t.Fatalf("got error %q writing WAV", err)
+	}
+	file := buf.Bytes()
+	sizeAt := bytes.Index(file, []byte("data")) + 4
+
+	// A truncated file is an error
+	_, _, err = wav.Read(bytes.NewReader(file[:len(file)-10]))
+	if err == nil {
+		t.Error("got nil, want error for short WAV data")
+	}
+
+	// A stream has the largest size, and is read to the end without
+	// making room for all of it first
+	stream := append([]byte{}, file...)
+	binary.LittleEndian.PutUint32(stream[sizeAt:], math.MaxUint32)
+	got, _, err := wav.Read(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("got error %q reading a stream", err)
+	}
+	if len(got) != len(samples) {
+		t.Errorf("got %d samples, want %d from a stream", len(got), len(samples))
+	}
+}
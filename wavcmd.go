@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/scottmcleodjr/rekl/config"
+	"github.com/scottmcleodjr/rekl/handler"
+	"github.com/scottmcleodjr/rekl/wav"
+)
+
+// runWav renders the text in the arguments, or on stdin if there
+// are no arguments, as CW audio in a WAV file.  The timing and audio
+// settings come from the config file, and the flags that are set
+// override them.
+func runWav(args []string) {
+	flags := flag.NewFlagSet("rekl wav", flag.ExitOnError)
+	out := flags.String("out", "rekl.wav", "Path of the WAV file to write")
+	configPath := flags.String("config", userConfigPath("config.json"), "Path of the config file, with the speed and audio settings")
+	speed := flags.Int("speed", config.InitSpeed, "CW speed in WPM")
+	farnsworth := flags.Int("farnsworth", 0, "Farnsworth speed in WPM, 0 for off")
+	weight := flags.Int("weight", config.InitWeight, "Key down weight as a percentage")
+	tone := flags.Int("tone", wav.DefaultTone, "Tone frequency in Hz")
+	sampleRate := flags.Int("rate", wav.DefaultSampleRate, "Sample rate in Hz")
+	rise := flags.Duration("rise", wav.DefaultRise, "Rise and fall time of each element")
+	flags.Parse(args)
+
+	text := strings.Join(flags.Args(), " ")
+	if flags.NArg() == 0 {
+		input, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			log.Fatalf("unable to read stdin: %s", err)
+		}
+		text = string(input)
+	}
+
+	message, err := handler.ValidateMessage(strings.Join(strings.Fields(text), " "))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if message == "" {
+		log.Fatal("nothing to render")
+	}
+
+	// Use Config for the same range checks as the interactive commands
+	cfg := config.New()
+	f, err := config.LoadFile(*configPath)
+	if err != nil {
+		log.Fatalf("unable to load config: %s", err)
+	}
+	err = cfg.Apply(f)
+	if err != nil {
+		log.Fatalf("unable to load config %s: %s", *configPath, err)
+	}
+	settings := cfg.WavSettings()
+	var errs []error
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "speed":
+			errs = append(errs, cfg.SetSpeed(*speed))
+		case "farnsworth":
+			errs = append(errs, cfg.SetFarnsworth(*farnsworth))
+		case "weight":
+			errs = append(errs, cfg.SetWeight(*weight))
+		case "tone":
+			settings.Tone = *tone
+		case "rate":
+			settings.SampleRate = *sampleRate
+		case "rise":
+			settings.Rise = *rise
+		}
+	})
+	errs = append(errs, cfg.SetWavSettings(settings))
+	for _, err := range errs {
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	err = wav.WriteFile(*out, message, cfg.Timing(), cfg.WavSettings())
+	if err != nil {
+		log.Fatalf("unable to write WAV file: %s", err)
+	}
+}